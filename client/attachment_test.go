@@ -0,0 +1,168 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestAttachFileEncryptsBeforeSending(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	plaintext := []byte("recovery codes: 1234")
+
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		var req protocol.AttachFileRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return
+		}
+		if req.ItemID != "item-1" || req.Filename != "codes.txt" {
+			t.Errorf("unexpected attach request: %+v", req)
+		}
+		decrypted, err := crypto.Decrypt(req.Data, masterKey)
+		if err != nil || string(decrypted) != string(plaintext) {
+			t.Errorf("expected the attachment to be encrypted under the master key, got err=%v data=%q", err, decrypted)
+		}
+		resp, _ := json.Marshal(protocol.AttachFileResponse{ID: "attachment-1"})
+		writeFramedResponse(serverConn, protocol.MsgTypeAttachFileResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	id, err := c.AttachFile("item-1", "codes.txt", plaintext)
+	if err != nil {
+		t.Fatalf("AttachFile: %v", err)
+	}
+	if id != "attachment-1" {
+		t.Fatalf("got id %q, want %q", id, "attachment-1")
+	}
+}
+
+func TestListAttachmentsReturnsServerList(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, _ := json.Marshal(protocol.ListAttachmentsResponse{
+			Attachments: []protocol.Attachment{{ID: "a1", Filename: "codes.txt", Size: 21}},
+		})
+		writeFramedResponse(serverConn, protocol.MsgTypeListAttachmentsResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn}
+	attachments, err := c.ListAttachments("item-1")
+	if err != nil {
+		t.Fatalf("ListAttachments: %v", err)
+	}
+	if len(attachments) != 1 || attachments[0].ID != "a1" {
+		t.Fatalf("unexpected attachments: %+v", attachments)
+	}
+}
+
+func TestDownloadAttachmentDecryptsAndVerifiesChecksum(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	plaintext := []byte("recovery codes: 1234")
+	encrypted, err := crypto.Encrypt(plaintext, masterKey)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	sum := sha256.Sum256(encrypted)
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, _ := json.Marshal(protocol.DownloadAttachmentResponse{
+			Filename:       "codes.txt",
+			Data:           encrypted,
+			ChecksumSHA256: hex.EncodeToString(sum[:]),
+		})
+		writeFramedResponse(serverConn, protocol.MsgTypeDownloadAttachmentResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	data, filename, err := c.DownloadAttachment("a1")
+	if err != nil {
+		t.Fatalf("DownloadAttachment: %v", err)
+	}
+	if filename != "codes.txt" || string(data) != string(plaintext) {
+		t.Fatalf("got filename=%q data=%q", filename, data)
+	}
+}
+
+func TestDownloadAttachmentRejectsMismatchingChecksum(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	encrypted, err := crypto.Encrypt([]byte("tampered"), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, _ := json.Marshal(protocol.DownloadAttachmentResponse{
+			Data:           encrypted,
+			ChecksumSHA256: "0000000000000000000000000000000000000000000000000000000000000000",
+		})
+		writeFramedResponse(serverConn, protocol.MsgTypeDownloadAttachmentResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	if _, _, err := c.DownloadAttachment("a1"); err == nil {
+		t.Fatal("expected an error when the checksum doesn't match the downloaded data")
+	}
+}
+
+func TestDeleteAttachmentSendsID(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		var req protocol.DeleteAttachmentRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return
+		}
+		if req.ID != "a1" {
+			t.Errorf("got id %q, want %q", req.ID, "a1")
+		}
+		resp, _ := json.Marshal(protocol.DeleteAttachmentResponse{})
+		writeFramedResponse(serverConn, protocol.MsgTypeDeleteAttachmentResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn}
+	if err := c.DeleteAttachment("a1"); err != nil {
+		t.Fatalf("DeleteAttachment: %v", err)
+	}
+}
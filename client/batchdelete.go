@@ -0,0 +1,35 @@
+package client
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// SummarizeBatchDeleteResults turns a BatchDelete outcome into a short
+// human-readable summary such as "3 удалено, 1: data item not found",
+// grouping failures by their exact error message so a batch with
+// several distinct failure reasons reports each count separately rather
+// than lumping every failure together.
+func SummarizeBatchDeleteResults(results []protocol.BatchDeleteResult) string {
+	deleted := 0
+	failureCounts := make(map[string]int)
+	var failureOrder []string
+	for _, r := range results {
+		if r.Error == "" {
+			deleted++
+			continue
+		}
+		if _, seen := failureCounts[r.Error]; !seen {
+			failureOrder = append(failureOrder, r.Error)
+		}
+		failureCounts[r.Error]++
+	}
+
+	parts := []string{fmt.Sprintf("%d удалено", deleted)}
+	for _, reason := range failureOrder {
+		parts = append(parts, fmt.Sprintf("%d: %s", failureCounts[reason], reason))
+	}
+	return strings.Join(parts, ", ")
+}
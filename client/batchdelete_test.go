@@ -0,0 +1,43 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestSummarizeBatchDeleteResultsAllSucceed(t *testing.T) {
+	results := []protocol.BatchDeleteResult{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	if got, want := SummarizeBatchDeleteResults(results), "3 удалено"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSummarizeBatchDeleteResultsMixedSuccessAndFailure(t *testing.T) {
+	results := []protocol.BatchDeleteResult{
+		{ID: "1"},
+		{ID: "2"},
+		{ID: "3"},
+		{ID: "4", Error: "data item not found"},
+	}
+	if got, want := SummarizeBatchDeleteResults(results), "3 удалено, 1: data item not found"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSummarizeBatchDeleteResultsGroupsDistinctFailureReasons(t *testing.T) {
+	results := []protocol.BatchDeleteResult{
+		{ID: "1", Error: "data item not found"},
+		{ID: "2", Error: "data item not found"},
+		{ID: "3", Error: "permission denied"},
+	}
+	if got, want := SummarizeBatchDeleteResults(results), "0 удалено, 2: data item not found, 1: permission denied"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestSummarizeBatchDeleteResultsEmpty(t *testing.T) {
+	if got, want := SummarizeBatchDeleteResults(nil), "0 удалено"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
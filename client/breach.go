@@ -0,0 +1,95 @@
+package client
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// BreachResult identifies a stored login whose password matched an entry
+// in a local breached-hash list.
+type BreachResult struct {
+	ItemID   string
+	ItemName string
+
+	// Count is the occurrence count recorded next to the matching hash
+	// in the local file, or 0 if the file didn't record one.
+	Count int
+}
+
+// loadBreachedHashes reads a local breached-password file into a set of
+// upper-case hex SHA-1 hashes. Each line is "HASH" or "HASH:COUNT" — the
+// same shape as a Have I Been Pwned k-anonymity range response, except
+// each line carries the full hash rather than just the suffix for a
+// requested 5-character prefix, since CheckBreached checks a whole file
+// at once instead of querying per-prefix.
+func loadBreachedHashes(localHashFile string) (map[string]int, error) {
+	f, err := os.Open(localHashFile)
+	if err != nil {
+		return nil, fmt.Errorf("open breached hash file: %w", err)
+	}
+	defer f.Close()
+
+	hashes := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		hash, countStr, _ := strings.Cut(line, ":")
+		hash = strings.ToUpper(strings.TrimSpace(hash))
+		count := 0
+		if countStr != "" {
+			count, _ = strconv.Atoi(strings.TrimSpace(countStr))
+		}
+		hashes[hash] = count
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breached hash file: %w", err)
+	}
+	return hashes, nil
+}
+
+// CheckBreached syncs every login item, hashes each stored password with
+// SHA-1, and checks it against localHashFile — a locally provided list
+// in the k-anonymity style used by Have I Been Pwned's range API, so no
+// password or hash prefix is ever sent anywhere. It returns one
+// BreachResult per matching item.
+func (c *Client) CheckBreached(localHashFile string) ([]BreachResult, error) {
+	hashes, err := loadBreachedHashes(localHashFile)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := c.SyncData(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []BreachResult
+	for _, item := range items {
+		if item.Type != protocol.DataTypeLogin {
+			continue
+		}
+		var login Login
+		if err := json.Unmarshal(item.Data, &login); err != nil {
+			continue
+		}
+
+		sum := sha1.Sum([]byte(login.Password))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+		if count, ok := hashes[hash]; ok {
+			results = append(results, BreachResult{ItemID: item.ID, ItemName: item.Name, Count: count})
+		}
+	}
+	return results, nil
+}
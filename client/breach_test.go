@@ -0,0 +1,126 @@
+package client
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// serveOneSyncResponse reads a single framed request off conn and
+// replies with a SyncResponse wrapping items.
+func serveOneSyncResponse(conn net.Conn, items []protocol.DataItem) error {
+	reqHeader := make([]byte, protocol.HeaderSize)
+	if _, err := io.ReadFull(conn, reqHeader); err != nil {
+		return err
+	}
+	reqLength := binary.BigEndian.Uint32(reqHeader[5:9])
+	if reqLength > 0 {
+		if _, err := io.ReadFull(conn, make([]byte, reqLength)); err != nil {
+			return err
+		}
+	}
+
+	respPayload, err := json.Marshal(protocol.SyncResponse{Items: items})
+	if err != nil {
+		return err
+	}
+	respHeader := make([]byte, protocol.HeaderSize)
+	respHeader[0] = protocol.MsgTypeSyncResponse
+	copy(respHeader[1:5], reqHeader[1:5])
+	binary.BigEndian.PutUint32(respHeader[5:9], uint32(len(respPayload)))
+	if _, err := conn.Write(respHeader); err != nil {
+		return err
+	}
+	_, err = conn.Write(respPayload)
+	return err
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestCheckBreachedFlagsMatchingPasswords(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+
+	breachedData, err := json.Marshal(map[string]string{protocol.FieldLogin: "alice", protocol.FieldPassword: "123456"})
+	if err != nil {
+		t.Fatalf("marshal breached login: %v", err)
+	}
+	safeData, err := json.Marshal(map[string]string{protocol.FieldLogin: "bob", protocol.FieldPassword: "correct-horse-battery-staple"})
+	if err != nil {
+		t.Fatalf("marshal safe login: %v", err)
+	}
+	breachedEncrypted, err := crypto.Encrypt(breachedData, masterKey)
+	if err != nil {
+		t.Fatalf("encrypt breached login: %v", err)
+	}
+	safeEncrypted, err := crypto.Encrypt(safeData, masterKey)
+	if err != nil {
+		t.Fatalf("encrypt safe login: %v", err)
+	}
+
+	items := []protocol.DataItem{
+		{ID: "item-breached", Type: protocol.DataTypeLogin, Name: "example.com", Data: breachedEncrypted},
+		{ID: "item-safe", Type: protocol.DataTypeLogin, Name: "other.com", Data: safeEncrypted},
+	}
+
+	go serveOneSyncResponse(serverConn, items)
+
+	breachFile := filepath.Join(t.TempDir(), "breached.txt")
+	content := sha1Hex("123456") + ":2000000\n" + sha1Hex("some-other-leaked-password") + ":5\n"
+	if err := os.WriteFile(breachFile, []byte(content), 0o600); err != nil {
+		t.Fatalf("write breach file: %v", err)
+	}
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	results, err := c.CheckBreached(breachFile)
+	if err != nil {
+		t.Fatalf("CheckBreached: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 breached result, got %d: %+v", len(results), results)
+	}
+	if results[0].ItemID != "item-breached" {
+		t.Fatalf("expected item-breached to be flagged, got %+v", results[0])
+	}
+	if results[0].Count != 2000000 {
+		t.Fatalf("expected count 2000000, got %d", results[0].Count)
+	}
+}
+
+func TestCheckBreachedReturnsErrorForMissingFile(t *testing.T) {
+	c := &Client{masterKey: []byte("0123456789abcdef0123456789abcdef")}
+	if _, err := c.CheckBreached(filepath.Join(t.TempDir(), "does-not-exist.txt")); err == nil {
+		t.Fatal("expected an error for a missing breach file")
+	}
+}
+
+func TestLoadBreachedHashesAcceptsHashesWithoutCount(t *testing.T) {
+	breachFile := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(breachFile, []byte(sha1Hex("password")+"\n"), 0o600); err != nil {
+		t.Fatalf("write breach file: %v", err)
+	}
+
+	hashes, err := loadBreachedHashes(breachFile)
+	if err != nil {
+		t.Fatalf("loadBreachedHashes: %v", err)
+	}
+	if count, ok := hashes[sha1Hex("password")]; !ok || count != 0 {
+		t.Fatalf("expected hash present with count 0, got ok=%v count=%d", ok, count)
+	}
+}
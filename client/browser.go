@@ -0,0 +1,46 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// validateURL checks that raw is a well-formed absolute http(s) URL,
+// suitable for storing against a login item and later opening with
+// openInBrowser.
+func validateURL(raw string) error {
+	u, err := url.ParseRequestURI(raw)
+	if err != nil {
+		return fmt.Errorf("некорректный URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("URL должен начинаться с http:// или https://")
+	}
+	if u.Host == "" {
+		return fmt.Errorf("в URL отсутствует хост")
+	}
+	return nil
+}
+
+// openInBrowser launches the OS default browser on rawURL. It refuses
+// to try on a headless Linux session (no X11/Wayland display), where
+// there's no browser to open and shelling out would just hang or fail.
+func openInBrowser(rawURL string) error {
+	if runtime.GOOS == "linux" && os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return fmt.Errorf("нет графического окружения для открытия браузера")
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", rawURL)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", rawURL)
+	default:
+		cmd = exec.Command("xdg-open", rawURL)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,28 @@
+package client
+
+import "testing"
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{"valid http", "http://example.com", false},
+		{"valid https with path", "https://example.com/login?next=1", false},
+		{"missing scheme", "example.com", true},
+		{"unsupported scheme", "ftp://example.com", true},
+		{"scheme only, no host", "https://", true},
+		{"empty", "", true},
+		{"not a URL at all", "not a url", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateURL(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateURL(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestEncryptItemFieldsRecordsChecksum(t *testing.T) {
+	c := &Client{masterKey: []byte("0123456789abcdef0123456789abcdef")}
+	item := protocol.NewDataItem{Type: protocol.DataTypeText, Data: []byte(`{"text":"hello"}`)}
+
+	if err := c.encryptItemFields(&item); err != nil {
+		t.Fatalf("encryptItemFields: %v", err)
+	}
+	if item.Metadata[protocol.MetaChecksumSHA256] == "" {
+		t.Fatal("expected a checksum to be recorded in metadata")
+	}
+}
+
+func TestDecryptItemFieldsAcceptsCorrectChecksum(t *testing.T) {
+	c := &Client{masterKey: []byte("0123456789abcdef0123456789abcdef")}
+	newItem := protocol.NewDataItem{Type: protocol.DataTypeText, Data: []byte(`{"text":"hello"}`)}
+	if err := c.encryptItemFields(&newItem); err != nil {
+		t.Fatalf("encryptItemFields: %v", err)
+	}
+
+	var buf bytes.Buffer
+	c.Logger = log.New(&buf, "", 0)
+
+	item := protocol.DataItem{Type: newItem.Type, Data: newItem.Data, Metadata: newItem.Metadata}
+	if err := c.decryptItemFields(&item); err != nil {
+		t.Fatalf("decryptItemFields: %v", err)
+	}
+	if string(item.Data) != `{"text":"hello"}` {
+		t.Fatalf("unexpected decrypted data: %s", item.Data)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected no checksum warning for a correct checksum, got: %s", buf.String())
+	}
+}
+
+func TestDecryptItemFieldsFlagsTamperedPayload(t *testing.T) {
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{masterKey: masterKey}
+	newItem := protocol.NewDataItem{Type: protocol.DataTypeText, Data: []byte(`{"text":"hello"}`)}
+	if err := c.encryptItemFields(&newItem); err != nil {
+		t.Fatalf("encryptItemFields: %v", err)
+	}
+
+	// Re-encrypt a different plaintext but keep the original checksum in
+	// metadata, simulating a payload that changed after the checksum was
+	// recorded.
+	tamperedCiphertext, err := c.encryptData([]byte(`{"text":"tampered"}`))
+	if err != nil {
+		t.Fatalf("encryptData: %v", err)
+	}
+
+	var buf bytes.Buffer
+	c.Logger = log.New(&buf, "", 0)
+
+	item := protocol.DataItem{ID: "item-1", Type: newItem.Type, Data: tamperedCiphertext, Metadata: newItem.Metadata}
+	if err := c.decryptItemFields(&item); err != nil {
+		t.Fatalf("decryptItemFields: %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Fatal("expected a checksum mismatch warning for a tampered payload")
+	}
+}
+
+func TestDecryptItemFieldsFlagsWrongKey(t *testing.T) {
+	saveKey := []byte("0123456789abcdef0123456789abcdef")
+	wrongKey := []byte("fedcba9876543210fedcba9876543210")
+
+	saver := &Client{masterKey: saveKey}
+	newItem := protocol.NewDataItem{Type: protocol.DataTypeText, Data: []byte(`{"text":"hello"}`)}
+	if err := saver.encryptItemFields(&newItem); err != nil {
+		t.Fatalf("encryptItemFields: %v", err)
+	}
+
+	reader := &Client{masterKey: wrongKey}
+	var buf bytes.Buffer
+	reader.Logger = log.New(&buf, "", 0)
+
+	item := protocol.DataItem{ID: "item-2", Type: newItem.Type, Data: newItem.Data, Metadata: newItem.Metadata}
+	if err := reader.decryptItemFields(&item); err == nil {
+		if buf.Len() == 0 {
+			t.Fatal("expected either a decrypt error or a checksum mismatch warning under the wrong key")
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// validMetadataKeys returns the set of metadata keys that belong on an
+// item of type t. MetaTags, MetaFavorite, and MetaChecksumSHA256 are
+// client-set on any item type, so every set includes them.
+func validMetadataKeys(t uint8) map[string]bool {
+	keys := map[string]bool{
+		protocol.MetaTags:           true,
+		protocol.MetaFavorite:       true,
+		protocol.MetaChecksumSHA256: true,
+	}
+	switch t {
+	case protocol.DataTypeLogin:
+		keys[protocol.MetaURL] = true
+		keys[protocol.MetaPasswordHistory] = true
+	case protocol.DataTypeBinary:
+		keys[protocol.MetaOriginalFileName] = true
+		keys[protocol.MetaOriginalSize] = true
+	}
+	return keys
+}
+
+// orphanedMetadataKeys returns the keys in metadata that aren't valid for
+// an item of type t, e.g. MetaOriginalFileName left over on an item that
+// was a binary file before a type-changing edit.
+func orphanedMetadataKeys(t uint8, metadata map[string]string) []string {
+	valid := validMetadataKeys(t)
+	var orphaned []string
+	for key := range metadata {
+		if !valid[key] {
+			orphaned = append(orphaned, key)
+		}
+	}
+	return orphaned
+}
+
+// CleanupItem describes the orphaned metadata found on a single item.
+type CleanupItem struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	OrphanedKeys []string `json:"orphaned_keys"`
+}
+
+// CleanupReport summarizes the result of a vault-wide metadata cleanup:
+// which items had orphaned keys, and what was removed from each.
+type CleanupReport struct {
+	Items []CleanupItem `json:"items"`
+}
+
+// Cleanup scans every item in the vault for metadata keys that don't
+// belong to the item's data type (e.g. MetaOriginalFileName left over on
+// an item that was a binary file before a type-changing edit), strips
+// them via UpdateMetadata, and reports what was removed from each item.
+func (c *Client) Cleanup() (CleanupReport, error) {
+	items, err := c.SyncMetadataOnly(time.Time{})
+	if err != nil {
+		return CleanupReport{}, err
+	}
+
+	var report CleanupReport
+	for _, item := range items {
+		orphaned := orphanedMetadataKeys(item.Type, item.Metadata)
+		if len(orphaned) == 0 {
+			continue
+		}
+		report.Items = append(report.Items, CleanupItem{ID: item.ID, Name: item.Name, OrphanedKeys: orphaned})
+
+		valid := validMetadataKeys(item.Type)
+		cleaned := make(map[string]string, len(item.Metadata))
+		for key, value := range item.Metadata {
+			if valid[key] {
+				cleaned[key] = value
+			}
+		}
+		if err := c.UpdateMetadata(item.ID, cleaned); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
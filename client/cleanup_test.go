@@ -0,0 +1,128 @@
+package client
+
+import (
+	"encoding/json"
+	"net"
+	"sort"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestValidMetadataKeysPerType(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  uint8
+		want []string
+	}{
+		{"login", protocol.DataTypeLogin, []string{
+			protocol.MetaChecksumSHA256, protocol.MetaFavorite, protocol.MetaPasswordHistory, protocol.MetaTags, protocol.MetaURL,
+		}},
+		{"card", protocol.DataTypeCard, []string{protocol.MetaChecksumSHA256, protocol.MetaFavorite, protocol.MetaTags}},
+		{"text", protocol.DataTypeText, []string{protocol.MetaChecksumSHA256, protocol.MetaFavorite, protocol.MetaTags}},
+		{"binary", protocol.DataTypeBinary, []string{
+			protocol.MetaChecksumSHA256, protocol.MetaFavorite, protocol.MetaOriginalFileName, protocol.MetaOriginalSize, protocol.MetaTags,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid := validMetadataKeys(tt.typ)
+			var got []string
+			for key := range valid {
+				got = append(got, key)
+			}
+			sort.Strings(got)
+			sort.Strings(tt.want)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOrphanedMetadataKeysFindsMismatchedKeys(t *testing.T) {
+	metadata := map[string]string{
+		protocol.MetaOriginalFileName: "report.pdf",
+		protocol.MetaTags:             "work",
+	}
+	orphaned := orphanedMetadataKeys(protocol.DataTypeText, metadata)
+	if len(orphaned) != 1 || orphaned[0] != protocol.MetaOriginalFileName {
+		t.Fatalf("got %v, want [%s]", orphaned, protocol.MetaOriginalFileName)
+	}
+}
+
+func TestOrphanedMetadataKeysEmptyWhenAllValid(t *testing.T) {
+	metadata := map[string]string{protocol.MetaURL: "https://example.com"}
+	if orphaned := orphanedMetadataKeys(protocol.DataTypeLogin, metadata); len(orphaned) != 0 {
+		t.Fatalf("expected no orphaned keys, got %v", orphaned)
+	}
+}
+
+func TestCleanupRemovesOrphanedMetadataAndReports(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	c := &Client{conn: clientConn, masterKey: []byte("0123456789abcdef0123456789abcdef")}
+
+	item := protocol.DataItem{
+		ID:   "item-1",
+		Type: protocol.DataTypeText,
+		Name: "Note",
+		Metadata: map[string]string{
+			protocol.MetaOriginalFileName: "old.bin",
+			protocol.MetaTags:             "work",
+		},
+	}
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, err := json.Marshal(protocol.SyncResponse{Items: []protocol.DataItem{item}})
+		if err != nil {
+			return
+		}
+		if err := writeFramedResponse(serverConn, protocol.MsgTypeSyncResponse, msgID, resp); err != nil {
+			return
+		}
+
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		var updateReq protocol.UpdateMetadataRequest
+		if err := json.Unmarshal(payload, &updateReq); err != nil {
+			return
+		}
+		if _, ok := updateReq.Metadata[protocol.MetaOriginalFileName]; ok {
+			t.Errorf("expected orphaned key to be stripped, got %v", updateReq.Metadata)
+		}
+		if updateReq.Metadata[protocol.MetaTags] != "work" {
+			t.Errorf("expected valid key to be kept, got %v", updateReq.Metadata)
+		}
+		resp, err = json.Marshal(protocol.UpdateMetadataResponse{})
+		if err != nil {
+			return
+		}
+		writeFramedResponse(serverConn, protocol.MsgTypeUpdateMetadataResponse, msgID, resp)
+	}()
+
+	report, err := c.Cleanup()
+	if err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+	if len(report.Items) != 1 || report.Items[0].ID != "item-1" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if len(report.Items[0].OrphanedKeys) != 1 || report.Items[0].OrphanedKeys[0] != protocol.MetaOriginalFileName {
+		t.Fatalf("unexpected orphaned keys: %v", report.Items[0].OrphanedKeys)
+	}
+}
@@ -0,0 +1,1702 @@
+// Package client implements the password manager's TCP client: the
+// wire-level Client used to talk to the server, and the interactive
+// UIClient built on top of it.
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// Client is a connection to a password manager server plus the session
+// state (auth token, master key) needed to encrypt and authenticate
+// requests.
+type Client struct {
+	addr string
+	conn net.Conn
+
+	token      string
+	username   string
+	masterKey  []byte
+	kdfSalt    []byte
+	wrappedDEK []byte
+
+	passwordAgeDays int
+	rotationDue     bool
+
+	lastDeletedID string
+
+	nextID uint32
+
+	// MaxResponseSize caps how large a declared response payload the
+	// client will allocate for, guarding against a malicious or buggy
+	// server declaring an unreasonable length. Zero means
+	// defaultMaxResponseSize.
+	MaxResponseSize uint32
+
+	// PinnedCertSHA256 is the expected SHA-256 fingerprint (hex-encoded,
+	// case-insensitive) of the server's TLS leaf certificate, checked
+	// against a "tls://" address in place of normal chain verification.
+	// This protects against a rogue CA even if the system trust store is
+	// compromised. Empty means: for a "tls://" address, fall back to
+	// normal certificate verification against the system roots.
+	PinnedCertSHA256 string
+
+	// Logger, if set, receives one line per request/response pair naming
+	// the request's correlation ID (the wire-level MessageID). Matching
+	// that ID against the server's own log (see requestLogger in
+	// server/handler.go) lets a user's bug report be traced end-to-end.
+	// Nil disables logging, which is the default.
+	Logger *log.Logger
+
+	// EncryptMetadataValues, when set, encrypts each metadata value
+	// client-side before StoreData/UpdateData, and transparently decrypts
+	// them back on read. Metadata keys are left alone so the server can
+	// still be asked to search or filter on them; only the values, which
+	// can hold sensitive things like URLs or bank names, are hidden from
+	// anyone with database access. Off by default so existing callers
+	// keep seeing plaintext metadata without a migration step.
+	EncryptMetadataValues bool
+
+	// DisableAutoConnect, when set, makes a method that would otherwise
+	// silently dial the server on first use return ErrNotConnected
+	// instead. Off by default, matching sendAndReceive's long-standing
+	// auto-connect behavior; set it when a caller wants Connect's
+	// failure to surface explicitly rather than happen implicitly inside
+	// whatever method it first called.
+	DisableAutoConnect bool
+}
+
+// ErrNotConnected is returned by a method that needs an open connection
+// when none exists and DisableAutoConnect is set, instead of the method
+// silently dialing one.
+var ErrNotConnected = errors.New("client is not connected")
+
+// clearCredentials zeroes the cached master key and clears the session
+// token, so a subsequent request is sent unauthenticated and any attempt
+// to decrypt local data fails until Login supplies fresh credentials.
+// The connection itself is left open: locking the vault shouldn't force
+// a fresh dial.
+func (c *Client) clearCredentials() {
+	for i := range c.masterKey {
+		c.masterKey[i] = 0
+	}
+	c.masterKey = nil
+	c.token = ""
+}
+
+// IsConnected reports whether the client currently holds an open
+// connection to the server. It doesn't probe the connection, so it can
+// still report true for a connection the peer has since closed; the
+// next request will discover that and reconnect (or fail with
+// ErrNotConnected, if DisableAutoConnect is set).
+func (c *Client) IsConnected() bool {
+	return c.conn != nil
+}
+
+// defaultMaxResponseSize is the response payload cap used when
+// Client.MaxResponseSize is unset.
+const defaultMaxResponseSize = 64 * 1024 * 1024
+
+// NewClient creates a Client that will dial addr on first use.
+func NewClient(addr string) *Client {
+	return &Client{addr: addr}
+}
+
+// ClientOptions configures a Client constructed via NewClientWithOptions.
+type ClientOptions struct {
+	// DisableAutoConnect, if set, requires an explicit Connect call
+	// before any method that needs a connection: such a method returns
+	// ErrNotConnected instead of silently dialing. The zero value (false)
+	// preserves NewClient's behavior of auto-connecting as needed, which
+	// is why this is phrased as a "disable" flag rather than an
+	// "AutoConnect" one — a bool that defaults to true has no zero value
+	// that means "true", so it couldn't be told apart from an explicit
+	// opt-out. Useful for an embedder that wants explicit control over
+	// connection timing and retries instead of having them implicit in
+	// the first request.
+	DisableAutoConnect bool
+}
+
+// NewClientWithOptions is NewClient with explicit control over
+// auto-connect behavior via opts.
+func NewClientWithOptions(addr string, opts ClientOptions) *Client {
+	return &Client{addr: addr, DisableAutoConnect: opts.DisableAutoConnect}
+}
+
+// unixSocketPrefix marks a Client address as a Unix domain socket path
+// rather than a host:port, e.g. "unix:///var/run/pwmanager.sock".
+const unixSocketPrefix = "unix://"
+
+// tlsPrefix marks a Client address as a TCP address to be dialed over
+// TLS, e.g. "tls://pwmanager.example.com:8443".
+const tlsPrefix = "tls://"
+
+// Connect dials the server: over TCP for a host:port address, over TLS
+// for an address prefixed with "tls://", or over a Unix domain socket
+// for an address prefixed with "unix://". Most Client methods call it
+// automatically if needed.
+func (c *Client) Connect() error {
+	if strings.HasPrefix(c.addr, tlsPrefix) {
+		return c.connectTLS(strings.TrimPrefix(c.addr, tlsPrefix))
+	}
+
+	network, address := "tcp", c.addr
+	if strings.HasPrefix(c.addr, unixSocketPrefix) {
+		network, address = "unix", strings.TrimPrefix(c.addr, unixSocketPrefix)
+	}
+
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+	return nil
+}
+
+func (c *Client) connectTLS(address string) error {
+	tlsConfig := &tls.Config{}
+	if c.PinnedCertSHA256 != "" {
+		// The pin itself is the trust anchor, so the usual chain/hostname
+		// checks are redundant with (and can't substitute for) it.
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = verifyPinnedCertificate(c.PinnedCertSHA256)
+	}
+
+	conn, err := tls.Dial("tcp", address, tlsConfig)
+	if err != nil {
+		return err
+	}
+	c.conn = conn
+	return nil
+}
+
+// verifyPinnedCertificate returns a tls.Config.VerifyPeerCertificate
+// callback that accepts the connection only if the server's leaf
+// certificate's SHA-256 fingerprint matches pinnedHex.
+func verifyPinnedCertificate(pinnedHex string) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("server presented no certificate to pin against")
+		}
+		fingerprint := sha256.Sum256(rawCerts[0])
+		got := hex.EncodeToString(fingerprint[:])
+		want := strings.ToLower(strings.ReplaceAll(pinnedHex, ":", ""))
+		if got != want {
+			return fmt.Errorf("server certificate fingerprint %s does not match pinned fingerprint %s", got, want)
+		}
+		return nil
+	}
+}
+
+// Close zeroes the cached master key, clears the session token and
+// username, and closes the underlying connection, so a closed Client
+// leaves no secrets behind in memory. This matters for long-running
+// processes that create and close many Clients over their lifetime.
+func (c *Client) Close() error {
+	c.clearCredentials()
+	c.username = ""
+	if c.conn == nil {
+		return nil
+	}
+	return c.conn.Close()
+}
+
+// sendAndReceive writes a framed request and reads back the framed
+// response, auto-connecting if no connection is open yet (or returning
+// ErrNotConnected instead, if DisableAutoConnect is set). If the
+// connection turns out to be dead (the server restarted, the network
+// dropped) and msgType is safe to resend, it reconnects once and replays
+// the request before giving up. A bare reconnect is enough: every
+// request already carries c.token, so there's no per-connection session
+// to re-establish with a fresh Login.
+//
+// The replay is restricted to message types isSafeToRetry allows,
+// because a connection-loss error can mean the request reached the
+// server and was fully processed and only the response was lost in
+// transit. Resending a read is harmless; resending a write like
+// StoreData or CreateShare would silently duplicate it.
+func (c *Client) sendAndReceive(msgType uint8, payload []byte) (uint8, []byte, error) {
+	respType, respPayload, err := c.sendAndReceiveOnce(msgType, payload)
+	if err != nil && isConnectionLossError(err) && isSafeToRetry(msgType) {
+		c.reconnect()
+		respType, respPayload, err = c.sendAndReceiveOnce(msgType, payload)
+	}
+	return respType, respPayload, err
+}
+
+// retryableMsgTypes are the request types sendAndReceive will reconnect
+// and replay after a connection-loss error: reads and other requests
+// with no server-side effect, where resending on a fresh connection
+// can't change the outcome. Writes are deliberately excluded, since the
+// original request may already have been fully processed by the server.
+var retryableMsgTypes = map[uint8]bool{
+	protocol.MsgTypeAuthRequest:         true,
+	protocol.MsgTypeRecoveryInfoRequest: true,
+	protocol.MsgTypeGetDataRequest:      true,
+	protocol.MsgTypeSyncRequest:         true,
+	protocol.MsgTypeListModifiedRequest: true,
+	protocol.MsgTypeStatsRequest:        true,
+	protocol.MsgTypeDownloadRequest:     true,
+	protocol.MsgTypePingRequest:         true,
+}
+
+// isSafeToRetry reports whether msgType may be blindly resent on a fresh
+// connection after the original attempt failed with a connection-loss
+// error.
+func isSafeToRetry(msgType uint8) bool {
+	return retryableMsgTypes[msgType]
+}
+
+// sendAndReceiveOnce is sendAndReceive without the reconnect-and-replay
+// wrapper, so that wrapper can bound itself to a single retry instead of
+// recursing.
+func (c *Client) sendAndReceiveOnce(msgType uint8, payload []byte) (uint8, []byte, error) {
+	if c.conn == nil {
+		if c.DisableAutoConnect {
+			return 0, nil, ErrNotConnected
+		}
+		if err := c.Connect(); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	id := atomic.AddUint32(&c.nextID, 1)
+
+	if c.Logger != nil {
+		c.Logger.Printf("[req %d] sending message type %s", id, protocol.MsgType(msgType))
+	}
+
+	if err := protocol.WriteMessage(c.conn, msgType, id, payload); err != nil {
+		return 0, nil, err
+	}
+
+	maxSize := c.MaxResponseSize
+	if maxSize == 0 {
+		maxSize = defaultMaxResponseSize
+	}
+
+	respHeader, respPayload, err := protocol.ReadMessage(c.conn, maxSize)
+	if errors.Is(err, protocol.ErrMessageTooLarge) {
+		return 0, nil, fmt.Errorf("server declared response of %d bytes, exceeding limit of %d", respHeader.Length, maxSize)
+	}
+	if err != nil {
+		if c.Logger != nil {
+			c.Logger.Printf("[req %d] error reading response: %v", id, err)
+		}
+		return 0, nil, err
+	}
+
+	// A mismatched message ID means these bytes don't belong to the
+	// request we just sent, e.g. a stray response left over from a
+	// connection a caller is reusing after an earlier partial read. Treat
+	// it the same as a connection failure rather than risk pairing a
+	// request with the wrong response.
+	if respHeader.MessageID != id {
+		return 0, nil, fmt.Errorf("received response for message %d, expected %d", respHeader.MessageID, id)
+	}
+	if c.Logger != nil {
+		c.Logger.Printf("[req %d] received response type %s, length %d", id, protocol.MsgType(respHeader.Type), respHeader.Length)
+	}
+	return respHeader.Type, respPayload, nil
+}
+
+// reconnect discards the current connection, if any, so the next call to
+// sendAndReceive dials a fresh one. Used after a read is interrupted
+// partway through, since at that point the stream's framing can no
+// longer be trusted.
+func (c *Client) reconnect() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn = nil
+	}
+}
+
+// isPartialReadError reports whether err looks like the connection was
+// cut mid-read, as opposed to a request that reached the server and came
+// back with a clean (if unsuccessful) response.
+func isPartialReadError(err error) bool {
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// isConnectionLossError reports whether err looks like the underlying
+// connection died outright — the server restarted, the network dropped
+// mid-write — rather than a request that reached the server and came
+// back with a clean, if unsuccessful, response. sendAndReceive uses this
+// to decide whether reconnecting and replaying the request is worth
+// trying at all.
+func isConnectionLossError(err error) bool {
+	if isPartialReadError(err) || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, net.ErrClosed) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset")
+}
+
+// deriveSimpleKey derives a key-wrapping key from the account's username
+// and master password. It's kept only as a fallback for accounts
+// registered before KDFSalt existed; current registrations use
+// deriveSaltedKey instead, since deriving from the username means a
+// rename has to re-wrap the data-encryption key (see RenameAccount).
+func deriveSimpleKey(username, password string) []byte {
+	return crypto.DeriveKey(password, []byte(username))
+}
+
+// deriveSaltedKey derives a key-wrapping key from a server-issued
+// per-account salt and the master password, so the same key can be
+// re-derived from any device and survives a username change.
+func deriveSaltedKey(password string, salt []byte) []byte {
+	return crypto.DeriveKey(password, salt)
+}
+
+// Register creates a new account on the server, generating a random
+// data-encryption key and wrapping it under a key derived from
+// password. Vault items are always encrypted under the DEK, never
+// directly under the password-derived key, so the DEK can later be
+// re-wrapped (rotated password, recovery, re-key) without re-deriving
+// from scratch.
+func (c *Client) Register(username, password string) error {
+	_, err := c.registerAccount(username, password, "")
+	return err
+}
+
+// RegisterWithRecovery is like Register, but also generates a recovery
+// key that can later be used with RecoverWithKey if the master password
+// is lost. The returned key is shown to the user exactly once; the
+// server never sees it in plaintext after this call.
+func (c *Client) RegisterWithRecovery(username, password string) (recoveryKey string, err error) {
+	recoveryKey, err = crypto.GenerateRecoveryKey()
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.registerAccount(username, password, recoveryKey); err != nil {
+		return "", err
+	}
+	return recoveryKey, nil
+}
+
+func (c *Client) registerAccount(username, password, recoveryKey string) (dek []byte, err error) {
+	dek = make([]byte, crypto.KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, err
+	}
+
+	salt, err := crypto.GenerateSalt()
+	if err != nil {
+		return nil, err
+	}
+	wrappedDEK, err := crypto.Encrypt(dek, deriveSaltedKey(password, salt))
+	if err != nil {
+		return nil, err
+	}
+
+	req := protocol.RegisterRequest{Username: username, Password: password, WrappedDEK: wrappedDEK, KDFSalt: salt, ClientVersion: protocol.Version}
+	if recoveryKey != "" {
+		wrappedDEKRecovery, err := crypto.Encrypt(dek, crypto.DeriveKey(recoveryKey, []byte(username)))
+		if err != nil {
+			return nil, err
+		}
+		req.RecoveryKey = recoveryKey
+		req.WrappedDEKRecovery = wrappedDEKRecovery
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeRegisterRequest, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp protocol.RegisterResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, errors.New(resp.Error)
+	}
+	return dek, nil
+}
+
+// Login authenticates against the server and unwraps the account's
+// data-encryption key using a key derived from password.
+func (c *Client) Login(username, password string) error {
+	req := protocol.AuthRequest{Username: username, Password: password, ClientVersion: protocol.Version}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeAuthRequest, payload)
+	if err != nil {
+		return err
+	}
+
+	var resp protocol.AuthResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return errors.New(resp.Error)
+	}
+
+	kek := deriveSimpleKey(username, password)
+	if len(resp.KDFSalt) > 0 {
+		kek = deriveSaltedKey(password, resp.KDFSalt)
+	}
+	dek, err := crypto.Decrypt(resp.WrappedDEK, kek)
+	if err != nil {
+		return fmt.Errorf("unwrap data key: %w", err)
+	}
+
+	c.token = resp.Token
+	c.username = username
+	c.masterKey = dek
+	c.kdfSalt = resp.KDFSalt
+	c.wrappedDEK = resp.WrappedDEK
+	c.passwordAgeDays = resp.PasswordAge
+	c.rotationDue = resp.RotationDue
+	return nil
+}
+
+// VerifyMasterPassword reports whether password re-derives the same
+// data-encryption key established at Login, without a server
+// round-trip. It's meant for re-gating access to an already-unlocked
+// session (e.g. after a vault timeout), not as a substitute for Login:
+// it requires wrappedDEK to already be cached from a prior Login, and
+// returns false rather than erroring if it isn't.
+func (c *Client) VerifyMasterPassword(password string) bool {
+	if len(c.wrappedDEK) == 0 {
+		return false
+	}
+
+	kek := deriveSimpleKey(c.username, password)
+	if len(c.kdfSalt) > 0 {
+		kek = deriveSaltedKey(password, c.kdfSalt)
+	}
+	dek, err := crypto.Decrypt(c.wrappedDEK, kek)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(dek, c.masterKey) == 1
+}
+
+// RecoverWithKey re-wraps the account's data-encryption key under a new
+// password using a previously generated recovery key, without needing
+// the old password. It requires a live connection but not a prior
+// Login.
+func (c *Client) RecoverWithKey(username, recoveryKey, newPassword string) error {
+	infoPayload, err := json.Marshal(protocol.RecoveryInfoRequest{Username: username})
+	if err != nil {
+		return err
+	}
+	_, infoRespPayload, err := c.sendAndReceive(protocol.MsgTypeRecoveryInfoRequest, infoPayload)
+	if err != nil {
+		return err
+	}
+	var infoResp protocol.RecoveryInfoResponse
+	if err := json.Unmarshal(infoRespPayload, &infoResp); err != nil {
+		return err
+	}
+	if !infoResp.Available {
+		return errors.New("no recovery key is set up for this account")
+	}
+
+	dek, err := crypto.Decrypt(infoResp.WrappedDEKRecovery, crypto.DeriveKey(recoveryKey, []byte(username)))
+	if err != nil {
+		return errors.New("invalid recovery key")
+	}
+
+	kek := deriveSimpleKey(username, newPassword)
+	if len(infoResp.KDFSalt) > 0 {
+		kek = deriveSaltedKey(newPassword, infoResp.KDFSalt)
+	}
+	newWrappedDEK, err := crypto.Encrypt(dek, kek)
+	if err != nil {
+		return err
+	}
+
+	req := protocol.RecoverRequest{
+		Username:      username,
+		RecoveryKey:   recoveryKey,
+		NewPassword:   newPassword,
+		NewWrappedDEK: newWrappedDEK,
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeRecoverRequest, payload)
+	if err != nil {
+		return err
+	}
+	var resp protocol.RecoverResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// PasswordRotationStatus reports how old the current session's master
+// password is and whether the server thinks it's due for rotation.
+func (c *Client) PasswordRotationStatus() (ageDays int, rotationDue bool) {
+	return c.passwordAgeDays, c.rotationDue
+}
+
+// RenameAccount changes the caller's username. It requires an active
+// session (Login must have already unwrapped the data-encryption key).
+// Accounts with a KDFSalt derive their key-wrapping key independently of
+// the username, so renaming doesn't disturb it; only legacy accounts
+// without a salt need their data-encryption key re-wrapped here.
+func (c *Client) RenameAccount(newName, password string) error {
+	var newWrappedDEK []byte
+	if len(c.kdfSalt) == 0 {
+		wrapped, err := crypto.Encrypt(c.masterKey, deriveSimpleKey(newName, password))
+		if err != nil {
+			return err
+		}
+		newWrappedDEK = wrapped
+	}
+
+	req := protocol.RenameUserRequest{
+		Token:         c.token,
+		NewUsername:   newName,
+		Password:      password,
+		NewWrappedDEK: newWrappedDEK,
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeRenameUserRequest, payload)
+	if err != nil {
+		return err
+	}
+	var resp protocol.RenameUserResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+
+	c.username = newName
+	return nil
+}
+
+// RekeyDataKey generates a new data-encryption key, re-wraps it under
+// the account's existing key-wrapping key, and re-encrypts every item
+// under it — all without changing the login password. Useful after a
+// suspected key leak.
+//
+// This isn't atomic: the wrapped key is updated on the server before
+// items are re-encrypted, so a failure partway through leaves some
+// items under the new key and some under the old one. A retry re-syncs
+// and re-encrypts everything, since UpdateData is idempotent per item.
+func (c *Client) RekeyDataKey(password string) error {
+	items, err := c.SyncData(time.Time{})
+	if err != nil {
+		return err
+	}
+
+	newDEK := make([]byte, crypto.KeySize)
+	if _, err := rand.Read(newDEK); err != nil {
+		return err
+	}
+
+	kek := deriveSimpleKey(c.username, password)
+	if len(c.kdfSalt) > 0 {
+		kek = deriveSaltedKey(password, c.kdfSalt)
+	}
+	newWrappedDEK, err := crypto.Encrypt(newDEK, kek)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(protocol.RekeyRequest{Token: c.token, NewWrappedDEK: newWrappedDEK})
+	if err != nil {
+		return err
+	}
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeRekeyRequest, payload)
+	if err != nil {
+		return err
+	}
+	var resp protocol.RekeyResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+
+	c.masterKey = newDEK
+	for _, item := range items {
+		if err := c.UpdateData(item.ID, protocol.NewDataItem{
+			Type: item.Type, Name: item.Name, Data: item.Data, Notes: item.Notes, Metadata: item.Metadata,
+		}); err != nil {
+			return fmt.Errorf("re-encrypt item %s: %w", item.ID, err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) encryptData(data []byte) ([]byte, error) {
+	return crypto.Encrypt(data, c.masterKey)
+}
+
+// gzipCompress compresses data and reports whether the result was
+// actually smaller: encrypted data is close to incompressible, so
+// compressing already-compressed or tiny inputs would only add gzip's
+// own header/footer overhead. Callers should keep the original data
+// unless ok is true.
+func gzipCompress(data []byte) (compressed []byte, ok bool) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, false
+	}
+	if err := w.Close(); err != nil {
+		return nil, false
+	}
+	if buf.Len() >= len(data) {
+		return nil, false
+	}
+	return buf.Bytes(), true
+}
+
+func gzipDecompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (c *Client) decryptData(data []byte) ([]byte, error) {
+	return crypto.Decrypt(data, c.masterKey)
+}
+
+// encryptMetadataValues returns a copy of metadata with every value
+// replaced by its base64-encoded ciphertext, so the result still fits
+// the map[string]string the wire format expects. Keys are left
+// untouched.
+func (c *Client) encryptMetadataValues(metadata map[string]string) (map[string]string, error) {
+	encrypted := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		ciphertext, err := c.encryptData([]byte(value))
+		if err != nil {
+			return nil, err
+		}
+		encrypted[key] = base64.StdEncoding.EncodeToString(ciphertext)
+	}
+	return encrypted, nil
+}
+
+// decryptMetadataValues is encryptMetadataValues's inverse, applied to
+// metadata fetched from the server.
+func (c *Client) decryptMetadataValues(metadata map[string]string) (map[string]string, error) {
+	decrypted := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		ciphertext, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := c.decryptData(ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		decrypted[key] = string(plaintext)
+	}
+	return decrypted, nil
+}
+
+// encryptItemFields records a SHA-256 checksum of item's plaintext Data
+// in its metadata, then encrypts Data, and Notes if any was set, in
+// place, ahead of a StoreData or UpdateData call. Notes shares Data's
+// encryption but is left alone when empty, so an item without a note
+// doesn't grow a spurious ciphertext. The checksum lets decryptItemFields
+// later detect corruption or a wrong key that GCM's own integrity check
+// wouldn't catch on its own.
+func (c *Client) encryptItemFields(item *protocol.NewDataItem) error {
+	checksum := sha256.Sum256(item.Data)
+	if item.Metadata == nil {
+		item.Metadata = make(map[string]string, 1)
+	}
+	item.Metadata[protocol.MetaChecksumSHA256] = hex.EncodeToString(checksum[:])
+
+	if compressed, ok := gzipCompress(item.Data); ok {
+		item.Data = compressed
+		item.Metadata[protocol.MetaCompression] = protocol.CompressionGzip
+	}
+
+	encrypted, err := c.encryptData(item.Data)
+	if err != nil {
+		return err
+	}
+	item.Data = encrypted
+
+	if len(item.Notes) > 0 {
+		encryptedNotes, err := c.encryptData(item.Notes)
+		if err != nil {
+			return err
+		}
+		item.Notes = encryptedNotes
+	}
+
+	if c.EncryptMetadataValues && len(item.Metadata) > 0 {
+		encryptedMetadata, err := c.encryptMetadataValues(item.Metadata)
+		if err != nil {
+			return err
+		}
+		item.Metadata = encryptedMetadata
+	}
+	return nil
+}
+
+// decryptItemFields is encryptItemFields's inverse, applied to a
+// DataItem fetched from the server. It also verifies the SHA-256
+// checksum encryptItemFields stored in metadata, logging a warning via
+// c.Logger (if set) on a mismatch instead of failing the call outright,
+// since a stale or hand-edited item might simply predate checksums.
+func (c *Client) decryptItemFields(item *protocol.DataItem) error {
+	decrypted, err := c.decryptData(item.Data)
+	if err != nil {
+		return err
+	}
+	item.Data = decrypted
+
+	if len(item.Notes) > 0 {
+		decryptedNotes, err := c.decryptData(item.Notes)
+		if err != nil {
+			return err
+		}
+		item.Notes = decryptedNotes
+	}
+
+	if c.EncryptMetadataValues && len(item.Metadata) > 0 {
+		decryptedMetadata, err := c.decryptMetadataValues(item.Metadata)
+		if err != nil {
+			return err
+		}
+		item.Metadata = decryptedMetadata
+	}
+
+	if item.Metadata[protocol.MetaCompression] == protocol.CompressionGzip {
+		decompressed, err := gzipDecompress(item.Data)
+		if err != nil {
+			return fmt.Errorf("decompress item %s: %w", item.ID, err)
+		}
+		item.Data = decompressed
+	}
+
+	if want, ok := item.Metadata[protocol.MetaChecksumSHA256]; ok {
+		got := sha256.Sum256(item.Data)
+		if want != hex.EncodeToString(got[:]) {
+			if c.Logger != nil {
+				c.Logger.Printf("item %s failed checksum verification after decrypt: possible corruption or wrong key", item.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// StoreData encrypts item's payload and saves it, returning the
+// server-assigned ID.
+func (c *Client) StoreData(item protocol.NewDataItem) (string, error) {
+	if err := c.encryptItemFields(&item); err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(protocol.StoreDataRequest{Token: c.token, Item: item})
+	if err != nil {
+		return "", err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeStoreDataRequest, payload)
+	if err != nil {
+		return "", err
+	}
+
+	var resp protocol.StoreDataResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+	return resp.ID, nil
+}
+
+// StoreDataFull is StoreData plus the server's full view of the stored
+// item (ID and created_at/updated_at timestamps), decrypted the same as
+// GetData would return it, so a caller can update its own state without
+// a follow-up fetch.
+func (c *Client) StoreDataFull(item protocol.NewDataItem) (protocol.DataItem, error) {
+	if err := c.encryptItemFields(&item); err != nil {
+		return protocol.DataItem{}, err
+	}
+
+	payload, err := json.Marshal(protocol.StoreDataRequest{Token: c.token, Item: item})
+	if err != nil {
+		return protocol.DataItem{}, err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeStoreDataRequest, payload)
+	if err != nil {
+		return protocol.DataItem{}, err
+	}
+
+	var resp protocol.StoreDataResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return protocol.DataItem{}, err
+	}
+	if resp.Error != "" {
+		return protocol.DataItem{}, errors.New(resp.Error)
+	}
+	if err := c.decryptItemFields(&resp.Item); err != nil {
+		return protocol.DataItem{}, err
+	}
+	return resp.Item, nil
+}
+
+// GetData fetches and decrypts a single item.
+func (c *Client) GetData(id string) (protocol.DataItem, error) {
+	payload, err := json.Marshal(protocol.GetDataRequest{Token: c.token, ID: id})
+	if err != nil {
+		return protocol.DataItem{}, err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeGetDataRequest, payload)
+	if err != nil {
+		return protocol.DataItem{}, err
+	}
+
+	var resp protocol.GetDataResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return protocol.DataItem{}, err
+	}
+	if resp.Error != "" {
+		return protocol.DataItem{}, errors.New(resp.Error)
+	}
+
+	item := resp.Item
+	if err := c.decryptItemFields(&item); err != nil {
+		return protocol.DataItem{}, err
+	}
+	return item, nil
+}
+
+// SyncData returns every item updated since the given time, decrypted.
+func (c *Client) SyncData(since time.Time) ([]protocol.DataItem, error) {
+	items, err := c.sync(since, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range items {
+		if err := c.decryptItemFields(&items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// SyncMetadataOnly is like SyncData, but skips transferring and
+// decrypting each item's Data blob — useful for listing many items when
+// the caller isn't ready to decrypt them yet. Fetch a specific item's
+// blob afterward with GetData.
+func (c *Client) SyncMetadataOnly(since time.Time) ([]protocol.DataItem, error) {
+	return c.sync(since, true)
+}
+
+// FullResync is SyncData with the cursor forced to the zero time,
+// ignoring whatever "since" a caller might otherwise track. Use it to
+// rebuild local state from scratch after suspected local corruption,
+// rather than trusting an incremental pull to fill in the gaps.
+func (c *Client) FullResync() ([]protocol.DataItem, error) {
+	return c.SyncData(time.Time{})
+}
+
+// maxSyncRetries bounds how many times sync reconnects and resends after
+// a partial read failure before giving up. Sync has no side effects on
+// the server, so resending it wholesale on a fresh connection is always
+// safe — there's nothing to double-process.
+const maxSyncRetries = 3
+
+func (c *Client) sync(since time.Time, metadataOnly bool) ([]protocol.DataItem, error) {
+	payload, err := json.Marshal(protocol.SyncRequest{Token: c.token, Since: since, MetadataOnly: metadataOnly})
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxSyncRetries; attempt++ {
+		if attempt > 0 {
+			c.reconnect()
+		}
+
+		_, respPayload, err := c.sendAndReceive(protocol.MsgTypeSyncRequest, payload)
+		if err != nil {
+			if !isPartialReadError(err) {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+
+		var resp protocol.SyncResponse
+		if err := json.Unmarshal(respPayload, &resp); err != nil {
+			return nil, err
+		}
+		if resp.Error != "" {
+			return nil, errors.New(resp.Error)
+		}
+		return resp.Items, nil
+	}
+	return nil, fmt.Errorf("sync interrupted after %d retries: %w", maxSyncRetries, lastErr)
+}
+
+// SyncStream is SyncData, but processes items one at a time as they
+// arrive off the wire (protocol.MsgTypeSyncBegin, then one
+// protocol.MsgTypeSyncItem per item, then protocol.MsgTypeSyncEnd)
+// instead of buffering the whole response, so a very large vault
+// doesn't need to fit in memory at once. fn is called once per
+// decrypted item, in order; if fn returns an error, SyncStream stops
+// reading immediately and returns that error without processing the
+// remaining items.
+//
+// Unlike SyncData, SyncStream doesn't retry on a partial read: since a
+// caller may already have acted on items fn was given before a failure,
+// blindly resending the whole sync isn't safe the way it is for the
+// all-at-once response. A returned error always leaves the underlying
+// connection unusable; SyncStream closes it so the next call reconnects.
+func (c *Client) SyncStream(lastSync time.Time, fn func(protocol.DataItem) error) (err error) {
+	defer func() {
+		if err != nil {
+			c.reconnect()
+		}
+	}()
+
+	if c.conn == nil {
+		if c.DisableAutoConnect {
+			return ErrNotConnected
+		}
+		if err := c.Connect(); err != nil {
+			return err
+		}
+	}
+
+	payload, err := json.Marshal(protocol.SyncStreamRequest{Token: c.token, Since: lastSync})
+	if err != nil {
+		return err
+	}
+
+	id := atomic.AddUint32(&c.nextID, 1)
+	if err := protocol.WriteMessage(c.conn, protocol.MsgTypeSyncStreamRequest, id, payload); err != nil {
+		return err
+	}
+
+	maxSize := c.MaxResponseSize
+	if maxSize == 0 {
+		maxSize = defaultMaxResponseSize
+	}
+
+	header, beginPayload, err := protocol.ReadMessage(c.conn, maxSize)
+	if err != nil {
+		return err
+	}
+	if header.Type != protocol.MsgTypeSyncBegin {
+		return fmt.Errorf("expected SyncBegin, got %s", protocol.MsgType(header.Type))
+	}
+	var begin protocol.SyncBeginResponse
+	if err := json.Unmarshal(beginPayload, &begin); err != nil {
+		return err
+	}
+	if begin.Error != "" {
+		return errors.New(begin.Error)
+	}
+
+	for i := 0; i < begin.Count; i++ {
+		header, itemPayload, err := protocol.ReadMessage(c.conn, maxSize)
+		if err != nil {
+			return err
+		}
+		if header.Type != protocol.MsgTypeSyncItem {
+			return fmt.Errorf("expected SyncItem, got %s", protocol.MsgType(header.Type))
+		}
+		var itemResp protocol.SyncItemResponse
+		if err := json.Unmarshal(itemPayload, &itemResp); err != nil {
+			return err
+		}
+		if err := c.decryptItemFields(&itemResp.Item); err != nil {
+			return err
+		}
+		if err := fn(itemResp.Item); err != nil {
+			return err
+		}
+	}
+
+	header, endPayload, err := protocol.ReadMessage(c.conn, maxSize)
+	if err != nil {
+		return err
+	}
+	if header.Type != protocol.MsgTypeSyncEnd {
+		return fmt.Errorf("expected SyncEnd, got %s", protocol.MsgType(header.Type))
+	}
+	var end protocol.SyncEndResponse
+	if err := json.Unmarshal(endPayload, &end); err != nil {
+		return err
+	}
+	if end.Error != "" {
+		return errors.New(end.Error)
+	}
+	return nil
+}
+
+// ListModified returns every item whose updated_at falls in
+// [since, until), decrypted locally. Unlike SyncData, which pulls
+// everything past a cursor, this is a bounded range query meant for
+// auditing ("show items changed last week").
+func (c *Client) ListModified(since, until time.Time) ([]protocol.DataItem, error) {
+	payload, err := json.Marshal(protocol.ListModifiedRequest{Token: c.token, Since: since, Until: until})
+	if err != nil {
+		return nil, err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeListModifiedRequest, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp protocol.ListModifiedResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+
+	for i := range resp.Items {
+		if err := c.decryptItemFields(&resp.Items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return resp.Items, nil
+}
+
+// Autocomplete returns up to limit items whose name starts with prefix,
+// decrypted locally, for interactive typing. limit <= 0 falls back to
+// the server's own default.
+func (c *Client) Autocomplete(prefix string, limit int) ([]protocol.DataItem, error) {
+	payload, err := json.Marshal(protocol.AutocompleteRequest{Token: c.token, Prefix: prefix, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeAutocompleteRequest, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp protocol.AutocompleteResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+
+	for i := range resp.Items {
+		if err := c.decryptItemFields(&resp.Items[i]); err != nil {
+			return nil, err
+		}
+	}
+	return resp.Items, nil
+}
+
+// UpdateData re-encrypts and overwrites an existing item.
+func (c *Client) UpdateData(id string, item protocol.NewDataItem) error {
+	if err := c.encryptItemFields(&item); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(protocol.UpdateDataRequest{Token: c.token, ID: id, Item: item})
+	if err != nil {
+		return err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeUpdateDataRequest, payload)
+	if err != nil {
+		return err
+	}
+
+	var resp protocol.UpdateDataResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// UpdateMetadata replaces an item's metadata without re-uploading its
+// (potentially large) encrypted data blob. Metadata isn't encrypted, so
+// there's nothing to decrypt on the way back down.
+func (c *Client) UpdateMetadata(id string, metadata map[string]string) error {
+	payload, err := json.Marshal(protocol.UpdateMetadataRequest{Token: c.token, ID: id, Metadata: metadata})
+	if err != nil {
+		return err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeUpdateMetadataRequest, payload)
+	if err != nil {
+		return err
+	}
+
+	var resp protocol.UpdateMetadataResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// DeleteData removes an item permanently.
+func (c *Client) DeleteData(id string) error {
+	payload, err := json.Marshal(protocol.DeleteDataRequest{Token: c.token, ID: id})
+	if err != nil {
+		return err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeDeleteDataRequest, payload)
+	if err != nil {
+		return err
+	}
+
+	var resp protocol.DeleteDataResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	c.lastDeletedID = id
+	return nil
+}
+
+// BatchDelete deletes every item in ids, continuing past per-item
+// failures rather than aborting on the first one: the returned
+// []BatchDeleteResult reports each ID's own outcome. The error return is
+// only non-nil for a request-level failure (network error, bad auth)
+// that means no deletes were attempted at all.
+func (c *Client) BatchDelete(ids []string) ([]protocol.BatchDeleteResult, error) {
+	payload, err := json.Marshal(protocol.BatchDeleteRequest{Token: c.token, IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeBatchDeleteRequest, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp protocol.BatchDeleteResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp.Results, nil
+}
+
+// shareTokenSeparator joins a share's server-side ID to the share key in
+// the combined token handed to CreateShare's caller. The key never
+// reaches the server; it only ever travels inside this token, however
+// the caller chooses to pass it on to the recipient.
+const shareTokenSeparator = "."
+
+// CreateShare snapshots itemID into a new share that can be redeemed up
+// to maxAccesses times (default 1, a one-time link) before ttl elapses.
+// It returns a single opaque token combining the share's server-side ID
+// with a share key generated just for this share; RedeemShare expects
+// that same token back.
+func (c *Client) CreateShare(itemID string, ttl time.Duration, maxAccesses int) (string, error) {
+	item, err := c.GetData(itemID)
+	if err != nil {
+		return "", err
+	}
+
+	shareKey := make([]byte, crypto.KeySize)
+	if _, err := rand.Read(shareKey); err != nil {
+		return "", err
+	}
+	encryptedData, err := crypto.Encrypt(item.Data, shareKey)
+	if err != nil {
+		return "", err
+	}
+
+	req := protocol.CreateShareRequest{
+		Token:         c.token,
+		ItemID:        itemID,
+		Type:          item.Type,
+		Name:          item.Name,
+		EncryptedData: encryptedData,
+		ExpiresAt:     time.Now().Add(ttl),
+		MaxAccesses:   maxAccesses,
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeCreateShareRequest, payload)
+	if err != nil {
+		return "", err
+	}
+	var resp protocol.CreateShareResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+
+	return resp.ShareID + shareTokenSeparator + base64.RawURLEncoding.EncodeToString(shareKey), nil
+}
+
+// RedeemShare exchanges a token produced by CreateShare for the shared
+// item's plaintext type, name, and data. It's unauthenticated: anyone
+// holding the token can redeem it, up to the share's access limit.
+func (c *Client) RedeemShare(token string) (protocol.DataItem, error) {
+	shareID, encodedKey, ok := strings.Cut(token, shareTokenSeparator)
+	if !ok {
+		return protocol.DataItem{}, errors.New("malformed share token")
+	}
+	shareKey, err := base64.RawURLEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return protocol.DataItem{}, fmt.Errorf("malformed share token: %w", err)
+	}
+
+	payload, err := json.Marshal(protocol.RedeemShareRequest{ShareID: shareID})
+	if err != nil {
+		return protocol.DataItem{}, err
+	}
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeRedeemShareRequest, payload)
+	if err != nil {
+		return protocol.DataItem{}, err
+	}
+	var resp protocol.RedeemShareResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return protocol.DataItem{}, err
+	}
+	if resp.Error != "" {
+		return protocol.DataItem{}, errors.New(resp.Error)
+	}
+
+	data, err := crypto.Decrypt(resp.Data, shareKey)
+	if err != nil {
+		return protocol.DataItem{}, fmt.Errorf("decrypt shared item: %w", err)
+	}
+
+	return protocol.DataItem{Type: resp.Type, Name: resp.Name, Data: data}, nil
+}
+
+// LastDeletedID returns the ID of the most recently deleted item in this
+// session, or "" if nothing has been deleted (or it was already
+// restored).
+func (c *Client) LastDeletedID() string {
+	return c.lastDeletedID
+}
+
+// RestoreData clears the tombstone left by DeleteData, making the item
+// visible again in sync and get.
+func (c *Client) RestoreData(itemID string) error {
+	payload, err := json.Marshal(protocol.RestoreDataRequest{Token: c.token, ID: itemID})
+	if err != nil {
+		return err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeRestoreDataRequest, payload)
+	if err != nil {
+		return err
+	}
+
+	var resp protocol.RestoreDataResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	if c.lastDeletedID == itemID {
+		c.lastDeletedID = ""
+	}
+	return nil
+}
+
+// Stats reports how many items the logged-in user has and how much
+// space they use.
+func (c *Client) Stats() (protocol.UserStats, error) {
+	payload, err := json.Marshal(protocol.StatsRequest{Token: c.token})
+	if err != nil {
+		return protocol.UserStats{}, err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeStatsRequest, payload)
+	if err != nil {
+		return protocol.UserStats{}, err
+	}
+
+	var resp protocol.StatsResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return protocol.UserStats{}, err
+	}
+	if resp.Error != "" {
+		return protocol.UserStats{}, errors.New(resp.Error)
+	}
+	return resp.Stats, nil
+}
+
+// DownloadFile fetches and decrypts a binary item's payload.
+func (c *Client) DownloadFile(id string) ([]byte, error) {
+	data, _, checksum, err := c.DownloadFileRange(id, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if checksum != "" {
+		got := sha256.Sum256(data)
+		if hex.EncodeToString(got[:]) != checksum {
+			return nil, fmt.Errorf("client: downloaded data doesn't match the server's checksum")
+		}
+	}
+	return c.decryptData(data)
+}
+
+// DownloadFileRange fetches the [offset, offset+length) slice of item
+// id's still-encrypted stored payload (length 0 meaning "through the
+// end"), along with totalSize, the item's full stored size, and
+// checksum, a hex-encoded SHA-256 of the item's *full* stored ciphertext
+// (not just this slice). It deliberately returns raw ciphertext rather
+// than decrypting: AES-GCM authenticates the whole payload together, so
+// a partial chunk can't be decrypted on its own. Reassemble every chunk
+// from offset 0 through totalSize and decrypt the result as a whole (see
+// DownloadFileResumable), or use DownloadFile for a single-shot
+// fetch-and-decrypt of the whole file.
+func (c *Client) DownloadFileRange(id string, offset, length int64) (data []byte, totalSize int64, checksum string, err error) {
+	payload, err := json.Marshal(protocol.DownloadRequest{Token: c.token, ID: id, Offset: offset, Length: length})
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeDownloadRequest, payload)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	var resp protocol.DownloadResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return nil, 0, "", err
+	}
+	if resp.Error != "" {
+		return nil, 0, "", errors.New(resp.Error)
+	}
+
+	return resp.Data, resp.TotalSize, resp.ChecksumSHA256, nil
+}
+
+// DownloadFileResumable finishes a chunked download that already has
+// alreadyDownloaded raw bytes (e.g. from a prior attempt over an
+// unreliable link), fetching only the remaining range and decrypting
+// once the full ciphertext has been reassembled. Pass a nil/empty slice
+// to download from the start. If the server reported a ciphertext
+// checksum, the reassembled data is verified against it before
+// decrypting, so a corrupted reassembly is caught with a clear error
+// rather than surfacing as an opaque AES-GCM authentication failure.
+func (c *Client) DownloadFileResumable(id string, alreadyDownloaded []byte) ([]byte, error) {
+	rest, totalSize, checksum, err := c.DownloadFileRange(id, int64(len(alreadyDownloaded)), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	full := append(append([]byte{}, alreadyDownloaded...), rest...)
+	if int64(len(full)) != totalSize {
+		return nil, fmt.Errorf("client: reassembled %d bytes, server reports %d", len(full), totalSize)
+	}
+	if checksum != "" {
+		got := sha256.Sum256(full)
+		if hex.EncodeToString(got[:]) != checksum {
+			return nil, fmt.Errorf("client: reassembled data doesn't match the server's checksum")
+		}
+	}
+	return c.decryptData(full)
+}
+
+// pingTimeout bounds how long Ping waits for a pong before giving up, so
+// an unreachable server reports a timeout instead of hanging forever. A
+// var, not a const, so tests can shrink it instead of actually waiting.
+var pingTimeout = 5 * time.Second
+
+// Ping measures round-trip latency to the server by sending a
+// PingRequest and timing the PingResponse. It's unauthenticated and has
+// no side effects, so it can be used to diagnose a slow or dead
+// connection before (or without) logging in.
+func (c *Client) Ping() (time.Duration, error) {
+	if c.conn == nil {
+		if c.DisableAutoConnect {
+			return 0, ErrNotConnected
+		}
+		if err := c.Connect(); err != nil {
+			return 0, err
+		}
+	}
+	if err := c.conn.SetDeadline(time.Now().Add(pingTimeout)); err != nil {
+		return 0, err
+	}
+	defer c.conn.SetDeadline(time.Time{})
+
+	payload, err := json.Marshal(protocol.PingRequest{})
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if _, _, err := c.sendAndReceive(protocol.MsgTypePingRequest, payload); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// DiagnoseReport summarizes a pre-flight check of a server, run before a
+// caller attempts to authenticate against it.
+type DiagnoseReport struct {
+	// Connected reports whether a TCP (or Unix socket) connection to the
+	// server could be established at all.
+	Connected bool
+	// RTT is the round-trip time of the ping used to reach ServerVersion
+	// and DBHealthy below.
+	RTT time.Duration
+	// ServerVersion is the protocol.Version the server reported.
+	ServerVersion int
+	// ProtocolCompatible reports whether ServerVersion matches this
+	// build's protocol.Version.
+	ProtocolCompatible bool
+	// DBHealthy reflects the server's own most recent database health
+	// check, from PingResponse.
+	DBHealthy bool
+}
+
+// Diagnose runs a pre-flight check against the server, meant to be run
+// once at startup so a connectivity or database problem is reported
+// clearly up front, instead of surfacing as a generic failure deep in
+// the auth flow. It checks TCP connectivity, exchanges a ping to learn
+// the server's reported protocol version and database health, and
+// leaves the connection open on success for the caller to authenticate
+// over, the same as Connect would.
+func (c *Client) Diagnose() (DiagnoseReport, error) {
+	var report DiagnoseReport
+
+	if c.conn == nil {
+		if c.DisableAutoConnect {
+			return report, ErrNotConnected
+		}
+		if err := c.Connect(); err != nil {
+			return report, fmt.Errorf("connect to server: %w", err)
+		}
+	}
+	report.Connected = true
+
+	if err := c.conn.SetDeadline(time.Now().Add(pingTimeout)); err != nil {
+		return report, err
+	}
+	defer c.conn.SetDeadline(time.Time{})
+
+	payload, err := json.Marshal(protocol.PingRequest{})
+	if err != nil {
+		return report, err
+	}
+
+	start := time.Now()
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypePingRequest, payload)
+	if err != nil {
+		return report, fmt.Errorf("ping server: %w", err)
+	}
+	report.RTT = time.Since(start)
+
+	var resp protocol.PingResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return report, err
+	}
+	report.ServerVersion = resp.ServerVersion
+	report.ProtocolCompatible = resp.ServerVersion == protocol.Version
+	report.DBHealthy = resp.DBHealthy
+	return report, nil
+}
+
+// AttachFile encrypts data under the client's master key and links it to
+// itemID as a new attachment. It returns the new attachment's server-side
+// ID.
+func (c *Client) AttachFile(itemID, filename string, data []byte) (string, error) {
+	encrypted, err := c.encryptData(data)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(protocol.AttachFileRequest{
+		Token:    c.token,
+		ItemID:   itemID,
+		Filename: filename,
+		Data:     encrypted,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeAttachFileRequest, payload)
+	if err != nil {
+		return "", err
+	}
+
+	var resp protocol.AttachFileResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+	return resp.ID, nil
+}
+
+// ListAttachments lists every attachment linked to itemID, without their
+// file contents.
+func (c *Client) ListAttachments(itemID string) ([]protocol.Attachment, error) {
+	payload, err := json.Marshal(protocol.ListAttachmentsRequest{Token: c.token, ItemID: itemID})
+	if err != nil {
+		return nil, err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeListAttachmentsRequest, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp protocol.ListAttachmentsResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp.Attachments, nil
+}
+
+// DownloadAttachment fetches and decrypts one attachment's content by ID,
+// verifying it against the server's checksum first.
+func (c *Client) DownloadAttachment(id string) (data []byte, filename string, err error) {
+	payload, err := json.Marshal(protocol.DownloadAttachmentRequest{Token: c.token, ID: id})
+	if err != nil {
+		return nil, "", err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeDownloadAttachmentRequest, payload)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var resp protocol.DownloadAttachmentResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return nil, "", err
+	}
+	if resp.Error != "" {
+		return nil, "", errors.New(resp.Error)
+	}
+	if resp.ChecksumSHA256 != "" {
+		got := sha256.Sum256(resp.Data)
+		if hex.EncodeToString(got[:]) != resp.ChecksumSHA256 {
+			return nil, "", fmt.Errorf("client: downloaded attachment doesn't match the server's checksum")
+		}
+	}
+
+	decrypted, err := c.decryptData(resp.Data)
+	if err != nil {
+		return nil, "", err
+	}
+	return decrypted, resp.Filename, nil
+}
+
+// DeleteAttachment removes one attachment by ID.
+func (c *Client) DeleteAttachment(id string) error {
+	payload, err := json.Marshal(protocol.DeleteAttachmentRequest{Token: c.token, ID: id})
+	if err != nil {
+		return err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeDeleteAttachmentRequest, payload)
+	if err != nil {
+		return err
+	}
+
+	var resp protocol.DeleteAttachmentResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return err
+	}
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// Manifest fetches a lightweight {id, updated_at, content_hash} summary
+// of every item the logged-in user owns, for diffing against a local
+// cache before deciding which items to actually fetch with GetData or
+// BatchDelete's read-side equivalents.
+func (c *Client) Manifest() ([]protocol.ManifestEntry, error) {
+	payload, err := json.Marshal(protocol.ManifestRequest{Token: c.token})
+	if err != nil {
+		return nil, err
+	}
+
+	_, respPayload, err := c.sendAndReceive(protocol.MsgTypeManifestRequest, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp protocol.ManifestResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	return resp.Entries, nil
+}
@@ -0,0 +1,391 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// TestRekeyDataKeyReencryptsUnderNewKey verifies the core guarantee
+// RekeyDataKey relies on: once an item's ciphertext has been produced
+// under a new data-encryption key, it decrypts under that key and no
+// longer decrypts under the old one.
+func TestRekeyDataKeyReencryptsUnderNewKey(t *testing.T) {
+	oldClient := &Client{masterKey: []byte("0123456789abcdef0123456789abcdef")}
+	plaintext := []byte("super secret note")
+
+	encryptedOld, err := oldClient.encryptData(plaintext)
+	if err != nil {
+		t.Fatalf("encryptData with old key: %v", err)
+	}
+
+	newClient := &Client{masterKey: []byte("fedcba9876543210fedcba9876543210")}
+	encryptedNew, err := newClient.encryptData(plaintext)
+	if err != nil {
+		t.Fatalf("encryptData with new key: %v", err)
+	}
+
+	decrypted, err := newClient.decryptData(encryptedNew)
+	if err != nil {
+		t.Fatalf("expected item re-encrypted under the new key to decrypt: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Fatal("expected decrypted plaintext to match original")
+	}
+
+	if _, err := newClient.decryptData(encryptedOld); err == nil {
+		t.Fatal("expected ciphertext still under the old key to fail to decrypt under the new key")
+	}
+}
+
+// TestVerifyMasterPasswordAcceptsCorrectPassword sets up a Client as if
+// Login had just unwrapped its data key under a salted key, then checks
+// that VerifyMasterPassword recognizes the same password without any
+// network I/O.
+func TestVerifyMasterPasswordAcceptsCorrectPassword(t *testing.T) {
+	salt := []byte("a-fixed-per-account-salt")
+	dek := []byte("0123456789abcdef0123456789abcdef")
+	kek := deriveSaltedKey("hunter2", salt)
+	wrappedDEK, err := crypto.Encrypt(dek, kek)
+	if err != nil {
+		t.Fatalf("wrap dek: %v", err)
+	}
+
+	c := &Client{masterKey: dek, kdfSalt: salt, wrappedDEK: wrappedDEK}
+	if !c.VerifyMasterPassword("hunter2") {
+		t.Fatal("expected VerifyMasterPassword to accept the correct password")
+	}
+}
+
+func TestVerifyMasterPasswordRejectsWrongPassword(t *testing.T) {
+	salt := []byte("a-fixed-per-account-salt")
+	dek := []byte("0123456789abcdef0123456789abcdef")
+	kek := deriveSaltedKey("hunter2", salt)
+	wrappedDEK, err := crypto.Encrypt(dek, kek)
+	if err != nil {
+		t.Fatalf("wrap dek: %v", err)
+	}
+
+	c := &Client{masterKey: dek, kdfSalt: salt, wrappedDEK: wrappedDEK}
+	if c.VerifyMasterPassword("wrong-password") {
+		t.Fatal("expected VerifyMasterPassword to reject an incorrect password")
+	}
+}
+
+func TestVerifyMasterPasswordFalseWithoutCachedWrappedDEK(t *testing.T) {
+	c := &Client{masterKey: []byte("0123456789abcdef0123456789abcdef")}
+	if c.VerifyMasterPassword("anything") {
+		t.Fatal("expected VerifyMasterPassword to fail without a cached wrapped DEK")
+	}
+}
+
+// TestDeriveSaltedKeyIsStableAcrossLogins verifies that the same
+// password and salt always derive the same key-wrapping key, regardless
+// of the username — the property that lets any device unwrap the same
+// data-encryption key after a plain re-login.
+func TestDeriveSaltedKeyIsStableAcrossLogins(t *testing.T) {
+	salt := []byte("a-fixed-per-account-salt")
+
+	key1 := deriveSaltedKey("hunter2", salt)
+	key2 := deriveSaltedKey("hunter2", salt)
+	if !bytes.Equal(key1, key2) {
+		t.Fatal("expected deriveSaltedKey to be deterministic for the same password and salt")
+	}
+
+	if otherSalt := deriveSaltedKey("hunter2", []byte("a-different-salt")); bytes.Equal(key1, otherSalt) {
+		t.Fatal("expected a different salt to produce a different key")
+	}
+}
+
+// TestSendAndReceiveRejectsOversizedLength verifies that a server
+// declaring an implausibly large response length is rejected before the
+// client allocates a buffer for it. The fake server never writes a
+// payload, so the test would hang (or the process would balloon in
+// memory) if sendAndReceive tried to read/allocate the declared length.
+func TestSendAndReceiveRejectsOversizedLength(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	c := &Client{conn: clientConn, MaxResponseSize: 1024}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		reqHeader := make([]byte, protocol.HeaderSize)
+		if _, err := io.ReadFull(serverConn, reqHeader); err != nil {
+			return
+		}
+		reqLength := binary.BigEndian.Uint32(reqHeader[5:9])
+		if reqLength > 0 {
+			io.ReadFull(serverConn, make([]byte, reqLength))
+		}
+
+		respHeader := make([]byte, protocol.HeaderSize)
+		respHeader[0] = protocol.MsgTypeAuthResponse
+		binary.BigEndian.PutUint32(respHeader[1:5], 1)
+		binary.BigEndian.PutUint32(respHeader[5:9], 1<<30)
+		serverConn.Write(respHeader)
+	}()
+
+	_, _, err := c.sendAndReceive(protocol.MsgTypeAuthRequest, []byte("{}"))
+	if err == nil {
+		t.Fatal("expected an error for an oversized declared response length")
+	}
+	<-done
+}
+
+// TestSyncRetriesAfterPartialReadFailure verifies that sync reconnects
+// and resends the request when the first attempt's connection is cut off
+// mid-payload, instead of failing the whole sync outright.
+func TestSyncRetriesAfterPartialReadFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	items := []protocol.DataItem{{ID: "item-1", Type: protocol.DataTypeText, Name: "note"}}
+	respPayload, err := json.Marshal(protocol.SyncResponse{Items: items})
+	if err != nil {
+		t.Fatalf("marshal sync response: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		// First connection: read the request, then drop the connection
+		// partway through writing the response payload.
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		reqHeader := make([]byte, protocol.HeaderSize)
+		if _, err := io.ReadFull(conn, reqHeader); err != nil {
+			conn.Close()
+			return
+		}
+		reqLength := binary.BigEndian.Uint32(reqHeader[5:9])
+		io.ReadFull(conn, make([]byte, reqLength))
+
+		respHeader := make([]byte, protocol.HeaderSize)
+		respHeader[0] = protocol.MsgTypeSyncResponse
+		copy(respHeader[1:5], reqHeader[1:5])
+		binary.BigEndian.PutUint32(respHeader[5:9], uint32(len(respPayload)))
+		conn.Write(respHeader)
+		conn.Write(respPayload[:len(respPayload)/2])
+		conn.Close()
+
+		// Second connection: the retry. Read the resent request and
+		// reply with the full, valid response.
+		conn, err = listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reqHeader = make([]byte, protocol.HeaderSize)
+		if _, err := io.ReadFull(conn, reqHeader); err != nil {
+			return
+		}
+		reqLength = binary.BigEndian.Uint32(reqHeader[5:9])
+		io.ReadFull(conn, make([]byte, reqLength))
+
+		respHeader = make([]byte, protocol.HeaderSize)
+		respHeader[0] = protocol.MsgTypeSyncResponse
+		copy(respHeader[1:5], reqHeader[1:5])
+		binary.BigEndian.PutUint32(respHeader[5:9], uint32(len(respPayload)))
+		conn.Write(respHeader)
+		conn.Write(respPayload)
+	}()
+
+	c := &Client{addr: listener.Addr().String()}
+	got, err := c.SyncMetadataOnly(time.Time{})
+	if err != nil {
+		t.Fatalf("expected the retry to succeed, got error: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "item-1" {
+		t.Fatalf("expected the retried sync to return the item, got %v", got)
+	}
+	<-done
+}
+
+// TestFullResyncIgnoresPriorSince verifies that FullResync always sends
+// a zero "since" cursor, even right after a call that used a non-zero
+// one — a caller reaching for FullResync to rebuild a suspect local
+// cache must not have that rebuild silently narrowed by leftover state.
+func TestFullResyncIgnoresPriorSince(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	var sinceValues []time.Time
+	respPayload, err := json.Marshal(protocol.SyncResponse{})
+	if err != nil {
+		t.Fatalf("marshal sync response: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2; i++ {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			reqHeader := make([]byte, protocol.HeaderSize)
+			if _, err := io.ReadFull(conn, reqHeader); err != nil {
+				conn.Close()
+				return
+			}
+			reqLength := binary.BigEndian.Uint32(reqHeader[5:9])
+			reqPayload := make([]byte, reqLength)
+			io.ReadFull(conn, reqPayload)
+
+			var req protocol.SyncRequest
+			json.Unmarshal(reqPayload, &req)
+			sinceValues = append(sinceValues, req.Since)
+
+			respHeader := make([]byte, protocol.HeaderSize)
+			respHeader[0] = protocol.MsgTypeSyncResponse
+			copy(respHeader[1:5], reqHeader[1:5])
+			binary.BigEndian.PutUint32(respHeader[5:9], uint32(len(respPayload)))
+			conn.Write(respHeader)
+			conn.Write(respPayload)
+			conn.Close()
+		}
+	}()
+
+	c := &Client{addr: listener.Addr().String()}
+	cursor := time.Now().Add(-24 * time.Hour)
+	if _, err := c.SyncMetadataOnly(cursor); err != nil {
+		t.Fatalf("incremental sync: %v", err)
+	}
+	if _, err := c.FullResync(); err != nil {
+		t.Fatalf("FullResync: %v", err)
+	}
+	<-done
+
+	if len(sinceValues) != 2 {
+		t.Fatalf("expected 2 sync requests, got %d", len(sinceValues))
+	}
+	if !sinceValues[0].Equal(cursor) {
+		t.Fatalf("expected the incremental sync to send its cursor, got %v", sinceValues[0])
+	}
+	if !sinceValues[1].IsZero() {
+		t.Fatalf("expected FullResync to send a zero since, got %v", sinceValues[1])
+	}
+}
+
+// TestSendAndReceiveReconnectsAfterConnectionLoss verifies that a dead
+// connection (e.g. left over from a server restart) doesn't fail the
+// request outright: sendAndReceive should reconnect using c.addr and
+// replay it once.
+func TestSendAndReceiveReconnectsAfterConnectionLoss(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	respPayload, err := json.Marshal(protocol.SyncResponse{})
+	if err != nil {
+		t.Fatalf("marshal sync response: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reqHeader := make([]byte, protocol.HeaderSize)
+		if _, err := io.ReadFull(conn, reqHeader); err != nil {
+			return
+		}
+		reqLength := binary.BigEndian.Uint32(reqHeader[5:9])
+		io.ReadFull(conn, make([]byte, reqLength))
+
+		respHeader := make([]byte, protocol.HeaderSize)
+		respHeader[0] = protocol.MsgTypeSyncResponse
+		copy(respHeader[1:5], reqHeader[1:5])
+		binary.BigEndian.PutUint32(respHeader[5:9], uint32(len(respPayload)))
+		conn.Write(respHeader)
+		conn.Write(respPayload)
+	}()
+
+	deadConn, _ := net.Pipe()
+	deadConn.Close()
+
+	c := &Client{addr: listener.Addr().String(), conn: deadConn}
+	payload, err := json.Marshal(protocol.SyncRequest{})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+
+	_, got, err := c.sendAndReceive(protocol.MsgTypeSyncRequest, payload)
+	if err != nil {
+		t.Fatalf("expected the reconnect-and-replay to succeed, got error: %v", err)
+	}
+	if !bytes.Equal(got, respPayload) {
+		t.Fatalf("expected the replayed response payload, got %q", got)
+	}
+	<-done
+}
+
+// TestSendAndReceiveDoesNotReplayNonIdempotentWrites verifies that a
+// connection-loss error on a write request (here, StoreData) is
+// reported to the caller as-is rather than replayed: the server may
+// already have fully processed the original request and only lost the
+// response, and blindly resending would risk storing the item twice.
+func TestSendAndReceiveDoesNotReplayNonIdempotentWrites(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan struct{}, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- struct{}{}
+		conn.Close()
+	}()
+
+	deadConn, _ := net.Pipe()
+	deadConn.Close()
+
+	c := &Client{addr: listener.Addr().String(), conn: deadConn}
+	payload, err := json.Marshal(protocol.StoreDataRequest{})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+
+	if _, _, err := c.sendAndReceive(protocol.MsgTypeStoreDataRequest, payload); err == nil {
+		t.Fatal("expected the dead connection's error to be reported rather than swallowed by a replay")
+	}
+
+	select {
+	case <-accepted:
+		t.Fatal("expected StoreData not to be replayed on a fresh connection after connection loss")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"bytes"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// clipboardAutoClear is how long a value copied with CopyWithTimeout
+// stays on the clipboard before being overwritten with an empty string.
+const clipboardAutoClear = 15 * time.Second
+
+// copyToClipboard best-effort copies text to the OS clipboard by
+// shelling out to a platform clipboard utility.
+func copyToClipboard(text string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("pbcopy")
+	case "windows":
+		cmd = exec.Command("clip")
+	default:
+		cmd = exec.Command("xclip", "-selection", "clipboard")
+	}
+	cmd.Stdin = bytes.NewBufferString(text)
+	return cmd.Run()
+}
+
+// CopyWithTimeout copies text to the clipboard and schedules it to be
+// overwritten with an empty string after clipboardAutoClear, so a copied
+// password or card number doesn't linger indefinitely.
+func CopyWithTimeout(text string) error {
+	if err := copyToClipboard(text); err != nil {
+		return err
+	}
+	go func() {
+		time.Sleep(clipboardAutoClear)
+		copyToClipboard("")
+	}()
+	return nil
+}
+
+// copyableField describes one field of an item that can be offered in
+// the "copy to clipboard" picker.
+type copyableField struct {
+	Label    string
+	Key      string
+	Metadata bool // Key names an item.Metadata entry instead of a decoded Data field
+}
+
+// copyableFieldsFor returns, in display order, the fields that can be
+// copied to the clipboard for itemType. Unknown types have none.
+func copyableFieldsFor(itemType uint8) []copyableField {
+	switch itemType {
+	case protocol.DataTypeLogin:
+		return []copyableField{
+			{Label: "Логин", Key: protocol.FieldLogin},
+			{Label: "Пароль", Key: protocol.FieldPassword},
+			{Label: "URL", Key: protocol.MetaURL, Metadata: true},
+		}
+	case protocol.DataTypeCard:
+		return []copyableField{
+			{Label: "Номер карты", Key: protocol.FieldCardNumber},
+			{Label: "CVV", Key: protocol.FieldCardCVV},
+			{Label: "Держатель", Key: protocol.FieldCardHolder},
+		}
+	case protocol.DataTypeText:
+		return []copyableField{
+			{Label: "Текст", Key: protocol.FieldText},
+		}
+	default:
+		return nil
+	}
+}
+
+// value looks up f's data in fields (the item's decoded Data) or in
+// item.Metadata, depending on f.Metadata.
+func (f copyableField) value(fields map[string]string, item protocol.DataItem) string {
+	if f.Metadata {
+		return item.Metadata[f.Key]
+	}
+	return fields[f.Key]
+}
@@ -0,0 +1,50 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestCopyableFieldsForByType(t *testing.T) {
+	tests := []struct {
+		name     string
+		itemType uint8
+		want     []string
+	}{
+		{"login", protocol.DataTypeLogin, []string{"Логин", "Пароль", "URL"}},
+		{"card", protocol.DataTypeCard, []string{"Номер карты", "CVV", "Держатель"}},
+		{"text", protocol.DataTypeText, []string{"Текст"}},
+		{"binary", protocol.DataTypeBinary, nil},
+		{"unknown", 0xFF, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := copyableFieldsFor(tt.itemType)
+			if len(fields) != len(tt.want) {
+				t.Fatalf("expected %d fields, got %d: %+v", len(tt.want), len(fields), fields)
+			}
+			for i, label := range tt.want {
+				if fields[i].Label != label {
+					t.Errorf("field %d: expected label %q, got %q", i, label, fields[i].Label)
+				}
+			}
+		})
+	}
+}
+
+func TestCopyableFieldValue(t *testing.T) {
+	fields := map[string]string{"login": "octocat", "password": "hunter2"}
+	item := protocol.DataItem{Type: protocol.DataTypeLogin, Metadata: map[string]string{protocol.MetaURL: "https://example.com"}}
+
+	loginField := copyableFieldsFor(protocol.DataTypeLogin)[0]
+	if got := loginField.value(fields, item); got != "octocat" {
+		t.Errorf("expected login value %q, got %q", "octocat", got)
+	}
+
+	urlField := copyableFieldsFor(protocol.DataTypeLogin)[2]
+	if got := urlField.value(fields, item); got != "https://example.com" {
+		t.Errorf("expected URL value %q, got %q", "https://example.com", got)
+	}
+}
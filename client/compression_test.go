@@ -0,0 +1,117 @@
+package client
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestCompressibleDataIsCompressedAndRoundTrips(t *testing.T) {
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{masterKey: masterKey}
+
+	plaintext := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog ", 200))
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	c.conn = clientConn
+
+	var gotWireData []byte
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		var req protocol.StoreDataRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return
+		}
+		gotWireData = req.Item.Data
+		if req.Item.Metadata[protocol.MetaCompression] != protocol.CompressionGzip {
+			t.Errorf("expected compressible data to be flagged as gzip-compressed, got metadata %v", req.Item.Metadata)
+		}
+		resp, _ := json.Marshal(protocol.StoreDataResponse{
+			ID: "item-1",
+			Item: protocol.DataItem{
+				ID:       "item-1",
+				Type:     req.Item.Type,
+				Name:     req.Item.Name,
+				Data:     req.Item.Data,
+				Metadata: req.Item.Metadata,
+			},
+		})
+		writeFramedResponse(serverConn, protocol.MsgTypeStoreDataResponse, msgID, resp)
+	}()
+
+	item, err := c.StoreDataFull(protocol.NewDataItem{Type: protocol.DataTypeText, Name: "note", Data: plaintext})
+	if err != nil {
+		t.Fatalf("StoreDataFull: %v", err)
+	}
+	if len(gotWireData) >= len(plaintext) {
+		t.Fatalf("expected the wire payload (%d bytes) to be smaller than the plaintext (%d bytes)", len(gotWireData), len(plaintext))
+	}
+	if !bytes.Equal(item.Data, plaintext) {
+		t.Fatalf("expected the round-tripped data to match the original, got %q", item.Data)
+	}
+}
+
+func TestIncompressibleDataIsStoredUncompressed(t *testing.T) {
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{masterKey: masterKey}
+
+	plaintext := make([]byte, 4096)
+	if _, err := rand.Read(plaintext); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	c.conn = clientConn
+
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		var req protocol.StoreDataRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return
+		}
+		if _, ok := req.Item.Metadata[protocol.MetaCompression]; ok {
+			t.Errorf("expected incompressible data not to be flagged as compressed, got metadata %v", req.Item.Metadata)
+		}
+		resp, _ := json.Marshal(protocol.StoreDataResponse{
+			ID: "item-1",
+			Item: protocol.DataItem{
+				ID:       "item-1",
+				Type:     req.Item.Type,
+				Name:     req.Item.Name,
+				Data:     req.Item.Data,
+				Metadata: req.Item.Metadata,
+			},
+		})
+		writeFramedResponse(serverConn, protocol.MsgTypeStoreDataResponse, msgID, resp)
+	}()
+
+	item, err := c.StoreDataFull(protocol.NewDataItem{Type: protocol.DataTypeText, Name: "note", Data: plaintext})
+	if err != nil {
+		t.Fatalf("StoreDataFull: %v", err)
+	}
+	if !bytes.Equal(item.Data, plaintext) {
+		t.Fatalf("expected the round-tripped data to match the original")
+	}
+}
+
+func TestGzipCompressReportsFalseWhenNotSmaller(t *testing.T) {
+	tiny := []byte("x")
+	if _, ok := gzipCompress(tiny); ok {
+		t.Fatal("expected gzip overhead to make a tiny input not worth compressing")
+	}
+}
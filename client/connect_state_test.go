@@ -0,0 +1,147 @@
+package client
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestIsConnectedFalseBeforeConnect(t *testing.T) {
+	c := NewClient("127.0.0.1:0")
+	if c.IsConnected() {
+		t.Fatal("expected a fresh client to report not connected")
+	}
+}
+
+func TestIsConnectedTrueAfterConnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	c := NewClient(listener.Addr().String())
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer c.Close()
+
+	if !c.IsConnected() {
+		t.Fatal("expected the client to report connected after Connect")
+	}
+}
+
+func TestSendAndReceiveReturnsErrNotConnectedWhenAutoConnectDisabled(t *testing.T) {
+	c := NewClient("127.0.0.1:0")
+	c.DisableAutoConnect = true
+
+	_, _, err := c.sendAndReceive(0x01, nil)
+	if !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected, got %v", err)
+	}
+}
+
+func TestPingReturnsErrNotConnectedWhenAutoConnectDisabled(t *testing.T) {
+	c := NewClient("127.0.0.1:0")
+	c.DisableAutoConnect = true
+
+	if _, err := c.Ping(); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected, got %v", err)
+	}
+}
+
+func TestNewClientWithOptionsDefaultAutoConnectDialsLikeNewClient(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			serveOneErrorResponse(conn)
+		}
+	}()
+
+	c := NewClientWithOptions(listener.Addr().String(), ClientOptions{})
+	if _, err := c.Ping(); errors.Is(err, ErrNotConnected) {
+		t.Fatal("expected auto-connect to dial by default, got ErrNotConnected")
+	}
+	if !c.IsConnected() {
+		t.Fatal("expected the default options to auto-connect on first use, like NewClient")
+	}
+}
+
+func TestNewClientWithOptionsDisableAutoConnectReturnsErrNotConnected(t *testing.T) {
+	c := NewClientWithOptions("127.0.0.1:0", ClientOptions{DisableAutoConnect: true})
+
+	if _, err := c.Ping(); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected, got %v", err)
+	}
+}
+
+func TestSendAndReceiveAutoConnectsByDefault(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	// Accept every connection sendAndReceive might make, including a
+	// reconnect-and-replay attempt, and answer each with a well-formed
+	// response so the call can't block forever waiting on a connection
+	// nothing ever services.
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveOneErrorResponse(conn)
+		}
+	}()
+
+	c := NewClient(listener.Addr().String())
+	if c.IsConnected() {
+		t.Fatal("expected a fresh client to report not connected")
+	}
+
+	_, _, err = c.sendAndReceive(0x01, nil)
+	if errors.Is(err, ErrNotConnected) {
+		t.Fatalf("expected auto-connect to dial rather than return ErrNotConnected, got %v", err)
+	}
+}
+
+// serveOneErrorResponse reads a single framed request off conn and
+// replies with an empty MsgTypeErrorResponse, echoing the request's
+// message ID as sendAndReceive requires.
+func serveOneErrorResponse(conn net.Conn) {
+	defer conn.Close()
+
+	reqHeader := make([]byte, protocol.HeaderSize)
+	if _, err := io.ReadFull(conn, reqHeader); err != nil {
+		return
+	}
+	reqLength := binary.BigEndian.Uint32(reqHeader[5:9])
+	if reqLength > 0 {
+		if _, err := io.ReadFull(conn, make([]byte, reqLength)); err != nil {
+			return
+		}
+	}
+
+	respHeader := make([]byte, protocol.HeaderSize)
+	respHeader[0] = protocol.MsgTypeErrorResponse
+	copy(respHeader[1:5], reqHeader[1:5])
+	binary.BigEndian.PutUint32(respHeader[5:9], 0)
+	conn.Write(respHeader)
+}
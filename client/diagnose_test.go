@@ -0,0 +1,123 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestDiagnoseReportsHealthyCompatibleServer(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, err := json.Marshal(protocol.PingResponse{ServerVersion: protocol.Version, DBHealthy: true})
+		if err != nil {
+			return
+		}
+		writeFramedResponse(serverConn, protocol.MsgTypePingResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn}
+	report, err := c.Diagnose()
+	if err != nil {
+		t.Fatalf("Diagnose: %v", err)
+	}
+	if !report.Connected {
+		t.Fatal("expected Connected to be true")
+	}
+	if !report.ProtocolCompatible {
+		t.Fatalf("expected ProtocolCompatible, got version %d", report.ServerVersion)
+	}
+	if !report.DBHealthy {
+		t.Fatal("expected DBHealthy to be true")
+	}
+}
+
+func TestDiagnoseReportsIncompatibleProtocolVersion(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, err := json.Marshal(protocol.PingResponse{ServerVersion: protocol.Version + 1, DBHealthy: true})
+		if err != nil {
+			return
+		}
+		writeFramedResponse(serverConn, protocol.MsgTypePingResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn}
+	report, err := c.Diagnose()
+	if err != nil {
+		t.Fatalf("Diagnose: %v", err)
+	}
+	if report.ProtocolCompatible {
+		t.Fatal("expected ProtocolCompatible to be false for a mismatched server version")
+	}
+	if report.ServerVersion != protocol.Version+1 {
+		t.Fatalf("expected ServerVersion %d, got %d", protocol.Version+1, report.ServerVersion)
+	}
+}
+
+func TestDiagnoseReportsUnhealthyDatabase(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, err := json.Marshal(protocol.PingResponse{ServerVersion: protocol.Version, DBHealthy: false})
+		if err != nil {
+			return
+		}
+		writeFramedResponse(serverConn, protocol.MsgTypePingResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn}
+	report, err := c.Diagnose()
+	if err != nil {
+		t.Fatalf("Diagnose: %v", err)
+	}
+	if report.DBHealthy {
+		t.Fatal("expected DBHealthy to be false")
+	}
+}
+
+func TestDiagnoseReturnsErrNotConnectedWhenAutoConnectDisabled(t *testing.T) {
+	c := NewClient("127.0.0.1:0")
+	c.DisableAutoConnect = true
+
+	if _, err := c.Diagnose(); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("expected ErrNotConnected, got %v", err)
+	}
+}
+
+func TestDiagnoseReturnsErrorOnUnreachableServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close() // nothing is listening now
+
+	c := NewClient(addr)
+	if _, err := c.Diagnose(); err == nil {
+		t.Fatal("expected an error connecting to an unreachable server")
+	}
+}
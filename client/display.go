@@ -0,0 +1,24 @@
+package client
+
+import (
+	"strings"
+	"unicode"
+)
+
+// sanitizeForDisplay strips Unicode control characters (including the
+// ESC byte that begins an ANSI escape sequence) from s before it's
+// printed to the terminal, so a malicious item name, metadata value, or
+// decrypted field can't manipulate the viewer's terminal. Ordinary
+// printable text, including non-Latin scripts, passes through
+// unchanged.
+func sanitizeForDisplay(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
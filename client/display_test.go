@@ -0,0 +1,26 @@
+package client
+
+import "testing"
+
+func TestSanitizeForDisplayStripsControlCharacters(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"ansi escape sequence", "\x1b[31mHacked\x1b[0m", "[31mHacked[0m"},
+		{"newline injection", "GitHub\nRoot: shell$", "GitHubRoot: shell$"},
+		{"null and bell", "a\x00b\x07c", "abc"},
+		{"plain ascii", "GitHub login", "GitHub login"},
+		{"cyrillic preserved", "Рабочая почта", "Рабочая почта"},
+		{"emoji preserved", "Wi-Fi 🔒", "Wi-Fi 🔒"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeForDisplay(tt.in); got != tt.want {
+				t.Errorf("sanitizeForDisplay(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestDownloadFileResumableAssemblesAndDecryptsRemainder(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	plaintext := []byte("the quick brown fox jumps over the lazy dog")
+	encrypted, err := crypto.Encrypt(plaintext, masterKey)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	alreadyDownloaded := encrypted[:10]
+
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		var req protocol.DownloadRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return
+		}
+		if req.Offset != int64(len(alreadyDownloaded)) || req.Length != 0 {
+			t.Errorf("unexpected range request: offset=%d length=%d", req.Offset, req.Length)
+		}
+		resp, err := json.Marshal(protocol.DownloadResponse{
+			Data:      encrypted[len(alreadyDownloaded):],
+			TotalSize: int64(len(encrypted)),
+		})
+		if err != nil {
+			return
+		}
+		writeFramedResponse(serverConn, protocol.MsgTypeDownloadResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	got, err := c.DownloadFileResumable("item-1", alreadyDownloaded)
+	if err != nil {
+		t.Fatalf("DownloadFileResumable: %v", err)
+	}
+	if string(got) != string(plaintext) {
+		t.Fatalf("got %q, want %q", got, plaintext)
+	}
+}
+
+func TestDownloadFileResumableErrorsOnSizeMismatch(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, err := json.Marshal(protocol.DownloadResponse{
+			Data:      []byte("short"),
+			TotalSize: 999,
+		})
+		if err != nil {
+			return
+		}
+		writeFramedResponse(serverConn, protocol.MsgTypeDownloadResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn, masterKey: []byte("0123456789abcdef0123456789abcdef")}
+	if _, err := c.DownloadFileResumable("item-1", nil); err == nil {
+		t.Fatal("expected an error when the reassembled size doesn't match TotalSize")
+	}
+}
+
+func TestDownloadFileVerifiesMatchingChecksum(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	plaintext := []byte("checksum me please")
+	encrypted, err := crypto.Encrypt(plaintext, masterKey)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	sum := sha256.Sum256(encrypted)
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, err := json.Marshal(protocol.DownloadResponse{
+			Data:           encrypted,
+			TotalSize:      int64(len(encrypted)),
+			ChecksumSHA256: hex.EncodeToString(sum[:]),
+		})
+		if err != nil {
+			return
+		}
+		writeFramedResponse(serverConn, protocol.MsgTypeDownloadResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	got, err := c.DownloadFile("item-1")
+	if err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	if string(got) != string(plaintext) {
+		t.Fatalf("got %q, want %q", got, plaintext)
+	}
+}
+
+func TestDownloadFileRejectsMismatchingChecksum(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	encrypted, err := crypto.Encrypt([]byte("tampered payload"), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, err := json.Marshal(protocol.DownloadResponse{
+			Data:           encrypted,
+			TotalSize:      int64(len(encrypted)),
+			ChecksumSHA256: "0000000000000000000000000000000000000000000000000000000000000000",
+		})
+		if err != nil {
+			return
+		}
+		writeFramedResponse(serverConn, protocol.MsgTypeDownloadResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	if _, err := c.DownloadFile("item-1"); err == nil {
+		t.Fatal("expected an error when the checksum doesn't match the downloaded data")
+	}
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseExpiry parses a card expiry string in MM/YY or MM/YYYY form and
+// returns the instant just past the end of that month (the last moment
+// the card is valid), in UTC. It rejects malformed input and impossible
+// months, but not past dates — callers that care whether the card has
+// already expired should compare the result against time.Now()
+// themselves, since "expired" is a warning, not a parse failure.
+func ParseExpiry(expiry string) (time.Time, error) {
+	parsed, err := time.Parse("01/06", expiry)
+	if err != nil {
+		parsed, err = time.Parse("01/2006", expiry)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("некорректный срок действия %q, ожидается MM/YY или MM/YYYY", expiry)
+		}
+	}
+	return parsed.AddDate(0, 1, 0), nil
+}
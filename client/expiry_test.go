@@ -0,0 +1,53 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExpiryAcceptsTwoDigitYear(t *testing.T) {
+	expiresAt, err := ParseExpiry("06/30")
+	if err != nil {
+		t.Fatalf("ParseExpiry: %v", err)
+	}
+	if expiresAt.Year() != 2030 || expiresAt.Month() != time.July {
+		t.Fatalf("expected the boundary just past June 2030, got %v", expiresAt)
+	}
+}
+
+func TestParseExpiryAcceptsFourDigitYear(t *testing.T) {
+	expiresAt, err := ParseExpiry("12/2029")
+	if err != nil {
+		t.Fatalf("ParseExpiry: %v", err)
+	}
+	if expiresAt.Year() != 2030 || expiresAt.Month() != time.January {
+		t.Fatalf("expected the boundary just past December 2029, got %v", expiresAt)
+	}
+}
+
+func TestParseExpiryRejectsInvalidMonth(t *testing.T) {
+	if _, err := ParseExpiry("13/25"); err == nil {
+		t.Fatal("expected an error for month 13")
+	}
+	if _, err := ParseExpiry("00/25"); err == nil {
+		t.Fatal("expected an error for month 00")
+	}
+}
+
+func TestParseExpiryRejectsMalformedInput(t *testing.T) {
+	for _, in := range []string{"", "june-2025", "12-25", "1/25"} {
+		if _, err := ParseExpiry(in); err == nil {
+			t.Fatalf("expected an error for malformed input %q", in)
+		}
+	}
+}
+
+func TestParseExpiryDoesNotErrorOnPastDate(t *testing.T) {
+	expiresAt, err := ParseExpiry("01/20")
+	if err != nil {
+		t.Fatalf("ParseExpiry should not fail on a past date: %v", err)
+	}
+	if !expiresAt.Before(time.Now()) {
+		t.Fatalf("expected an expiry date in the past, got %v", expiresAt)
+	}
+}
@@ -0,0 +1,97 @@
+package client
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// csvColumnsFor returns the CSV columns (beyond the leading "name"
+// column) written for items of dataType. Column headers are lowercase
+// English so the export is readable by other password managers. Types
+// with no defined columns aren't exportable.
+func csvColumnsFor(dataType uint8) []copyableField {
+	switch dataType {
+	case protocol.DataTypeLogin:
+		return []copyableField{
+			{Label: "login", Key: protocol.FieldLogin},
+			{Label: "password", Key: protocol.FieldPassword},
+			{Label: "url", Key: protocol.MetaURL, Metadata: true},
+		}
+	case protocol.DataTypeCard:
+		return []copyableField{
+			{Label: "number", Key: protocol.FieldCardNumber},
+			{Label: "expiry", Key: protocol.FieldCardExpiry},
+			{Label: "cvv", Key: protocol.FieldCardCVV},
+			{Label: "holder", Key: protocol.FieldCardHolder},
+		}
+	case protocol.DataTypeText:
+		return []copyableField{
+			{Label: "text", Key: protocol.FieldText},
+		}
+	default:
+		return nil
+	}
+}
+
+// ExportCSV syncs the vault, decrypts every item of dataType, and
+// writes them to w as CSV: a "name" column followed by the type's
+// fields (e.g. login, password, url).
+//
+// The output is plaintext. Callers MUST warn the user before writing it
+// anywhere persistent — unlike the vault, a CSV file on disk has no
+// encryption of its own.
+func (c *Client) ExportCSV(dataType uint8, w io.Writer) error {
+	items, err := c.SyncData(time.Time{})
+	if err != nil {
+		return err
+	}
+	return exportCSVFromItems(items, dataType, w)
+}
+
+// exportCSVFromItems formats items of dataType as CSV, so the formatting
+// logic can be tested without a live sync.
+func exportCSVFromItems(items []protocol.DataItem, dataType uint8, w io.Writer) error {
+	columns := csvColumnsFor(dataType)
+	if columns == nil {
+		return fmt.Errorf("export not supported for data type %d", dataType)
+	}
+
+	cw := csv.NewWriter(w)
+
+	header := make([]string, 0, len(columns)+1)
+	header = append(header, "name")
+	for _, col := range columns {
+		header = append(header, col.Label)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.Type != dataType {
+			continue
+		}
+
+		var fields map[string]string
+		if err := json.Unmarshal(item.Data, &fields); err != nil {
+			return fmt.Errorf("decode item %s: %w", item.ID, err)
+		}
+
+		row := make([]string, 0, len(columns)+1)
+		row = append(row, item.Name)
+		for _, col := range columns {
+			row = append(row, col.value(fields, item))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestExportCSVLogins(t *testing.T) {
+	loginData, _ := json.Marshal(map[string]string{"login": "octocat", "password": "hunter2"})
+	otherData, _ := json.Marshal(map[string]string{"text": "unrelated"})
+
+	items := []protocol.DataItem{
+		{ID: "1", Type: protocol.DataTypeLogin, Name: "GitHub", Data: loginData, Metadata: map[string]string{protocol.MetaURL: "https://github.com"}},
+		{ID: "2", Type: protocol.DataTypeText, Name: "Note", Data: otherData, Metadata: map[string]string{}},
+	}
+
+	var buf bytes.Buffer
+	if err := exportCSVFromItems(items, protocol.DataTypeLogin, &buf); err != nil {
+		t.Fatalf("exportCSVFromItems: %v", err)
+	}
+
+	want := "name,login,password,url\nGitHub,octocat,hunter2,https://github.com\n"
+	if buf.String() != want {
+		t.Errorf("CSV output:\n%q\nwant:\n%q", buf.String(), want)
+	}
+}
+
+func TestExportCSVQuotesFieldsContainingCommas(t *testing.T) {
+	cardData, _ := json.Marshal(map[string]string{"number": "4111 1111", "expiry": "01/30", "cvv": "123", "holder": "Doe, Jane"})
+	items := []protocol.DataItem{
+		{ID: "1", Type: protocol.DataTypeCard, Name: "Work, Personal", Data: cardData, Metadata: map[string]string{}},
+	}
+
+	var buf bytes.Buffer
+	if err := exportCSVFromItems(items, protocol.DataTypeCard, &buf); err != nil {
+		t.Fatalf("exportCSVFromItems: %v", err)
+	}
+
+	want := "name,number,expiry,cvv,holder\n\"Work, Personal\",4111 1111,01/30,123,\"Doe, Jane\"\n"
+	if buf.String() != want {
+		t.Errorf("CSV output:\n%q\nwant:\n%q", buf.String(), want)
+	}
+}
+
+func TestExportCSVRejectsUnsupportedType(t *testing.T) {
+	var buf bytes.Buffer
+	if err := exportCSVFromItems(nil, protocol.DataTypeBinary, &buf); err == nil {
+		t.Fatal("expected an error for an unexportable data type")
+	}
+}
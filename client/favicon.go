@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultFaviconMaxBytes caps how much of a favicon response body
+// FaviconCache reads, so a malicious or misconfigured server can't
+// exhaust memory or disk with an oversized response.
+const defaultFaviconMaxBytes = 100 * 1024
+
+// defaultFaviconTimeout bounds how long a single favicon fetch may take
+// before FaviconCache gives up and treats the site as unreachable.
+const defaultFaviconTimeout = 5 * time.Second
+
+// httpDoer is the subset of *http.Client that FaviconCache depends on,
+// so tests can inject a stub instead of starting a real server.
+type httpDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// FaviconCache fetches and locally caches site favicons for login
+// items, so list views can show a small indicator without re-fetching
+// on every display. It's opt-in (a UIClient only uses one if configured
+// with a cache directory) and degrades gracefully: any fetch failure
+// (offline, timeout, 404, oversized response) is returned as an error
+// for the caller to skip, never a panic.
+type FaviconCache struct {
+	// Dir is the directory cached favicon files are stored under.
+	Dir string
+
+	// MaxBytes caps a favicon response body; 0 uses
+	// defaultFaviconMaxBytes.
+	MaxBytes int64
+
+	// Timeout bounds a single fetch; 0 uses defaultFaviconTimeout.
+	Timeout time.Duration
+
+	// HTTPClient performs the fetch; nil uses http.DefaultClient. Tests
+	// inject a stub implementing httpDoer.
+	HTTPClient httpDoer
+}
+
+// NewFaviconCache returns a FaviconCache storing fetched favicons under
+// dir.
+func NewFaviconCache(dir string) *FaviconCache {
+	return &FaviconCache{Dir: dir}
+}
+
+// Get returns the local path to siteURL's cached favicon, fetching and
+// caching it first if it isn't already present. Callers should treat a
+// non-nil error as "no favicon available" and simply omit the
+// indicator, rather than as fatal.
+func (f *FaviconCache) Get(siteURL string) (string, error) {
+	faviconURL, cacheKey, err := faviconRequestFor(siteURL)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(f.Dir, cacheKey+".ico")
+	exists, err := fileExists(path)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return path, nil
+	}
+
+	if err := f.fetch(faviconURL, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// fetch downloads faviconURL and writes it to destPath, enforcing the
+// size cap and timeout.
+func (f *FaviconCache) fetch(faviconURL, destPath string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, faviconURL, nil)
+	if err != nil {
+		return fmt.Errorf("build favicon request: %w", err)
+	}
+
+	doer := f.HTTPClient
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	resp, err := doer.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch favicon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch favicon: server returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes()+1))
+	if err != nil {
+		return fmt.Errorf("read favicon: %w", err)
+	}
+	if int64(len(data)) > f.maxBytes() {
+		return fmt.Errorf("favicon exceeds %d byte limit", f.maxBytes())
+	}
+
+	if err := os.MkdirAll(f.Dir, 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(destPath, data, 0600)
+}
+
+func (f *FaviconCache) maxBytes() int64 {
+	if f.MaxBytes > 0 {
+		return f.MaxBytes
+	}
+	return defaultFaviconMaxBytes
+}
+
+func (f *FaviconCache) timeout() time.Duration {
+	if f.Timeout > 0 {
+		return f.Timeout
+	}
+	return defaultFaviconTimeout
+}
+
+// faviconRequestFor derives the "/favicon.ico" URL to fetch and a
+// filesystem-safe cache key from siteURL. siteURL may omit its scheme,
+// in which case https is assumed.
+func faviconRequestFor(siteURL string) (faviconURL, cacheKey string, err error) {
+	siteURL = strings.TrimSpace(siteURL)
+	u, err := url.Parse(siteURL)
+	if err != nil || u.Host == "" {
+		u, err = url.Parse("https://" + siteURL)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("invalid site URL: %w", err)
+	}
+	if u.Host == "" {
+		return "", "", fmt.Errorf("invalid site URL: %q has no host", siteURL)
+	}
+
+	favicon := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/favicon.ico"}
+	return favicon.String(), sanitizeFaviconCacheKey(u.Host), nil
+}
+
+// sanitizeFaviconCacheKey replaces any character that isn't safe in a
+// filename with "_", so a favicon URL's host can be used directly as a
+// cache file's base name.
+func sanitizeFaviconCacheKey(host string) string {
+	var b strings.Builder
+	b.Grow(len(host))
+	for _, r := range host {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFaviconCacheFetchesAndCachesIcon(t *testing.T) {
+	iconBytes := []byte("fake-icon-bytes")
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/favicon.ico" {
+			t.Errorf("expected request to /favicon.ico, got %s", r.URL.Path)
+		}
+		w.Write(iconBytes)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cache := NewFaviconCache(dir)
+	cache.HTTPClient = server.Client()
+
+	path, err := cache.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(data) != string(iconBytes) {
+		t.Fatalf("cached file content = %q, want %q", data, iconBytes)
+	}
+	if filepath.Dir(path) != dir {
+		t.Fatalf("expected cached path %q to live directly under %q", path, dir)
+	}
+
+	// A second Get for the same site must be served from the cache
+	// without another HTTP request.
+	if _, err := cache.Get(server.URL); err != nil {
+		t.Fatalf("second Get: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("expected exactly 1 HTTP request, got %d", requests)
+	}
+}
+
+func TestFaviconCacheReturnsErrorOn404(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	cache := NewFaviconCache(t.TempDir())
+	cache.HTTPClient = server.Client()
+
+	if _, err := cache.Get(server.URL); err == nil {
+		t.Fatal("expected an error for a 404 response, got nil")
+	}
+}
+
+// stubDoer implements httpDoer without opening any network connection,
+// so TestFaviconCacheEnforcesSizeCap can simulate an oversized response
+// cheaply.
+type stubDoer struct {
+	resp *http.Response
+	err  error
+}
+
+func (s stubDoer) Do(req *http.Request) (*http.Response, error) {
+	return s.resp, s.err
+}
+
+func TestFaviconCacheEnforcesSizeCap(t *testing.T) {
+	cache := NewFaviconCache(t.TempDir())
+	cache.MaxBytes = 4
+	cache.HTTPClient = stubDoer{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("way too many bytes")),
+	}}
+
+	if _, err := cache.Get("https://example.com"); err == nil {
+		t.Fatal("expected an error when the response exceeds MaxBytes, got nil")
+	}
+}
+
+func TestFaviconRequestForAddsSchemeAndDerivesCacheKey(t *testing.T) {
+	faviconURL, key, err := faviconRequestFor("example.com/login")
+	if err != nil {
+		t.Fatalf("faviconRequestFor: %v", err)
+	}
+	if faviconURL != "https://example.com/favicon.ico" {
+		t.Fatalf("faviconURL = %q, want https://example.com/favicon.ico", faviconURL)
+	}
+	if key != "example.com" {
+		t.Fatalf("cacheKey = %q, want example.com", key)
+	}
+}
+
+func TestFaviconRequestForRejectsEmptyURL(t *testing.T) {
+	if _, _, err := faviconRequestFor(""); err == nil {
+		t.Fatal("expected an error for an empty site URL, got nil")
+	}
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// GetDecryptedFields fetches and decrypts item itemID and returns its
+// payload as a flat field map, centralizing the per-type JSON-unmarshal
+// logic that would otherwise be duplicated by every caller that wants an
+// item's fields rather than the raw DataItem (the interactive UI's
+// showItemDetails is one such caller).
+func (c *Client) GetDecryptedFields(itemID string) (map[string]string, error) {
+	item, err := c.GetData(itemID)
+	if err != nil {
+		return nil, err
+	}
+	return decryptedFields(item), nil
+}
+
+// decryptedFields extracts item's already-decrypted Data as a flat
+// map[string]string. Login, card, and text items all store their data as
+// a JSON object of string fields (text under the conventional
+// protocol.FieldText key), so a plain unmarshal covers them; binary
+// items store raw file content in Data, which isn't structured fields at
+// all, so they always report an empty map.
+func decryptedFields(item protocol.DataItem) map[string]string {
+	if item.Type == protocol.DataTypeBinary {
+		return map[string]string{}
+	}
+	var fields map[string]string
+	if err := json.Unmarshal(item.Data, &fields); err != nil {
+		return map[string]string{}
+	}
+	return fields
+}
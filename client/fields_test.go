@@ -0,0 +1,94 @@
+package client
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestDecryptedFieldsLogin(t *testing.T) {
+	item := protocol.DataItem{
+		Type: protocol.DataTypeLogin,
+		Data: []byte(`{"login":"alice","password":"hunter2"}`),
+	}
+	fields := decryptedFields(item)
+	if fields[protocol.FieldLogin] != "alice" || fields[protocol.FieldPassword] != "hunter2" {
+		t.Fatalf("unexpected fields: %+v", fields)
+	}
+}
+
+func TestDecryptedFieldsCard(t *testing.T) {
+	item := protocol.DataItem{
+		Type: protocol.DataTypeCard,
+		Data: []byte(`{"number":"4111","expiry":"12/30","cvv":"123","holder":"Alice"}`),
+	}
+	fields := decryptedFields(item)
+	if fields[protocol.FieldCardNumber] != "4111" || fields[protocol.FieldCardHolder] != "Alice" {
+		t.Fatalf("unexpected fields: %+v", fields)
+	}
+}
+
+func TestDecryptedFieldsText(t *testing.T) {
+	item := protocol.DataItem{
+		Type: protocol.DataTypeText,
+		Data: []byte(`{"text":"hello world"}`),
+	}
+	fields := decryptedFields(item)
+	if fields[protocol.FieldText] != "hello world" {
+		t.Fatalf("unexpected fields: %+v", fields)
+	}
+}
+
+// TestDecryptedFieldsBinaryHasNoStructuredFields verifies that a binary
+// item, whose Data is raw file content rather than JSON, reports an
+// empty field map instead of failing.
+func TestDecryptedFieldsBinaryHasNoStructuredFields(t *testing.T) {
+	item := protocol.DataItem{
+		Type: protocol.DataTypeBinary,
+		Data: []byte{0x89, 0x50, 0x4e, 0x47},
+	}
+	fields := decryptedFields(item)
+	if len(fields) != 0 {
+		t.Fatalf("expected an empty field map for a binary item, got %+v", fields)
+	}
+}
+
+func TestGetDecryptedFieldsFetchesAndDecrypts(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	encryptedData, err := crypto.Encrypt([]byte(`{"login":"alice","password":"hunter2"}`), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt data: %v", err)
+	}
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, err := json.Marshal(protocol.GetDataResponse{Item: protocol.DataItem{
+			ID:   "item-1",
+			Type: protocol.DataTypeLogin,
+			Data: encryptedData,
+		}})
+		if err != nil {
+			return
+		}
+		writeFramedResponse(serverConn, protocol.MsgTypeGetDataResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	fields, err := c.GetDecryptedFields("item-1")
+	if err != nil {
+		t.Fatalf("GetDecryptedFields: %v", err)
+	}
+	if fields[protocol.FieldLogin] != "alice" || fields[protocol.FieldPassword] != "hunter2" {
+		t.Fatalf("unexpected fields: %+v", fields)
+	}
+}
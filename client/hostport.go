@@ -0,0 +1,65 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// parseHostPort validates and normalizes a host and a port string,
+// returning the trimmed host and the port as an int. It rejects an
+// empty host, a host that isn't a syntactically valid hostname or IP
+// address, and a port outside 1-65535.
+func parseHostPort(host, portStr string) (string, int, error) {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return "", 0, fmt.Errorf("host must not be empty")
+	}
+	if !isValidHost(host) {
+		return "", 0, fmt.Errorf("invalid host: %q", host)
+	}
+
+	port, err := strconv.Atoi(strings.TrimSpace(portStr))
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port: %q", portStr)
+	}
+	if port < 1 || port > 65535 {
+		return "", 0, fmt.Errorf("port must be between 1 and 65535, got %d", port)
+	}
+
+	return host, port, nil
+}
+
+// isValidHost reports whether host is a valid IP address or a
+// syntactically valid hostname (dot-separated labels of letters,
+// digits and hyphens, no label starting or ending with a hyphen).
+func isValidHost(host string) bool {
+	if net.ParseIP(host) != nil {
+		return true
+	}
+	if len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if !isValidHostLabel(label) {
+			return false
+		}
+	}
+	return true
+}
+
+func isValidHostLabel(label string) bool {
+	if label == "" || len(label) > 63 {
+		return false
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, r := range label {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
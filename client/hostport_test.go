@@ -0,0 +1,78 @@
+package client
+
+import "testing"
+
+func TestParseHostPortAcceptsHostnameAndValidPort(t *testing.T) {
+	host, port, err := parseHostPort(" localhost ", " 8080 ")
+	if err != nil {
+		t.Fatalf("parseHostPort: %v", err)
+	}
+	if host != "localhost" || port != 8080 {
+		t.Fatalf("got host %q port %d, want localhost 8080", host, port)
+	}
+}
+
+func TestParseHostPortAcceptsIPAddress(t *testing.T) {
+	host, port, err := parseHostPort("192.168.1.1", "443")
+	if err != nil {
+		t.Fatalf("parseHostPort: %v", err)
+	}
+	if host != "192.168.1.1" || port != 443 {
+		t.Fatalf("got host %q port %d, want 192.168.1.1 443", host, port)
+	}
+}
+
+func TestParseHostPortRejectsEmptyHost(t *testing.T) {
+	if _, _, err := parseHostPort("  ", "8080"); err == nil {
+		t.Fatal("expected an error for an empty host")
+	}
+}
+
+func TestParseHostPortRejectsInvalidHost(t *testing.T) {
+	if _, _, err := parseHostPort("-badhost", "8080"); err == nil {
+		t.Fatal("expected an error for an invalid host")
+	}
+}
+
+func TestParseHostPortRejectsNonNumericPort(t *testing.T) {
+	if _, _, err := parseHostPort("localhost", "abc"); err == nil {
+		t.Fatal("expected an error for a non-numeric port")
+	}
+}
+
+func TestParseHostPortRejectsOutOfRangePort(t *testing.T) {
+	if _, _, err := parseHostPort("localhost", "0"); err == nil {
+		t.Fatal("expected an error for port 0")
+	}
+	if _, _, err := parseHostPort("localhost", "65536"); err == nil {
+		t.Fatal("expected an error for port 65536")
+	}
+}
+
+func TestPromptServerAddressDefaultsWhenEmpty(t *testing.T) {
+	u := newTestUIClient("\n")
+	if got := u.promptServerAddress(); got != "localhost:8080" {
+		t.Fatalf("got %q, want localhost:8080", got)
+	}
+}
+
+func TestPromptServerAddressPassesUnixSocketThrough(t *testing.T) {
+	u := newTestUIClient("unix:///var/run/pwmanager.sock\n")
+	if got := u.promptServerAddress(); got != "unix:///var/run/pwmanager.sock" {
+		t.Fatalf("got %q, want the unix socket address unchanged", got)
+	}
+}
+
+func TestPromptServerAddressReprompsOnInvalidPort(t *testing.T) {
+	u := newTestUIClient("localhost:99999\nlocalhost:9090\n")
+	if got := u.promptServerAddress(); got != "localhost:9090" {
+		t.Fatalf("got %q, want the second, valid input to win", got)
+	}
+}
+
+func TestPromptServerAddressPreservesTLSPrefix(t *testing.T) {
+	u := newTestUIClient("tls://example.com:8443\n")
+	if got := u.promptServerAddress(); got != "tls://example.com:8443" {
+		t.Fatalf("got %q, want tls://example.com:8443", got)
+	}
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// importHeaderAliases maps header names used by other password managers
+// to this app's field keys (e.g. Chrome exports "username" where this
+// app calls the same field "login").
+var importHeaderAliases = map[string]string{
+	"username": protocol.FieldLogin,
+	"pass":     protocol.FieldPassword,
+	"website":  protocol.MetaURL,
+}
+
+// normalizeImportHeader lowercases and trims a CSV header cell and
+// resolves known aliases to this app's field keys.
+func normalizeImportHeader(cell string) string {
+	key := strings.ToLower(strings.TrimSpace(cell))
+	if canonical, ok := importHeaderAliases[key]; ok {
+		return canonical
+	}
+	return key
+}
+
+// parseImportCSV reads a CSV with a header row and turns each data row
+// into a NewDataItem of dataType, mapping columns by header name
+// (case-insensitive, with aliases via importHeaderAliases). Rows that
+// fail to parse as CSV are skipped and counted in skipped rather than
+// aborting the import.
+func parseImportCSV(r io.Reader, dataType uint8) (items []protocol.NewDataItem, skipped int, err error) {
+	columns := csvColumnsFor(dataType)
+	if columns == nil {
+		return nil, 0, fmt.Errorf("import not supported for data type %d", dataType)
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, 0, fmt.Errorf("read header: %w", err)
+	}
+
+	nameIdx := -1
+	colIdx := make(map[string]int)
+	for i, cell := range header {
+		key := normalizeImportHeader(cell)
+		if key == "name" {
+			nameIdx = i
+			continue
+		}
+		for _, col := range columns {
+			if col.Key == key {
+				colIdx[col.Key] = i
+			}
+		}
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		fields := map[string]string{}
+		metadata := map[string]string{}
+		for _, col := range columns {
+			idx, ok := colIdx[col.Key]
+			if !ok || idx >= len(record) {
+				continue
+			}
+			if col.Metadata {
+				metadata[col.Key] = record[idx]
+			} else {
+				fields[col.Key] = record[idx]
+			}
+		}
+
+		name := ""
+		if nameIdx >= 0 && nameIdx < len(record) {
+			name = record[nameIdx]
+		}
+
+		data, err := json.Marshal(fields)
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		items = append(items, protocol.NewDataItem{
+			Type:     dataType,
+			Name:     name,
+			Data:     data,
+			Metadata: metadata,
+		})
+	}
+
+	return items, skipped, nil
+}
+
+// ImportCSV reads a CSV with a header row (name/url/login/password,
+// or common aliases like "username") and stores one item of dataType
+// per data row, returning how many were imported. Rows that fail to
+// parse are skipped rather than aborting the whole import.
+func (c *Client) ImportCSV(r io.Reader, dataType uint8) (int, error) {
+	items, _, err := parseImportCSV(r, dataType)
+	if err != nil {
+		return 0, err
+	}
+
+	imported := 0
+	for _, item := range items {
+		if _, err := c.StoreData(item); err != nil {
+			continue
+		}
+		imported++
+	}
+	return imported, nil
+}
@@ -0,0 +1,76 @@
+package client
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestParseImportCSVLogins(t *testing.T) {
+	csvData := `name,url,username,password
+GitHub,https://github.com,octocat,hunter2
+"Work, Mail",https://mail.example.com,jane,"p,ss"
+`
+	items, skipped, err := parseImportCSV(strings.NewReader(csvData), protocol.DataTypeLogin)
+	if err != nil {
+		t.Fatalf("parseImportCSV: %v", err)
+	}
+	if skipped != 0 {
+		t.Fatalf("expected no skipped rows, got %d", skipped)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+
+	if items[0].Name != "GitHub" {
+		t.Errorf("expected name %q, got %q", "GitHub", items[0].Name)
+	}
+	if items[0].Metadata[protocol.MetaURL] != "https://github.com" {
+		t.Errorf("expected url metadata, got %q", items[0].Metadata[protocol.MetaURL])
+	}
+	var fields map[string]string
+	if err := json.Unmarshal(items[0].Data, &fields); err != nil {
+		t.Fatalf("unmarshal item data: %v", err)
+	}
+	if fields["login"] != "octocat" || fields["password"] != "hunter2" {
+		t.Errorf("unexpected fields: %+v", fields)
+	}
+
+	if items[1].Name != "Work, Mail" {
+		t.Errorf("expected quoted name to round-trip, got %q", items[1].Name)
+	}
+	var fields2 map[string]string
+	if err := json.Unmarshal(items[1].Data, &fields2); err != nil {
+		t.Fatalf("unmarshal item data: %v", err)
+	}
+	if fields2["password"] != "p,ss" {
+		t.Errorf("expected quoted password to round-trip, got %q", fields2["password"])
+	}
+}
+
+func TestParseImportCSVSkipsMalformedRows(t *testing.T) {
+	csvData := "name,login,password\n" +
+		"Good,octocat,hunter2\n" +
+		"Bad\"Row,x,y\n" +
+		"AlsoGood,jane,secret\n"
+
+	items, skipped, err := parseImportCSV(strings.NewReader(csvData), protocol.DataTypeLogin)
+	if err != nil {
+		t.Fatalf("parseImportCSV: %v", err)
+	}
+	if skipped != 1 {
+		t.Fatalf("expected 1 skipped row, got %d (items=%+v)", skipped, items)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 valid items, got %d", len(items))
+	}
+}
+
+func TestParseImportCSVRejectsUnsupportedType(t *testing.T) {
+	_, _, err := parseImportCSV(strings.NewReader("name\nx\n"), protocol.DataTypeBinary)
+	if err == nil {
+		t.Fatal("expected an error for an unimportable data type")
+	}
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// Login is a typed view of a DataTypeLogin item's decrypted fields.
+type Login struct {
+	Username string `json:"login"`
+	Password string `json:"password"`
+}
+
+// Card is a typed view of a DataTypeCard item's decrypted fields.
+type Card struct {
+	Number string `json:"number"`
+	Expiry string `json:"expiry"`
+	CVV    string `json:"cvv"`
+	Holder string `json:"holder"`
+}
+
+// WiFi is a typed view of a DataTypeWiFi item's decrypted fields.
+type WiFi struct {
+	SSID         string `json:"ssid"`
+	Password     string `json:"password"`
+	SecurityType string `json:"security_type"`
+}
+
+// GetLogin fetches and decrypts id and unmarshals it into a Login. It
+// returns an error if the item exists but isn't a DataTypeLogin item.
+func (c *Client) GetLogin(id string) (Login, error) {
+	item, err := c.GetData(id)
+	if err != nil {
+		return Login{}, err
+	}
+	if item.Type != protocol.DataTypeLogin {
+		return Login{}, fmt.Errorf("item %s is not a login item (type %d)", id, item.Type)
+	}
+
+	var login Login
+	if err := json.Unmarshal(item.Data, &login); err != nil {
+		return Login{}, err
+	}
+	return login, nil
+}
+
+// GetCard fetches and decrypts id and unmarshals it into a Card. It
+// returns an error if the item exists but isn't a DataTypeCard item.
+func (c *Client) GetCard(id string) (Card, error) {
+	item, err := c.GetData(id)
+	if err != nil {
+		return Card{}, err
+	}
+	if item.Type != protocol.DataTypeCard {
+		return Card{}, fmt.Errorf("item %s is not a card item (type %d)", id, item.Type)
+	}
+
+	var card Card
+	if err := json.Unmarshal(item.Data, &card); err != nil {
+		return Card{}, err
+	}
+	return card, nil
+}
+
+// GetWiFi fetches and decrypts id and unmarshals it into a WiFi. It
+// returns an error if the item exists but isn't a DataTypeWiFi item.
+func (c *Client) GetWiFi(id string) (WiFi, error) {
+	item, err := c.GetData(id)
+	if err != nil {
+		return WiFi{}, err
+	}
+	if item.Type != protocol.DataTypeWiFi {
+		return WiFi{}, fmt.Errorf("item %s is not a WiFi item (type %d)", id, item.Type)
+	}
+
+	var wifi WiFi
+	if err := json.Unmarshal(item.Data, &wifi); err != nil {
+		return WiFi{}, err
+	}
+	return wifi, nil
+}
+
+// GetNote fetches and decrypts id and returns a DataTypeText item's
+// text. It returns an error if the item exists but isn't a
+// DataTypeText item.
+func (c *Client) GetNote(id string) (string, error) {
+	item, err := c.GetData(id)
+	if err != nil {
+		return "", err
+	}
+	if item.Type != protocol.DataTypeText {
+		return "", fmt.Errorf("item %s is not a text item (type %d)", id, item.Type)
+	}
+
+	var fields struct {
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(item.Data, &fields); err != nil {
+		return "", err
+	}
+	return fields.Text, nil
+}
+
+// SaveLogin builds a login item's payload from login and password and
+// stores it under name via StoreData, so a caller doesn't have to
+// hand-assemble the map[string]string StoreData expects (and risk
+// typoing a key like "login") the way the UI does inline.
+func (c *Client) SaveLogin(name, login, password string, meta map[string]string) (string, error) {
+	data, err := json.Marshal(Login{Username: login, Password: password})
+	if err != nil {
+		return "", err
+	}
+	return c.StoreData(protocol.NewDataItem{Type: protocol.DataTypeLogin, Name: name, Data: data, Metadata: meta})
+}
+
+// passwordHistoryLimit bounds how many previous passwords
+// MetaPasswordHistory keeps for a login item, so repeated regeneration
+// doesn't grow the item's metadata without limit.
+const passwordHistoryLimit = 5
+
+// RegenerateLoginPassword generates a new password for the login item id,
+// stores the old password in the item's MetaPasswordHistory metadata
+// (newest first, capped at passwordHistoryLimit), and overwrites the item
+// via UpdateData. It returns the newly generated password.
+func (c *Client) RegenerateLoginPassword(id string) (string, error) {
+	item, err := c.GetData(id)
+	if err != nil {
+		return "", err
+	}
+	if item.Type != protocol.DataTypeLogin {
+		return "", fmt.Errorf("item %s is not a login item (type %d)", id, item.Type)
+	}
+
+	var login Login
+	if err := json.Unmarshal(item.Data, &login); err != nil {
+		return "", err
+	}
+
+	newPassword, err := crypto.GeneratePassphrase(6, "-")
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(Login{Username: login.Username, Password: newPassword})
+	if err != nil {
+		return "", err
+	}
+
+	metadata := make(map[string]string, len(item.Metadata)+1)
+	for k, v := range item.Metadata {
+		metadata[k] = v
+	}
+	metadata[protocol.MetaPasswordHistory] = appendPasswordHistory(metadata[protocol.MetaPasswordHistory], login.Password)
+
+	if err := c.UpdateData(id, protocol.NewDataItem{
+		Type:     item.Type,
+		Name:     item.Name,
+		Data:     data,
+		Notes:    item.Notes,
+		Metadata: metadata,
+	}); err != nil {
+		return "", err
+	}
+	return newPassword, nil
+}
+
+// appendPasswordHistory prepends oldPassword to the comma-separated
+// history stored in existing, dropping anything past
+// passwordHistoryLimit entries.
+func appendPasswordHistory(existing, oldPassword string) string {
+	var entries []string
+	if existing != "" {
+		entries = strings.Split(existing, ",")
+	}
+	entries = append([]string{oldPassword}, entries...)
+	if len(entries) > passwordHistoryLimit {
+		entries = entries[:passwordHistoryLimit]
+	}
+	return strings.Join(entries, ",")
+}
+
+// SaveCard stores card under name via StoreData.
+func (c *Client) SaveCard(name string, card Card, meta map[string]string) (string, error) {
+	data, err := json.Marshal(card)
+	if err != nil {
+		return "", err
+	}
+	return c.StoreData(protocol.NewDataItem{Type: protocol.DataTypeCard, Name: name, Data: data, Metadata: meta})
+}
+
+// SaveWiFi stores wifi under name via StoreData.
+func (c *Client) SaveWiFi(name string, wifi WiFi, meta map[string]string) (string, error) {
+	data, err := json.Marshal(wifi)
+	if err != nil {
+		return "", err
+	}
+	return c.StoreData(protocol.NewDataItem{Type: protocol.DataTypeWiFi, Name: name, Data: data, Metadata: meta})
+}
+
+// SaveNote stores text as a DataTypeText item under name via StoreData,
+// completing the typed constructor trio alongside SaveLogin/SaveCard.
+func (c *Client) SaveNote(name, text string, meta map[string]string) (string, error) {
+	data, err := json.Marshal(struct {
+		Text string `json:"text"`
+	}{Text: text})
+	if err != nil {
+		return "", err
+	}
+	return c.StoreData(protocol.NewDataItem{Type: protocol.DataTypeText, Name: name, Data: data, Metadata: meta})
+}
@@ -0,0 +1,488 @@
+package client
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// serveOneGetDataResponse reads a single framed request off conn and
+// replies with a GetDataResponse wrapping item, echoing the request's
+// message ID as sendAndReceive requires.
+func serveOneGetDataResponse(conn net.Conn, item protocol.DataItem) error {
+	reqHeader := make([]byte, protocol.HeaderSize)
+	if _, err := io.ReadFull(conn, reqHeader); err != nil {
+		return err
+	}
+	reqLength := binary.BigEndian.Uint32(reqHeader[5:9])
+	if reqLength > 0 {
+		if _, err := io.ReadFull(conn, make([]byte, reqLength)); err != nil {
+			return err
+		}
+	}
+
+	respPayload, err := json.Marshal(protocol.GetDataResponse{Item: item})
+	if err != nil {
+		return err
+	}
+	respHeader := make([]byte, protocol.HeaderSize)
+	respHeader[0] = protocol.MsgTypeGetDataResponse
+	copy(respHeader[1:5], reqHeader[1:5])
+	binary.BigEndian.PutUint32(respHeader[5:9], uint32(len(respPayload)))
+	if _, err := conn.Write(respHeader); err != nil {
+		return err
+	}
+	_, err = conn.Write(respPayload)
+	return err
+}
+
+func TestGetLoginDecodesFields(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	plaintext, err := json.Marshal(map[string]string{"login": "alice", "password": "hunter2"})
+	if err != nil {
+		t.Fatalf("marshal login fields: %v", err)
+	}
+	encrypted, err := crypto.Encrypt(plaintext, masterKey)
+	if err != nil {
+		t.Fatalf("encrypt login fields: %v", err)
+	}
+
+	go serveOneGetDataResponse(serverConn, protocol.DataItem{ID: "login-1", Type: protocol.DataTypeLogin, Data: encrypted})
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	login, err := c.GetLogin("login-1")
+	if err != nil {
+		t.Fatalf("GetLogin: %v", err)
+	}
+	if login.Username != "alice" || login.Password != "hunter2" {
+		t.Fatalf("unexpected login: %+v", login)
+	}
+}
+
+func TestGetLoginRejectsWrongType(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	encrypted, err := crypto.Encrypt([]byte(`{"text":"not a login"}`), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	go serveOneGetDataResponse(serverConn, protocol.DataItem{ID: "text-1", Type: protocol.DataTypeText, Data: encrypted})
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	if _, err := c.GetLogin("text-1"); err == nil {
+		t.Fatal("expected an error fetching a text item as a login")
+	}
+}
+
+func TestGetCardDecodesFields(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	plaintext, err := json.Marshal(map[string]string{
+		"number": "4111111111111111", "expiry": "12/30", "cvv": "123", "holder": "John Doe",
+	})
+	if err != nil {
+		t.Fatalf("marshal card fields: %v", err)
+	}
+	encrypted, err := crypto.Encrypt(plaintext, masterKey)
+	if err != nil {
+		t.Fatalf("encrypt card fields: %v", err)
+	}
+
+	go serveOneGetDataResponse(serverConn, protocol.DataItem{ID: "card-1", Type: protocol.DataTypeCard, Data: encrypted})
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	card, err := c.GetCard("card-1")
+	if err != nil {
+		t.Fatalf("GetCard: %v", err)
+	}
+	if card.Number != "4111111111111111" || card.Expiry != "12/30" || card.CVV != "123" || card.Holder != "John Doe" {
+		t.Fatalf("unexpected card: %+v", card)
+	}
+}
+
+func TestGetCardRejectsWrongType(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	encrypted, err := crypto.Encrypt([]byte(`{"login":"alice","password":"x"}`), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	go serveOneGetDataResponse(serverConn, protocol.DataItem{ID: "login-1", Type: protocol.DataTypeLogin, Data: encrypted})
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	if _, err := c.GetCard("login-1"); err == nil {
+		t.Fatal("expected an error fetching a login item as a card")
+	}
+}
+
+func TestGetWiFiDecodesFields(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	plaintext, err := json.Marshal(map[string]string{
+		"ssid": "HomeNet", "password": "hunter2", "security_type": "WPA2",
+	})
+	if err != nil {
+		t.Fatalf("marshal wifi fields: %v", err)
+	}
+	encrypted, err := crypto.Encrypt(plaintext, masterKey)
+	if err != nil {
+		t.Fatalf("encrypt wifi fields: %v", err)
+	}
+
+	go serveOneGetDataResponse(serverConn, protocol.DataItem{ID: "wifi-1", Type: protocol.DataTypeWiFi, Data: encrypted})
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	wifi, err := c.GetWiFi("wifi-1")
+	if err != nil {
+		t.Fatalf("GetWiFi: %v", err)
+	}
+	if wifi.SSID != "HomeNet" || wifi.Password != "hunter2" || wifi.SecurityType != "WPA2" {
+		t.Fatalf("unexpected wifi: %+v", wifi)
+	}
+}
+
+func TestGetWiFiRejectsWrongType(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	encrypted, err := crypto.Encrypt([]byte(`{"number":"4111111111111111"}`), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	go serveOneGetDataResponse(serverConn, protocol.DataItem{ID: "card-1", Type: protocol.DataTypeCard, Data: encrypted})
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	if _, err := c.GetWiFi("card-1"); err == nil {
+		t.Fatal("expected an error fetching a card item as a WiFi item")
+	}
+}
+
+func TestGetNoteDecodesText(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	encrypted, err := crypto.Encrypt([]byte(`{"text":"remember the milk"}`), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	go serveOneGetDataResponse(serverConn, protocol.DataItem{ID: "note-1", Type: protocol.DataTypeText, Data: encrypted})
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	note, err := c.GetNote("note-1")
+	if err != nil {
+		t.Fatalf("GetNote: %v", err)
+	}
+	if note != "remember the milk" {
+		t.Fatalf("unexpected note: %q", note)
+	}
+}
+
+// serveOneStoreDataResponse reads a single framed StoreDataRequest off
+// conn, replies with a StoreDataResponse assigning it id, and returns
+// the decoded request so the caller can inspect what was actually sent.
+func serveOneStoreDataResponse(conn net.Conn, id string) (protocol.StoreDataRequest, error) {
+	reqHeader := make([]byte, protocol.HeaderSize)
+	if _, err := io.ReadFull(conn, reqHeader); err != nil {
+		return protocol.StoreDataRequest{}, err
+	}
+	reqLength := binary.BigEndian.Uint32(reqHeader[5:9])
+	reqPayload := make([]byte, reqLength)
+	if reqLength > 0 {
+		if _, err := io.ReadFull(conn, reqPayload); err != nil {
+			return protocol.StoreDataRequest{}, err
+		}
+	}
+	var req protocol.StoreDataRequest
+	if err := json.Unmarshal(reqPayload, &req); err != nil {
+		return protocol.StoreDataRequest{}, err
+	}
+
+	respPayload, err := json.Marshal(protocol.StoreDataResponse{ID: id})
+	if err != nil {
+		return req, err
+	}
+	respHeader := make([]byte, protocol.HeaderSize)
+	respHeader[0] = protocol.MsgTypeStoreDataResponse
+	copy(respHeader[1:5], reqHeader[1:5])
+	binary.BigEndian.PutUint32(respHeader[5:9], uint32(len(respPayload)))
+	if _, err := conn.Write(respHeader); err != nil {
+		return req, err
+	}
+	_, err = conn.Write(respPayload)
+	return req, err
+}
+
+func TestSaveLoginPayloadMatchesGetLoginExpectations(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	reqCh := make(chan protocol.StoreDataRequest, 1)
+	go func() {
+		req, _ := serveOneStoreDataResponse(serverConn, "login-1")
+		reqCh <- req
+	}()
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	id, err := c.SaveLogin("github", "alice", "hunter2", nil)
+	if err != nil {
+		t.Fatalf("SaveLogin: %v", err)
+	}
+	if id != "login-1" {
+		t.Fatalf("unexpected id: %q", id)
+	}
+
+	req := <-reqCh
+	if req.Item.Type != protocol.DataTypeLogin || req.Item.Name != "github" {
+		t.Fatalf("unexpected stored item: %+v", req.Item)
+	}
+
+	decrypted, err := c.decryptData(req.Item.Data)
+	if err != nil {
+		t.Fatalf("decrypt stored payload: %v", err)
+	}
+	var login Login
+	if err := json.Unmarshal(decrypted, &login); err != nil {
+		t.Fatalf("expected SaveLogin's payload to unmarshal the same way GetLogin does: %v", err)
+	}
+	if login.Username != "alice" || login.Password != "hunter2" {
+		t.Fatalf("unexpected decoded login: %+v", login)
+	}
+}
+
+func TestSaveCardPayloadMatchesGetCardExpectations(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	reqCh := make(chan protocol.StoreDataRequest, 1)
+	go func() {
+		req, _ := serveOneStoreDataResponse(serverConn, "card-1")
+		reqCh <- req
+	}()
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	card := Card{Number: "4111111111111111", Expiry: "12/30", CVV: "123", Holder: "John Doe"}
+	id, err := c.SaveCard("bank card", card, map[string]string{"issuer": "example bank"})
+	if err != nil {
+		t.Fatalf("SaveCard: %v", err)
+	}
+	if id != "card-1" {
+		t.Fatalf("unexpected id: %q", id)
+	}
+
+	req := <-reqCh
+	if req.Item.Type != protocol.DataTypeCard || req.Item.Name != "bank card" {
+		t.Fatalf("unexpected stored item: %+v", req.Item)
+	}
+	if req.Item.Metadata["issuer"] != "example bank" {
+		t.Fatalf("expected metadata to be passed through, got %v", req.Item.Metadata)
+	}
+
+	decrypted, err := c.decryptData(req.Item.Data)
+	if err != nil {
+		t.Fatalf("decrypt stored payload: %v", err)
+	}
+	var decoded Card
+	if err := json.Unmarshal(decrypted, &decoded); err != nil {
+		t.Fatalf("expected SaveCard's payload to unmarshal the same way GetCard does: %v", err)
+	}
+	if decoded != card {
+		t.Fatalf("unexpected decoded card: %+v", decoded)
+	}
+}
+
+func TestSaveWiFiPayloadMatchesGetWiFiExpectations(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	reqCh := make(chan protocol.StoreDataRequest, 1)
+	go func() {
+		req, _ := serveOneStoreDataResponse(serverConn, "wifi-1")
+		reqCh <- req
+	}()
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	wifi := WiFi{SSID: "HomeNet", Password: "hunter2", SecurityType: "WPA2"}
+	id, err := c.SaveWiFi("home wifi", wifi, map[string]string{"tags": "home"})
+	if err != nil {
+		t.Fatalf("SaveWiFi: %v", err)
+	}
+	if id != "wifi-1" {
+		t.Fatalf("unexpected id: %q", id)
+	}
+
+	req := <-reqCh
+	if req.Item.Type != protocol.DataTypeWiFi || req.Item.Name != "home wifi" {
+		t.Fatalf("unexpected stored item: %+v", req.Item)
+	}
+	if req.Item.Metadata["tags"] != "home" {
+		t.Fatalf("expected metadata to be passed through, got %v", req.Item.Metadata)
+	}
+
+	decrypted, err := c.decryptData(req.Item.Data)
+	if err != nil {
+		t.Fatalf("decrypt stored payload: %v", err)
+	}
+	var decoded WiFi
+	if err := json.Unmarshal(decrypted, &decoded); err != nil {
+		t.Fatalf("expected SaveWiFi's payload to unmarshal the same way GetWiFi does: %v", err)
+	}
+	if decoded != wifi {
+		t.Fatalf("unexpected decoded wifi: %+v", decoded)
+	}
+}
+
+func TestGetNoteRejectsWrongType(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	encrypted, err := crypto.Encrypt([]byte(`{"number":"4111111111111111"}`), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	go serveOneGetDataResponse(serverConn, protocol.DataItem{ID: "card-1", Type: protocol.DataTypeCard, Data: encrypted})
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	if _, err := c.GetNote("card-1"); err == nil {
+		t.Fatal("expected an error fetching a card item as a note")
+	}
+}
+
+// serveOneUpdateDataResponse reads a single framed UpdateDataRequest off
+// conn, replies with an empty UpdateDataResponse, and returns the
+// decoded request so the caller can inspect what was actually sent.
+func serveOneUpdateDataResponse(conn net.Conn) (protocol.UpdateDataRequest, error) {
+	reqHeader := make([]byte, protocol.HeaderSize)
+	if _, err := io.ReadFull(conn, reqHeader); err != nil {
+		return protocol.UpdateDataRequest{}, err
+	}
+	reqLength := binary.BigEndian.Uint32(reqHeader[5:9])
+	reqPayload := make([]byte, reqLength)
+	if reqLength > 0 {
+		if _, err := io.ReadFull(conn, reqPayload); err != nil {
+			return protocol.UpdateDataRequest{}, err
+		}
+	}
+	var req protocol.UpdateDataRequest
+	if err := json.Unmarshal(reqPayload, &req); err != nil {
+		return protocol.UpdateDataRequest{}, err
+	}
+
+	respPayload, err := json.Marshal(protocol.UpdateDataResponse{})
+	if err != nil {
+		return req, err
+	}
+	respHeader := make([]byte, protocol.HeaderSize)
+	respHeader[0] = protocol.MsgTypeUpdateDataResponse
+	copy(respHeader[1:5], reqHeader[1:5])
+	binary.BigEndian.PutUint32(respHeader[5:9], uint32(len(respPayload)))
+	if _, err := conn.Write(respHeader); err != nil {
+		return req, err
+	}
+	_, err = conn.Write(respPayload)
+	return req, err
+}
+
+func TestRegenerateLoginPasswordUpdatesPayloadAndHistory(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	plaintext, err := json.Marshal(Login{Username: "alice", Password: "hunter2"})
+	if err != nil {
+		t.Fatalf("marshal login fields: %v", err)
+	}
+	encrypted, err := crypto.Encrypt(plaintext, masterKey)
+	if err != nil {
+		t.Fatalf("encrypt login fields: %v", err)
+	}
+
+	existing := protocol.DataItem{
+		ID:       "login-1",
+		Type:     protocol.DataTypeLogin,
+		Name:     "Example",
+		Data:     encrypted,
+		Metadata: map[string]string{protocol.MetaPasswordHistory: "oldest"},
+	}
+
+	var updateReq protocol.UpdateDataRequest
+	var updateErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := serveOneGetDataResponse(serverConn, existing); err != nil {
+			updateErr = err
+			return
+		}
+		updateReq, updateErr = serveOneUpdateDataResponse(serverConn)
+	}()
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	newPassword, err := c.RegenerateLoginPassword("login-1")
+	if err != nil {
+		t.Fatalf("RegenerateLoginPassword: %v", err)
+	}
+	<-done
+	if updateErr != nil {
+		t.Fatalf("serve update: %v", updateErr)
+	}
+
+	if newPassword == "hunter2" || newPassword == "" {
+		t.Fatalf("expected a freshly generated password, got %q", newPassword)
+	}
+
+	decryptedData, err := crypto.Decrypt(updateReq.Item.Data, masterKey)
+	if err != nil {
+		t.Fatalf("decrypt updated data: %v", err)
+	}
+	var updatedLogin Login
+	if err := json.Unmarshal(decryptedData, &updatedLogin); err != nil {
+		t.Fatalf("unmarshal updated login: %v", err)
+	}
+	if updatedLogin.Username != "alice" || updatedLogin.Password != newPassword {
+		t.Fatalf("unexpected updated login: %+v", updatedLogin)
+	}
+
+	wantHistory := "hunter2,oldest"
+	if got := updateReq.Item.Metadata[protocol.MetaPasswordHistory]; got != wantHistory {
+		t.Fatalf("expected password history %q, got %q", wantHistory, got)
+	}
+}
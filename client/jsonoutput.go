@@ -0,0 +1,91 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// JSONItem is the shape a DataItem is rendered as for --json output. It's
+// deliberately separate from protocol.DataItem so scripting consumers
+// don't depend on the wire format, and so redaction (omitting Fields and
+// Notes) is enforced at the type level rather than by remembering to
+// blank a field.
+type JSONItem struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Name      string            `json:"name"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+
+	// Fields and Notes are only populated when the caller asked to
+	// reveal secrets; otherwise they're omitted entirely rather than
+	// sent as empty, so a consumer can't mistake "redacted" for "no
+	// value was ever set".
+	Fields map[string]string `json:"fields,omitempty"`
+	Notes  string            `json:"notes,omitempty"`
+}
+
+// DataTypeSlug is the JSON-facing (English, machine-readable) name for a
+// data type, as opposed to getDataTypeName's Russian label for the
+// interactive UI.
+func DataTypeSlug(t uint8) string {
+	switch t {
+	case protocol.DataTypeLogin:
+		return "login"
+	case protocol.DataTypeCard:
+		return "card"
+	case protocol.DataTypeText:
+		return "text"
+	case protocol.DataTypeBinary:
+		return "binary"
+	default:
+		return "unknown"
+	}
+}
+
+// ToJSONItem converts a decrypted DataItem into its JSON output shape.
+// reveal controls whether item.Data (already-decrypted plaintext) and
+// item.Notes are included; when false they're omitted entirely.
+func ToJSONItem(item protocol.DataItem, reveal bool) JSONItem {
+	out := JSONItem{
+		ID:        item.ID,
+		Type:      DataTypeSlug(item.Type),
+		Name:      item.Name,
+		CreatedAt: item.CreatedAt,
+		UpdatedAt: item.UpdatedAt,
+		Metadata:  item.Metadata,
+	}
+	if !reveal {
+		return out
+	}
+
+	if item.Type != protocol.DataTypeBinary {
+		var fields map[string]string
+		if err := json.Unmarshal(item.Data, &fields); err == nil {
+			out.Fields = fields
+		}
+	}
+	if len(item.Notes) > 0 {
+		out.Notes = string(item.Notes)
+	}
+	return out
+}
+
+// RenderItemsJSON marshals items as a JSON array of JSONItem, redacting
+// secrets unless reveal is set.
+func RenderItemsJSON(items []protocol.DataItem, reveal bool) ([]byte, error) {
+	out := make([]JSONItem, len(items))
+	for i, item := range items {
+		out[i] = ToJSONItem(item, reveal)
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// RenderItemJSON marshals a single item as JSONItem, redacting secrets
+// unless reveal is set.
+func RenderItemJSON(item protocol.DataItem, reveal bool) ([]byte, error) {
+	return json.MarshalIndent(ToJSONItem(item, reveal), "", "  ")
+}
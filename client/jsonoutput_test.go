@@ -0,0 +1,104 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func testLoginItem() protocol.DataItem {
+	return protocol.DataItem{
+		ID:        "item-1",
+		Type:      protocol.DataTypeLogin,
+		Name:      "example.com",
+		Data:      []byte(`{"login":"alice","password":"hunter2"}`),
+		Notes:     []byte("work account"),
+		Metadata:  map[string]string{protocol.MetaURL: "https://example.com"},
+		CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestRenderItemJSONRedactsByDefault(t *testing.T) {
+	out, err := RenderItemJSON(testLoginItem(), false)
+	if err != nil {
+		t.Fatalf("RenderItemJSON: %v", err)
+	}
+
+	var decoded JSONItem
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.ID != "item-1" || decoded.Type != "login" || decoded.Name != "example.com" {
+		t.Fatalf("unexpected redacted item: %+v", decoded)
+	}
+	if decoded.Fields != nil {
+		t.Fatalf("expected Fields to be omitted when not revealing, got %+v", decoded.Fields)
+	}
+	if decoded.Notes != "" {
+		t.Fatalf("expected Notes to be omitted when not revealing, got %q", decoded.Notes)
+	}
+	if decoded.Metadata[protocol.MetaURL] != "https://example.com" {
+		t.Fatalf("expected metadata to still be present, got %+v", decoded.Metadata)
+	}
+}
+
+func TestRenderItemJSONRevealsFieldsAndNotes(t *testing.T) {
+	out, err := RenderItemJSON(testLoginItem(), true)
+	if err != nil {
+		t.Fatalf("RenderItemJSON: %v", err)
+	}
+
+	var decoded JSONItem
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.Fields[protocol.FieldLogin] != "alice" || decoded.Fields[protocol.FieldPassword] != "hunter2" {
+		t.Fatalf("expected revealed fields, got %+v", decoded.Fields)
+	}
+	if decoded.Notes != "work account" {
+		t.Fatalf("expected revealed notes, got %q", decoded.Notes)
+	}
+}
+
+func TestRenderItemsJSONProducesArrayShape(t *testing.T) {
+	items := []protocol.DataItem{testLoginItem(), {
+		ID:   "item-2",
+		Type: protocol.DataTypeText,
+		Name: "a note",
+		Data: []byte(`{"text":"hello"}`),
+	}}
+
+	out, err := RenderItemsJSON(items, false)
+	if err != nil {
+		t.Fatalf("RenderItemsJSON: %v", err)
+	}
+
+	var decoded []JSONItem
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(decoded) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(decoded))
+	}
+	if decoded[0].ID != "item-1" || decoded[1].ID != "item-2" {
+		t.Fatalf("unexpected item ordering: %+v", decoded)
+	}
+}
+
+func TestDataTypeSlugCoversKnownTypes(t *testing.T) {
+	cases := map[uint8]string{
+		protocol.DataTypeLogin:  "login",
+		protocol.DataTypeCard:   "card",
+		protocol.DataTypeText:   "text",
+		protocol.DataTypeBinary: "binary",
+		99:                      "unknown",
+	}
+	for typ, want := range cases {
+		if got := DataTypeSlug(typ); got != want {
+			t.Errorf("DataTypeSlug(%d) = %q, want %q", typ, got, want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// TestClearCredentialsZeroesKeyAndClearsToken verifies that
+// clearCredentials wipes the master key's backing bytes in place and
+// resets the cached token, rather than just dropping a reference to
+// them.
+func TestClearCredentialsZeroesKeyAndClearsToken(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{masterKey: key, token: "some-session-token"}
+
+	c.clearCredentials()
+
+	for i, b := range key {
+		if b != 0 {
+			t.Fatalf("byte %d of the former master key backing array = %d, want 0", i, b)
+		}
+	}
+	if c.masterKey != nil {
+		t.Fatalf("expected masterKey to be nil after clearCredentials, got %v", c.masterKey)
+	}
+	if c.token != "" {
+		t.Fatalf("expected token to be empty after clearCredentials, got %q", c.token)
+	}
+}
+
+// TestOperationAfterClearCredentialsSendsEmptyToken verifies that a
+// request made after clearCredentials carries no token, so the server
+// rejects it rather than the client silently reusing a stale one.
+func TestOperationAfterClearCredentialsSendsEmptyToken(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	c := &Client{conn: clientConn, token: "will-be-cleared", masterKey: []byte("0123456789abcdef0123456789abcdef")}
+	c.clearCredentials()
+
+	done := make(chan error, 1)
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		var req protocol.GetDataRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			done <- err
+			return
+		}
+		if req.Token != "" {
+			done <- errors.New("expected an empty token after locking, got " + req.Token)
+			return
+		}
+		resp, err := json.Marshal(protocol.GetDataResponse{Error: "invalid or expired token"})
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- writeFramedResponse(serverConn, protocol.MsgTypeGetDataResponse, msgID, resp)
+	}()
+
+	if _, err := c.GetData("item-1"); err == nil {
+		t.Fatal("expected an error requesting data after locking")
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("server goroutine: %v", err)
+	}
+}
+
+// TestCloseZeroesKeyAndClearsCredentials verifies that Close wipes the
+// master key's backing bytes in place and clears the cached token and
+// username, not just the connection.
+func TestCloseZeroesKeyAndClearsCredentials(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{masterKey: key, token: "some-session-token", username: "alice"}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	for i, b := range key {
+		if b != 0 {
+			t.Fatalf("byte %d of the former master key backing array = %d, want 0", i, b)
+		}
+	}
+	if c.masterKey != nil {
+		t.Fatalf("expected masterKey to be nil after Close, got %v", c.masterKey)
+	}
+	if c.token != "" {
+		t.Fatalf("expected token to be empty after Close, got %q", c.token)
+	}
+	if c.username != "" {
+		t.Fatalf("expected username to be empty after Close, got %q", c.username)
+	}
+}
+
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// lockFileName is the name of the advisory lock file placed inside a
+// client-local state directory (e.g. a local encrypted cache) to stop
+// two client processes from using it at once and corrupting it.
+const lockFileName = ".lock"
+
+// Lock is an advisory, PID-stamped lock on a client state directory,
+// acquired by AcquireLock and released by Release.
+type Lock struct {
+	path string
+}
+
+// lockAcquireAttempts bounds how many times AcquireLock will retry after
+// clearing a stale lock file, so a pathological loop of processes racing
+// on the same stale lock can't spin forever.
+const lockAcquireAttempts = 2
+
+// AcquireLock acquires an advisory lock on dir by creating a PID-stamped
+// lock file inside it, creating dir if necessary. The file is created
+// with O_EXCL so two processes racing to acquire the same lock can't
+// both succeed: only one O_EXCL create wins, and the loser falls back to
+// inspecting the file that beat it. If that file's PID is still alive,
+// AcquireLock returns an error saying another instance is running. If
+// the PID is no longer alive (a previous instance crashed without
+// cleaning up), the stale lock file is removed and acquisition retries.
+func AcquireLock(dir string) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, lockFileName)
+
+	var lastErr error
+	for attempt := 0; attempt < lockAcquireAttempts; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, writeErr := f.WriteString(strconv.Itoa(os.Getpid()))
+			closeErr := f.Close()
+			if writeErr != nil {
+				return nil, writeErr
+			}
+			if closeErr != nil {
+				return nil, closeErr
+			}
+			return &Lock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		pid, readErr := readLockPID(path)
+		if readErr != nil {
+			// The lock file vanished or became unreadable between our
+			// failed create and this read (e.g. the holder just
+			// released it) — retry the create rather than erroring.
+			lastErr = readErr
+			continue
+		}
+		if processAlive(pid) {
+			return nil, fmt.Errorf("another instance is running (pid %d)", pid)
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("remove stale lock file: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("failed to acquire lock at %s: %w", path, lastErr)
+}
+
+// Release removes the lock file, allowing another instance to acquire
+// the lock afterward.
+func (l *Lock) Release() error {
+	return os.Remove(l.path)
+}
+
+// readLockPID reads and parses the PID stored in the lock file at path.
+func readLockPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("parse lock file %s: %w", path, err)
+	}
+	return pid, nil
+}
+
+// processAlive reports whether pid refers to a currently running
+// process, by sending it signal 0: a no-op that still fails if the
+// process doesn't exist.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
@@ -0,0 +1,126 @@
+package client
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestAcquireLockSucceedsOnEmptyDir(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	defer lock.Release()
+
+	data, err := os.ReadFile(filepath.Join(dir, lockFileName))
+	if err != nil {
+		t.Fatalf("read lock file: %v", err)
+	}
+	if string(data) != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("expected lock file to contain this process's pid, got %q", data)
+	}
+}
+
+func TestAcquireLockFailsWhenHeldByLiveProcess(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	defer lock.Release()
+
+	if _, err := AcquireLock(dir); err == nil {
+		t.Fatal("expected a second AcquireLock to fail while the first is held")
+	}
+}
+
+func TestAcquireLockCleansUpStaleLock(t *testing.T) {
+	dir := t.TempDir()
+
+	// Run a short-lived subprocess and use its pid, which is guaranteed
+	// dead once Wait returns, to stand in for a crashed client instance
+	// that never released its lock.
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("run subprocess: %v", err)
+	}
+	stalePID := cmd.Process.Pid
+
+	if err := os.WriteFile(filepath.Join(dir, lockFileName), []byte(strconv.Itoa(stalePID)), 0600); err != nil {
+		t.Fatalf("write stale lock file: %v", err)
+	}
+
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("expected AcquireLock to clean up a stale lock and succeed, got: %v", err)
+	}
+	defer lock.Release()
+
+	data, err := os.ReadFile(filepath.Join(dir, lockFileName))
+	if err != nil {
+		t.Fatalf("read lock file: %v", err)
+	}
+	if string(data) != strconv.Itoa(os.Getpid()) {
+		t.Fatalf("expected lock file to now contain this process's pid, got %q", data)
+	}
+}
+
+// TestAcquireLockConcurrentCallersOnlyOneWins launches many goroutines
+// racing to acquire the same lock at once, standing in for two client
+// processes launched at the same moment. Exactly one must win: this is
+// the exact scenario the lock file exists to prevent, so a second winner
+// here would mean the corruption it's meant to stop can still happen.
+func TestAcquireLockConcurrentCallersOnlyOneWins(t *testing.T) {
+	dir := t.TempDir()
+
+	const racers = 20
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var winners []*Lock
+
+	for i := 0; i < racers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lock, err := AcquireLock(dir)
+			if err == nil {
+				mu.Lock()
+				winners = append(winners, lock)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	for _, lock := range winners {
+		lock.Release()
+	}
+
+	if len(winners) != 1 {
+		t.Fatalf("expected exactly one of %d concurrent AcquireLock calls to succeed, got %d", racers, len(winners))
+	}
+}
+
+func TestLockReleaseAllowsReacquisition(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("AcquireLock: %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+
+	second, err := AcquireLock(dir)
+	if err != nil {
+		t.Fatalf("expected AcquireLock to succeed after Release, got: %v", err)
+	}
+	defer second.Release()
+}
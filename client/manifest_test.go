@@ -0,0 +1,60 @@
+package client
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestManifestReturnsServerEntries(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	updatedAt := time.Now().Truncate(time.Second)
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, _ := json.Marshal(protocol.ManifestResponse{
+			Entries: []protocol.ManifestEntry{
+				{ID: "item-1", UpdatedAt: updatedAt, ContentHash: "deadbeef"},
+			},
+		})
+		writeFramedResponse(serverConn, protocol.MsgTypeManifestResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn}
+	entries, err := c.Manifest()
+	if err != nil {
+		t.Fatalf("Manifest: %v", err)
+	}
+	if len(entries) != 1 || entries[0].ID != "item-1" || entries[0].ContentHash != "deadbeef" {
+		t.Fatalf("unexpected manifest entries: %+v", entries)
+	}
+}
+
+func TestManifestReturnsErrorFromServer(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, _ := json.Marshal(protocol.ManifestResponse{Error: "invalid token"})
+		writeFramedResponse(serverConn, protocol.MsgTypeManifestResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn}
+	if _, err := c.Manifest(); err == nil {
+		t.Fatal("expected an error when the server reports one")
+	}
+}
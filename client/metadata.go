@@ -0,0 +1,26 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// parseMetadataJSON validates that raw decodes to a flat JSON object of
+// string values, rejecting anything nested (objects, arrays) or of a
+// non-string type, and returns it as a metadata map.
+func parseMetadataJSON(raw string) (map[string]string, error) {
+	var untyped map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &untyped); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	metadata := make(map[string]string, len(untyped))
+	for key, value := range untyped {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("metadata value for %q must be a string, got %T", key, value)
+		}
+		metadata[key] = s
+	}
+	return metadata, nil
+}
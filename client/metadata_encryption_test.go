@@ -0,0 +1,159 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// TestStoreDataEncryptsMetadataValuesWhenEnabled verifies that, with
+// EncryptMetadataValues set, StoreData never sends a metadata value in
+// plaintext, while the key it's stored under stays readable so the
+// server can still filter on it.
+func TestStoreDataEncryptsMetadataValuesWhenEnabled(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{conn: clientConn, masterKey: masterKey, EncryptMetadataValues: true}
+
+	done := make(chan error, 1)
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		var req protocol.StoreDataRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			done <- err
+			return
+		}
+		rawURL, ok := req.Item.Metadata[protocol.MetaURL]
+		if !ok {
+			done <- io.EOF
+			return
+		}
+		if rawURL == "https://example.com" {
+			done <- io.EOF // fail: value must not travel in plaintext
+			return
+		}
+		ciphertext, err := base64.StdEncoding.DecodeString(rawURL)
+		if err != nil {
+			done <- err
+			return
+		}
+		decrypted, err := crypto.Decrypt(ciphertext, masterKey)
+		if err != nil {
+			done <- err
+			return
+		}
+		if string(decrypted) != "https://example.com" {
+			done <- io.EOF
+			return
+		}
+		resp, err := json.Marshal(protocol.StoreDataResponse{ID: "item-1"})
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- writeFramedResponse(serverConn, protocol.MsgTypeStoreDataResponse, msgID, resp)
+	}()
+
+	_, err := c.StoreData(protocol.NewDataItem{
+		Type:     protocol.DataTypeLogin,
+		Name:     "example",
+		Data:     []byte(`{"login":"alice","password":"hunter2"}`),
+		Metadata: map[string]string{protocol.MetaURL: "https://example.com"},
+	})
+	if err != nil {
+		t.Fatalf("StoreData: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("server: %v", err)
+	}
+}
+
+// TestGetDataDecryptsMetadataValuesWhenEnabled verifies the read-side
+// round trip: a metadata value encrypted at store time comes back as
+// plaintext once GetData decrypts it.
+func TestGetDataDecryptsMetadataValuesWhenEnabled(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{conn: clientConn, masterKey: masterKey, EncryptMetadataValues: true}
+
+	ciphertext, err := crypto.Encrypt([]byte("https://example.com"), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	encryptedData, err := crypto.Encrypt([]byte(`{"login":"alice","password":"hunter2"}`), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		item := protocol.DataItem{
+			ID:   "item-1",
+			Type: protocol.DataTypeLogin,
+			Name: "example",
+			Data: encryptedData,
+			Metadata: map[string]string{
+				protocol.MetaURL: base64.StdEncoding.EncodeToString(ciphertext),
+			},
+		}
+		resp, err := json.Marshal(protocol.GetDataResponse{Item: item})
+		if err != nil {
+			return
+		}
+		writeFramedResponse(serverConn, protocol.MsgTypeGetDataResponse, msgID, resp)
+	}()
+
+	item, err := c.GetData("item-1")
+	if err != nil {
+		t.Fatalf("GetData: %v", err)
+	}
+	if item.Metadata[protocol.MetaURL] != "https://example.com" {
+		t.Fatalf("got %q, want plaintext URL", item.Metadata[protocol.MetaURL])
+	}
+}
+
+func TestEncryptDecryptMetadataValuesRoundTrip(t *testing.T) {
+	c := &Client{masterKey: []byte("0123456789abcdef0123456789abcdef")}
+	original := map[string]string{
+		protocol.MetaURL:  "https://example.com",
+		protocol.MetaTags: "work,personal",
+	}
+
+	encrypted, err := c.encryptMetadataValues(original)
+	if err != nil {
+		t.Fatalf("encryptMetadataValues: %v", err)
+	}
+	for key, value := range encrypted {
+		if value == original[key] {
+			t.Fatalf("expected %q to be encrypted, got the original value back", key)
+		}
+	}
+
+	decrypted, err := c.decryptMetadataValues(encrypted)
+	if err != nil {
+		t.Fatalf("decryptMetadataValues: %v", err)
+	}
+	for key, value := range original {
+		if decrypted[key] != value {
+			t.Fatalf("key %q: got %q, want %q", key, decrypted[key], value)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMetadataJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "valid flat object",
+			raw:  `{"url": "https://example.com", "note": "work account"}`,
+			want: map[string]string{"url": "https://example.com", "note": "work account"},
+		},
+		{
+			name: "empty object",
+			raw:  `{}`,
+			want: map[string]string{},
+		},
+		{
+			name:    "malformed JSON",
+			raw:     `{"url": }`,
+			wantErr: true,
+		},
+		{
+			name:    "not an object",
+			raw:     `["url", "note"]`,
+			wantErr: true,
+		},
+		{
+			name:    "nested object value",
+			raw:     `{"url": {"scheme": "https"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "non-string value",
+			raw:     `{"count": 3}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMetadataJSON(tt.raw)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error for input %q", tt.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package client
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// readFramedRequest reads a single request off conn and returns its
+// message type, ID, and payload.
+func readFramedRequest(conn net.Conn) (msgType uint8, msgID [4]byte, payload []byte, err error) {
+	header := make([]byte, protocol.HeaderSize)
+	if _, err = io.ReadFull(conn, header); err != nil {
+		return
+	}
+	msgType = header[0]
+	copy(msgID[:], header[1:5])
+	length := binary.BigEndian.Uint32(header[5:9])
+	if length > 0 {
+		payload = make([]byte, length)
+		if _, err = io.ReadFull(conn, payload); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// writeFramedResponse writes a response frame echoing the given message
+// ID, as sendAndReceive requires.
+func writeFramedResponse(conn net.Conn, msgType uint8, msgID [4]byte, payload []byte) error {
+	header := make([]byte, protocol.HeaderSize)
+	header[0] = msgType
+	copy(header[1:5], msgID[:])
+	binary.BigEndian.PutUint32(header[5:9], uint32(len(payload)))
+	if _, err := conn.Write(header); err != nil {
+		return err
+	}
+	_, err := conn.Write(payload)
+	return err
+}
+
+func TestStoreDataEncryptsNotes(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{conn: clientConn, masterKey: masterKey}
+
+	done := make(chan error, 1)
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		var req protocol.StoreDataRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			done <- err
+			return
+		}
+		if string(req.Item.Notes) == "work laptop login" {
+			done <- io.EOF // fail: notes must not travel in plaintext
+			return
+		}
+		decrypted, err := crypto.Decrypt(req.Item.Notes, masterKey)
+		if err != nil {
+			done <- err
+			return
+		}
+		if string(decrypted) != "work laptop login" {
+			done <- io.EOF
+			return
+		}
+		resp, err := json.Marshal(protocol.StoreDataResponse{ID: "item-1"})
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- writeFramedResponse(serverConn, protocol.MsgTypeStoreDataResponse, msgID, resp)
+	}()
+
+	id, err := c.StoreData(protocol.NewDataItem{
+		Type:  protocol.DataTypeLogin,
+		Name:  "example",
+		Data:  []byte(`{"login":"alice","password":"hunter2"}`),
+		Notes: []byte("work laptop login"),
+	})
+	if err != nil {
+		t.Fatalf("StoreData: %v", err)
+	}
+	if id != "item-1" {
+		t.Fatalf("unexpected id: %q", id)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("server: %v", err)
+	}
+}
+
+func TestGetDataDecryptsNotes(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	encryptedNotes, err := crypto.Encrypt([]byte("recovery hint: ask Bob"), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt notes: %v", err)
+	}
+	encryptedData, err := crypto.Encrypt([]byte(`{"text":"secret"}`), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt data: %v", err)
+	}
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, err := json.Marshal(protocol.GetDataResponse{Item: protocol.DataItem{
+			ID:    "note-1",
+			Type:  protocol.DataTypeText,
+			Data:  encryptedData,
+			Notes: encryptedNotes,
+		}})
+		if err != nil {
+			return
+		}
+		writeFramedResponse(serverConn, protocol.MsgTypeGetDataResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn, masterKey: masterKey}
+	item, err := c.GetData("note-1")
+	if err != nil {
+		t.Fatalf("GetData: %v", err)
+	}
+	if string(item.Notes) != "recovery hint: ask Bob" {
+		t.Fatalf("unexpected notes: %q", item.Notes)
+	}
+}
+
+func TestUpdateDataEncryptsNotes(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{conn: clientConn, masterKey: masterKey}
+
+	done := make(chan error, 1)
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		var req protocol.UpdateDataRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			done <- err
+			return
+		}
+		decrypted, err := crypto.Decrypt(req.Item.Notes, masterKey)
+		if err != nil {
+			done <- err
+			return
+		}
+		if string(decrypted) != "updated note" {
+			done <- io.EOF
+			return
+		}
+		resp, err := json.Marshal(protocol.UpdateDataResponse{})
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- writeFramedResponse(serverConn, protocol.MsgTypeUpdateDataResponse, msgID, resp)
+	}()
+
+	err := c.UpdateData("note-1", protocol.NewDataItem{
+		Type:  protocol.DataTypeCard,
+		Name:  "example card",
+		Data:  []byte(`{"card_number":"4111"}`),
+		Notes: []byte("updated note"),
+	})
+	if err != nil {
+		t.Fatalf("UpdateData: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("server: %v", err)
+	}
+}
+
+func TestEncryptDecryptItemFieldsRoundTripEmptyNotes(t *testing.T) {
+	c := &Client{masterKey: []byte("0123456789abcdef0123456789abcdef")}
+
+	item := &protocol.NewDataItem{Data: []byte(`{"text":"no note here"}`)}
+	if err := c.encryptItemFields(item); err != nil {
+		t.Fatalf("encryptItemFields: %v", err)
+	}
+	if len(item.Notes) != 0 {
+		t.Fatalf("expected empty notes to stay empty, got %q", item.Notes)
+	}
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func itemsNamed(n int) []protocol.DataItem {
+	items := make([]protocol.DataItem, n)
+	for i := range items {
+		items[i] = protocol.DataItem{ID: string(rune('a' + i))}
+	}
+	return items
+}
+
+func TestPaginateFullPages(t *testing.T) {
+	items := itemsNamed(20)
+
+	page, totalPages := paginate(items, 1, 10)
+	if totalPages != 2 {
+		t.Fatalf("expected 2 total pages, got %d", totalPages)
+	}
+	if len(page) != 10 || page[0].ID != items[0].ID || page[9].ID != items[9].ID {
+		t.Fatalf("unexpected first page: %+v", page)
+	}
+
+	page, totalPages = paginate(items, 2, 10)
+	if totalPages != 2 {
+		t.Fatalf("expected 2 total pages, got %d", totalPages)
+	}
+	if len(page) != 10 || page[0].ID != items[10].ID || page[9].ID != items[19].ID {
+		t.Fatalf("unexpected second page: %+v", page)
+	}
+}
+
+func TestPaginateLastPartialPage(t *testing.T) {
+	items := itemsNamed(23)
+
+	page, totalPages := paginate(items, 3, 10)
+	if totalPages != 3 {
+		t.Fatalf("expected 3 total pages, got %d", totalPages)
+	}
+	if len(page) != 3 {
+		t.Fatalf("expected 3 items on the last partial page, got %d", len(page))
+	}
+	if page[0].ID != items[20].ID || page[2].ID != items[22].ID {
+		t.Fatalf("unexpected last page contents: %+v", page)
+	}
+}
+
+func TestPaginateOutOfRangePage(t *testing.T) {
+	items := itemsNamed(5)
+
+	if page, totalPages := paginate(items, 0, 10); page != nil || totalPages != 1 {
+		t.Fatalf("page 0: got page=%v totalPages=%d, want nil page and totalPages 1", page, totalPages)
+	}
+	if page, totalPages := paginate(items, 2, 10); page != nil || totalPages != 1 {
+		t.Fatalf("page 2 of 1: got page=%v totalPages=%d, want nil page and totalPages 1", page, totalPages)
+	}
+	if page, totalPages := paginate(items, -1, 10); page != nil || totalPages != 1 {
+		t.Fatalf("negative page: got page=%v totalPages=%d, want nil page and totalPages 1", page, totalPages)
+	}
+}
+
+func TestPaginateEmptyItemsOrPageSize(t *testing.T) {
+	if page, totalPages := paginate(nil, 1, 10); page != nil || totalPages != 0 {
+		t.Fatalf("empty items: got page=%v totalPages=%d, want nil page and totalPages 0", page, totalPages)
+	}
+	if page, totalPages := paginate(itemsNamed(5), 1, 0); page != nil || totalPages != 0 {
+		t.Fatalf("zero page size: got page=%v totalPages=%d, want nil page and totalPages 0", page, totalPages)
+	}
+}
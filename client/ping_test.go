@@ -0,0 +1,56 @@
+package client
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestPingReturnsNonNegativeDuration(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, err := json.Marshal(protocol.PingResponse{})
+		if err != nil {
+			return
+		}
+		writeFramedResponse(serverConn, protocol.MsgTypePingResponse, msgID, resp)
+	}()
+
+	c := &Client{conn: clientConn}
+	rtt, err := c.Ping()
+	if err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if rtt < 0 {
+		t.Fatalf("expected a non-negative duration, got %s", rtt)
+	}
+}
+
+func TestPingTimesOutOnNonRespondingServer(t *testing.T) {
+	origTimeout := pingTimeout
+	pingTimeout = 50 * time.Millisecond
+	defer func() { pingTimeout = origTimeout }()
+
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go readFramedRequest(serverConn) // read the ping, never respond
+
+	c := &Client{conn: clientConn}
+	if _, err := c.Ping(); err == nil {
+		t.Fatal("expected a timeout error from a non-responding server")
+	} else if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+		t.Fatalf("expected a net.Error timeout, got %v (%T)", err, err)
+	}
+}
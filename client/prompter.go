@@ -0,0 +1,62 @@
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Prompter abstracts UIClient's interaction with a human, so the menu
+// and prompt flows can be driven by something other than a real
+// terminal in tests. The default, stdioPrompter, reads from os.Stdin and
+// writes to os.Stdout.
+type Prompter interface {
+	// ReadLine reads a line of input, with surrounding whitespace
+	// trimmed. It returns "" once the input is exhausted.
+	ReadLine() string
+	// ReadPassword reads a line of input the same as ReadLine. It's a
+	// separate method so an implementation can suppress echo for it
+	// without affecting ordinary prompts.
+	ReadPassword() string
+	// Printf writes formatted output, the same as fmt.Printf.
+	Printf(format string, args ...any)
+}
+
+// stdioPrompter is the default Prompter, backed by os.Stdin and
+// os.Stdout.
+type stdioPrompter struct {
+	reader *bufio.Reader
+}
+
+// newStdioPrompter returns a Prompter reading from standard input.
+func newStdioPrompter() *stdioPrompter {
+	return &stdioPrompter{reader: bufio.NewReader(os.Stdin)}
+}
+
+func (p *stdioPrompter) ReadLine() string {
+	line, _ := p.reader.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+// ReadPassword reads a password without echoing it, when standard input
+// is an actual terminal. When it isn't (input piped from a file or
+// script), term.ReadPassword has nothing to suspend echo on, so this
+// falls back to an ordinary, echoed ReadLine.
+func (p *stdioPrompter) ReadPassword() string {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return p.ReadLine()
+	}
+	password, err := term.ReadPassword(fd)
+	if err != nil {
+		return p.ReadLine()
+	}
+	return strings.TrimSpace(string(password))
+}
+
+func (p *stdioPrompter) Printf(format string, args ...any) {
+	fmt.Printf(format, args...)
+}
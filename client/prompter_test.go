@@ -0,0 +1,80 @@
+package client
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// recordingPrompter wraps a bufferPrompter and additionally records
+// whether password entry went through the no-echo ReadPassword method
+// rather than plain, echoed ReadLine.
+type recordingPrompter struct {
+	*bufferPrompter
+	usedReadPassword bool
+}
+
+func newRecordingPrompter(input string) *recordingPrompter {
+	return &recordingPrompter{bufferPrompter: newBufferPrompter(input)}
+}
+
+func (p *recordingPrompter) ReadPassword() string {
+	p.usedReadPassword = true
+	return p.bufferPrompter.ReadPassword()
+}
+
+// TestHandleLoginUsesNoEchoPasswordPrompt asserts that handleLogin reads
+// the master password through the Prompter's no-echo ReadPassword
+// method, not the plain, echoed ReadLine — the point of routing password
+// entry through the Prompter abstraction at all.
+func TestHandleLoginUsesNoEchoPasswordPrompt(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, _ := json.Marshal(protocol.AuthResponse{Success: false, Error: "invalid credentials"})
+		writeFramedResponse(serverConn, protocol.MsgTypeAuthResponse, msgID, resp)
+	}()
+
+	prompter := newRecordingPrompter("alice\nhunter2\n")
+	u := &UIClient{prompter: prompter, client: &Client{conn: clientConn}}
+
+	u.handleLogin()
+
+	if !prompter.usedReadPassword {
+		t.Fatal("expected handleLogin to read the password via the no-echo ReadPassword method")
+	}
+}
+
+// TestHandleRegistrationUsesNoEchoPasswordPrompt is the registration
+// analogue of TestHandleLoginUsesNoEchoPasswordPrompt.
+func TestHandleRegistrationUsesNoEchoPasswordPrompt(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		resp, _ := json.Marshal(protocol.RegisterResponse{Success: false, Error: "username taken"})
+		writeFramedResponse(serverConn, protocol.MsgTypeRegisterResponse, msgID, resp)
+	}()
+
+	prompter := newRecordingPrompter("alice\nhunter2\n")
+	u := &UIClient{prompter: prompter, client: &Client{conn: clientConn}}
+
+	u.handleRegistration()
+
+	if !prompter.usedReadPassword {
+		t.Fatal("expected handleRegistration to read the password via the no-echo ReadPassword method")
+	}
+}
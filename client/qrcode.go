@@ -0,0 +1,49 @@
+package client
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/mdp/qrterminal/v3"
+	"golang.org/x/term"
+	"rsc.io/qr"
+)
+
+// stdoutIsTerminal reports whether standard output is an actual terminal
+// capable of rendering block characters, as opposed to being piped or
+// redirected somewhere that can't.
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// RenderQRCode renders uri as ANSI block characters forming a scannable
+// QR code, for direct printing to a terminal. If uri can't be encoded as
+// a QR code (e.g. it's too long for any QR version), it's returned
+// unchanged instead.
+func RenderQRCode(uri string) string {
+	if _, err := qr.Encode(uri, qr.M); err != nil {
+		return uri
+	}
+	var buf bytes.Buffer
+	qrterminal.GenerateWithConfig(uri, qrterminal.Config{
+		Level:     qrterminal.M,
+		Writer:    &buf,
+		BlackChar: qrterminal.BLACK,
+		WhiteChar: qrterminal.WHITE,
+		QuietZone: qrterminal.QUIET_ZONE,
+	})
+	return buf.String()
+}
+
+// printQRCode prints uri as a scannable terminal QR code, so a user can
+// re-provision another device by pointing a camera at it. When standard
+// output isn't an actual terminal (piped to a file, redirected, etc.),
+// it falls back to printing the raw URI instead, since block characters
+// would just come out as garbage.
+func (u *UIClient) printQRCode(uri string) {
+	if !stdoutIsTerminal() {
+		u.prompter.Printf("%s\n", uri)
+		return
+	}
+	u.prompter.Printf("%s", RenderQRCode(uri))
+}
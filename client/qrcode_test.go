@@ -0,0 +1,49 @@
+package client
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRenderQRCodeProducesBlockOutput is a smoke test for the QR
+// rendering itself: it just checks that a valid URI comes back as
+// something other than the bare input (i.e. actual block characters
+// were rendered), without asserting on the exact QR layout.
+func TestRenderQRCodeProducesBlockOutput(t *testing.T) {
+	uri := WiFiQRPayload(WiFi{SSID: "HomeNet", Password: "hunter2", SecurityType: "WPA2"})
+
+	rendered := RenderQRCode(uri)
+	if rendered == uri {
+		t.Fatal("expected the URI to be rendered as a QR code, not returned unchanged")
+	}
+	if !strings.Contains(rendered, "\n") {
+		t.Fatal("expected multi-line block output")
+	}
+}
+
+// TestRenderQRCodeFallsBackOnUnencodableInput asserts that a URI too
+// long for any QR version is returned unchanged instead of panicking or
+// silently dropping data.
+func TestRenderQRCodeFallsBackOnUnencodableInput(t *testing.T) {
+	uri := "WIFI:T:WPA;S:" + strings.Repeat("x", 10000) + ";P:;;"
+
+	if rendered := RenderQRCode(uri); rendered != uri {
+		t.Fatalf("expected the unencodable URI to be returned unchanged, got %d bytes", len(rendered))
+	}
+}
+
+// TestPrintQRCodePrintsRawURIWhenNotATerminal asserts the graceful
+// fallback: go test's stdout is never a terminal, so printQRCode must
+// print the plain URI rather than block characters a non-terminal
+// couldn't render.
+func TestPrintQRCodePrintsRawURIWhenNotATerminal(t *testing.T) {
+	prompter := newBufferPrompter("")
+	u := &UIClient{prompter: prompter}
+
+	uri := WiFiQRPayload(WiFi{SSID: "HomeNet", Password: "hunter2"})
+	u.printQRCode(uri)
+
+	if got := prompter.out.String(); got != uri+"\n" {
+		t.Fatalf("expected the raw URI to be printed, got %q", got)
+	}
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// defaultRecentlyViewedLimit bounds how many item IDs RecencyList
+// retains by default.
+const defaultRecentlyViewedLimit = 10
+
+// RecencyList tracks the most recently touched IDs, most-recent-first,
+// deduplicated and capped at a fixed size.
+type RecencyList struct {
+	limit int
+	ids   []string
+}
+
+// NewRecencyList returns an empty RecencyList capped at limit entries.
+func NewRecencyList(limit int) *RecencyList {
+	return &RecencyList{limit: limit}
+}
+
+// Touch moves id to the front of the list, removing any earlier
+// occurrence, and drops the oldest entries past the list's limit.
+func (r *RecencyList) Touch(id string) {
+	ids := make([]string, 0, len(r.ids)+1)
+	ids = append(ids, id)
+	for _, existing := range r.ids {
+		if existing != id {
+			ids = append(ids, existing)
+		}
+	}
+	if len(ids) > r.limit {
+		ids = ids[:r.limit]
+	}
+	r.ids = ids
+}
+
+// IDs returns the tracked IDs, most-recent-first.
+func (r *RecencyList) IDs() []string {
+	return append([]string(nil), r.ids...)
+}
+
+// Prune drops any tracked ID for which exists returns false, e.g. an
+// item that no longer exists after a sync.
+func (r *RecencyList) Prune(exists func(id string) bool) {
+	var kept []string
+	for _, id := range r.ids {
+		if exists(id) {
+			kept = append(kept, id)
+		}
+	}
+	r.ids = kept
+}
+
+// LoadRecencyList reads a RecencyList previously written by Save from
+// path, capped at limit. A missing file is treated as an empty list
+// rather than an error, so the first run before any state file exists
+// works normally.
+func LoadRecencyList(path string, limit int) (*RecencyList, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewRecencyList(limit), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return nil, err
+	}
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return &RecencyList{limit: limit, ids: ids}, nil
+}
+
+// Save writes r's IDs to path as JSON, creating the containing directory
+// if necessary.
+func (r *RecencyList) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(r.ids)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRecencyListTouchOrdersMostRecentFirst(t *testing.T) {
+	list := NewRecencyList(10)
+	list.Touch("a")
+	list.Touch("b")
+	list.Touch("c")
+
+	if got, want := list.IDs(), []string{"c", "b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestRecencyListTouchDedupsExistingEntry(t *testing.T) {
+	list := NewRecencyList(10)
+	list.Touch("a")
+	list.Touch("b")
+	list.Touch("a")
+
+	if got, want := list.IDs(), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestRecencyListTouchCapsAtLimit(t *testing.T) {
+	list := NewRecencyList(2)
+	list.Touch("a")
+	list.Touch("b")
+	list.Touch("c")
+
+	if got, want := list.IDs(), []string{"c", "b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestRecencyListPruneRemovesDeletedItems(t *testing.T) {
+	list := NewRecencyList(10)
+	list.Touch("a")
+	list.Touch("b")
+	list.Touch("c")
+
+	existing := map[string]bool{"a": true, "c": true}
+	list.Prune(func(id string) bool { return existing[id] })
+
+	if got, want := list.IDs(), []string{"c", "a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestLoadRecencyListMissingFileReturnsEmptyList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "does-not-exist.json")
+	list, err := LoadRecencyList(path, defaultRecentlyViewedLimit)
+	if err != nil {
+		t.Fatalf("LoadRecencyList: %v", err)
+	}
+	if len(list.IDs()) != 0 {
+		t.Fatalf("expected empty list, got %v", list.IDs())
+	}
+}
+
+func TestRecencyListSaveAndLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "recently_viewed.json")
+	list := NewRecencyList(10)
+	list.Touch("a")
+	list.Touch("b")
+	if err := list.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := LoadRecencyList(path, 10)
+	if err != nil {
+		t.Fatalf("LoadRecencyList: %v", err)
+	}
+	if got, want := loaded.IDs(), []string{"b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
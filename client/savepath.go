@@ -0,0 +1,56 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveSavePath decides what path a download should actually be
+// written to. If overwrite is true, or nothing exists at path yet, path
+// is returned unchanged. Otherwise a numeric suffix is inserted before
+// the extension ("report.pdf" -> "report (1).pdf", trying (2), (3), ...)
+// until a path that doesn't already exist is found, so a download can
+// never silently clobber an existing file.
+func resolveSavePath(path string, overwrite bool) (string, error) {
+	if overwrite {
+		return path, nil
+	}
+
+	exists, err := fileExists(path)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return path, nil
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		exists, err := fileExists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+}
+
+// fileExists reports whether path names an existing file, treating any
+// stat error other than "not found" as a failure the caller should
+// surface rather than silently treat as "doesn't exist".
+func fileExists(path string) (bool, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSavePathReturnsOriginalWhenNothingExists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.pdf")
+	got, err := resolveSavePath(path, false)
+	if err != nil {
+		t.Fatalf("resolveSavePath: %v", err)
+	}
+	if got != path {
+		t.Fatalf("got %q, want %q", got, path)
+	}
+}
+
+func TestResolveSavePathOverwriteReturnsOriginalEvenIfItExists(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatalf("seed file: %v", err)
+	}
+
+	got, err := resolveSavePath(path, true)
+	if err != nil {
+		t.Fatalf("resolveSavePath: %v", err)
+	}
+	if got != path {
+		t.Fatalf("got %q, want %q", got, path)
+	}
+}
+
+func TestResolveSavePathAddsNumericSuffixOnCollision(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatalf("seed file: %v", err)
+	}
+
+	got, err := resolveSavePath(path, false)
+	if err != nil {
+		t.Fatalf("resolveSavePath: %v", err)
+	}
+	want := filepath.Join(dir, "report (1).pdf")
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveSavePathSkipsMultipleExistingSuffixes(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.pdf")
+	for _, name := range []string{"report.pdf", "report (1).pdf", "report (2).pdf"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("old"), 0o600); err != nil {
+			t.Fatalf("seed file: %v", err)
+		}
+	}
+
+	got, err := resolveSavePath(path, false)
+	if err != nil {
+		t.Fatalf("resolveSavePath: %v", err)
+	}
+	want := filepath.Join(dir, "report (3).pdf")
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveSavePathHandlesPathWithoutExtension(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatalf("seed file: %v", err)
+	}
+
+	got, err := resolveSavePath(path, false)
+	if err != nil {
+		t.Fatalf("resolveSavePath: %v", err)
+	}
+	want := filepath.Join(dir, "report (1)")
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestFileExistsReportsPresenceOfAFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "present.txt")
+	if err := os.WriteFile(path, []byte("x"), 0o600); err != nil {
+		t.Fatalf("seed file: %v", err)
+	}
+
+	exists, err := fileExists(path)
+	if err != nil {
+		t.Fatalf("fileExists: %v", err)
+	}
+	if !exists {
+		t.Fatal("expected the file to be reported as existing")
+	}
+
+	exists, err = fileExists(filepath.Join(dir, "absent.txt"))
+	if err != nil {
+		t.Fatalf("fileExists: %v", err)
+	}
+	if exists {
+		t.Fatal("expected a missing file to be reported as not existing")
+	}
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// Search syncs the vault, decrypts every item locally, and returns those
+// whose plaintext content matches query. This is distinct from any
+// server-side name search: the query and the decrypted content never
+// leave the client.
+func (c *Client) Search(query string) ([]protocol.DataItem, error) {
+	items, err := c.SyncData(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return SearchDecrypted(items, query), nil
+}
+
+// SearchDecrypted returns the items among items whose name or decrypted
+// content contains query, case-insensitively. It performs no network or
+// crypto operations, which keeps it easy to test directly.
+func SearchDecrypted(items []protocol.DataItem, query string) []protocol.DataItem {
+	lowerQuery := strings.ToLower(query)
+
+	var matches []protocol.DataItem
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(item.Name), lowerQuery) || itemContentMatches(item, lowerQuery) {
+			matches = append(matches, item)
+		}
+	}
+	return matches
+}
+
+// itemContentMatches inspects the decrypted, type-specific plaintext
+// fields of item for lowerQuery. Items whose payload doesn't parse as
+// expected are treated as non-matching rather than erroring, since a
+// search shouldn't abort over one malformed item.
+func itemContentMatches(item protocol.DataItem, lowerQuery string) bool {
+	var fields map[string]string
+	if err := json.Unmarshal(item.Data, &fields); err != nil {
+		return false
+	}
+
+	switch item.Type {
+	case protocol.DataTypeLogin:
+		return strings.Contains(strings.ToLower(fields[protocol.FieldLogin]), lowerQuery)
+	case protocol.DataTypeCard:
+		return strings.Contains(strings.ToLower(fields[protocol.FieldCardHolder]), lowerQuery)
+	case protocol.DataTypeText:
+		return strings.Contains(strings.ToLower(fields[protocol.FieldText]), lowerQuery)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func mustMarshal(t *testing.T, v map[string]string) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return data
+}
+
+func TestSearchDecrypted(t *testing.T) {
+	items := []protocol.DataItem{
+		{
+			ID:   "1",
+			Type: protocol.DataTypeLogin,
+			Name: "GitHub",
+			Data: mustMarshal(t, map[string]string{"login": "octocat", "password": "hunter2"}),
+		},
+		{
+			ID:   "2",
+			Type: protocol.DataTypeCard,
+			Name: "Work card",
+			Data: mustMarshal(t, map[string]string{"holder": "Jane Octocat", "number": "4111"}),
+		},
+		{
+			ID:   "3",
+			Type: protocol.DataTypeText,
+			Name: "Recovery note",
+			Data: mustMarshal(t, map[string]string{"text": "contact octocat support"}),
+		},
+		{
+			ID:   "4",
+			Type: protocol.DataTypeLogin,
+			Name: "Unrelated",
+			Data: mustMarshal(t, map[string]string{"login": "someone", "password": "x"}),
+		},
+	}
+
+	matches := SearchDecrypted(items, "octocat")
+	if len(matches) != 3 {
+		t.Fatalf("expected 3 matches, got %d: %+v", len(matches), matches)
+	}
+
+	byID := map[string]bool{}
+	for _, m := range matches {
+		byID[m.ID] = true
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if !byID[id] {
+			t.Errorf("expected item %s to match", id)
+		}
+	}
+	if byID["4"] {
+		t.Errorf("item 4 should not have matched")
+	}
+}
+
+func TestSearchDecryptedMatchesName(t *testing.T) {
+	items := []protocol.DataItem{
+		{ID: "1", Type: protocol.DataTypeText, Name: "Wifi password", Data: mustMarshal(t, map[string]string{"text": "unrelated"})},
+	}
+
+	matches := SearchDecrypted(items, "wifi")
+	if len(matches) != 1 {
+		t.Fatalf("expected name match, got %d matches", len(matches))
+	}
+}
+
+func TestSearchDecryptedNoMatch(t *testing.T) {
+	items := []protocol.DataItem{
+		{ID: "1", Type: protocol.DataTypeLogin, Name: "GitHub", Data: mustMarshal(t, map[string]string{"login": "octocat"})},
+	}
+
+	if matches := SearchDecrypted(items, "nonexistent"); len(matches) != 0 {
+		t.Fatalf("expected no matches, got %d", len(matches))
+	}
+}
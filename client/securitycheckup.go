@@ -0,0 +1,78 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// minPasswordStrengthBits is the entropy below which a login's password
+// is considered weak enough to flag for rotation.
+const minPasswordStrengthBits = 50
+
+// maxPasswordAge is how long a login's password can go without changing
+// before it's flagged as old, regardless of its strength.
+const maxPasswordAge = 180 * 24 * time.Hour
+
+// needsPasswordRotation reports whether a login item's password should
+// be flagged in a security checkup: either it's too weak, or it hasn't
+// been changed since before now-maxPasswordAge. updatedAt is the item's
+// last-modified time, the closest proxy this codebase has for "when the
+// password was last set" (RegenerateLoginPassword, like any other
+// change, bumps it via UpdateData).
+func needsPasswordRotation(password string, updatedAt, now time.Time) bool {
+	if crypto.EstimatePasswordStrengthBits(password) < minPasswordStrengthBits {
+		return true
+	}
+	return now.Sub(updatedAt) > maxPasswordAge
+}
+
+// SecurityCheckupResult reports one login item's outcome in a
+// SecurityCheckup pass.
+type SecurityCheckupResult struct {
+	ItemID  string
+	Name    string
+	Rotated bool
+	Skipped bool
+	Error   error
+}
+
+// SecurityCheckup syncs the caller's logins, finds the ones whose
+// password needsPasswordRotation, and calls decide for each one so the
+// caller (typically the UI) can show it and ask whether to regenerate it
+// now. decide returning false skips that item without touching it.
+func (c *Client) SecurityCheckup(decide func(item protocol.DataItem, login Login) bool) ([]SecurityCheckupResult, error) {
+	items, err := c.FullResync()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var results []SecurityCheckupResult
+	for _, item := range items {
+		if item.Type != protocol.DataTypeLogin {
+			continue
+		}
+		var login Login
+		if err := json.Unmarshal(item.Data, &login); err != nil {
+			continue
+		}
+		if !needsPasswordRotation(login.Password, item.UpdatedAt, now) {
+			continue
+		}
+
+		if !decide(item, login) {
+			results = append(results, SecurityCheckupResult{ItemID: item.ID, Name: item.Name, Skipped: true})
+			continue
+		}
+
+		if _, err := c.RegenerateLoginPassword(item.ID); err != nil {
+			results = append(results, SecurityCheckupResult{ItemID: item.ID, Name: item.Name, Error: err})
+			continue
+		}
+		results = append(results, SecurityCheckupResult{ItemID: item.ID, Name: item.Name, Rotated: true})
+	}
+	return results, nil
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestSecurityCheckupRotatesWeakPasswordWhenAccepted(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{conn: clientConn, masterKey: masterKey}
+
+	weakLogin, err := json.Marshal(Login{Username: "alice", Password: "abc123"})
+	if err != nil {
+		t.Fatalf("marshal login: %v", err)
+	}
+	encryptedWeak, err := crypto.Encrypt(weakLogin, masterKey)
+	if err != nil {
+		t.Fatalf("encrypt login: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		// SyncRequest -> one weak login item.
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		syncResp, _ := json.Marshal(protocol.SyncResponse{
+			Items: []protocol.DataItem{{
+				ID:        "login-1",
+				Type:      protocol.DataTypeLogin,
+				Name:      "Example",
+				Data:      encryptedWeak,
+				UpdatedAt: time.Now(),
+			}},
+		})
+		if err := writeFramedResponse(serverConn, protocol.MsgTypeSyncResponse, msgID, syncResp); err != nil {
+			done <- err
+			return
+		}
+
+		// RegenerateLoginPassword's GetData lookup.
+		_, msgID, _, err = readFramedRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		getResp, _ := json.Marshal(protocol.GetDataResponse{Item: protocol.DataItem{
+			ID: "login-1", Type: protocol.DataTypeLogin, Name: "Example", Data: encryptedWeak,
+		}})
+		if err := writeFramedResponse(serverConn, protocol.MsgTypeGetDataResponse, msgID, getResp); err != nil {
+			done <- err
+			return
+		}
+
+		// RegenerateLoginPassword's UpdateData call.
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		var updateReq protocol.UpdateDataRequest
+		if err := json.Unmarshal(payload, &updateReq); err != nil {
+			done <- err
+			return
+		}
+		if updateReq.Item.Type != protocol.DataTypeLogin {
+			t.Errorf("expected the update to keep the item's login type, got %d", updateReq.Item.Type)
+		}
+		updateResp, _ := json.Marshal(protocol.UpdateDataResponse{})
+		done <- writeFramedResponse(serverConn, protocol.MsgTypeUpdateDataResponse, msgID, updateResp)
+	}()
+
+	accepted := false
+	results, err := c.SecurityCheckup(func(item protocol.DataItem, login Login) bool {
+		accepted = true
+		if item.ID != "login-1" || login.Username != "alice" {
+			t.Errorf("unexpected item passed to decide: %+v %+v", item, login)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("SecurityCheckup: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("server goroutine: %v", err)
+	}
+	if !accepted {
+		t.Fatal("expected decide to be called for the weak login")
+	}
+	if len(results) != 1 || !results[0].Rotated {
+		t.Fatalf("expected one rotated result, got %+v", results)
+	}
+}
+
+func TestSecurityCheckupSkipsWhenDeclined(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{conn: clientConn, masterKey: masterKey}
+
+	weakLogin, err := json.Marshal(Login{Username: "alice", Password: "abc123"})
+	if err != nil {
+		t.Fatalf("marshal login: %v", err)
+	}
+	encryptedWeak, err := crypto.Encrypt(weakLogin, masterKey)
+	if err != nil {
+		t.Fatalf("encrypt login: %v", err)
+	}
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		syncResp, _ := json.Marshal(protocol.SyncResponse{
+			Items: []protocol.DataItem{{
+				ID:        "login-1",
+				Type:      protocol.DataTypeLogin,
+				Name:      "Example",
+				Data:      encryptedWeak,
+				UpdatedAt: time.Now(),
+			}},
+		})
+		writeFramedResponse(serverConn, protocol.MsgTypeSyncResponse, msgID, syncResp)
+	}()
+
+	results, err := c.SecurityCheckup(func(item protocol.DataItem, login Login) bool {
+		return false
+	})
+	if err != nil {
+		t.Fatalf("SecurityCheckup: %v", err)
+	}
+	if len(results) != 1 || !results[0].Skipped {
+		t.Fatalf("expected one skipped result, got %+v", results)
+	}
+}
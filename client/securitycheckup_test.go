@@ -0,0 +1,62 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNeedsPasswordRotation(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		password  string
+		updatedAt time.Time
+		want      bool
+	}{
+		{
+			name:      "strong and recent",
+			password:  "correct-horse-battery-staple-1!",
+			updatedAt: now,
+			want:      false,
+		},
+		{
+			name:      "weak and recent",
+			password:  "abc123",
+			updatedAt: now,
+			want:      true,
+		},
+		{
+			name:      "strong but old",
+			password:  "correct-horse-battery-staple-1!",
+			updatedAt: now.Add(-maxPasswordAge - 24*time.Hour),
+			want:      true,
+		},
+		{
+			name:      "weak and old",
+			password:  "abc123",
+			updatedAt: now.Add(-maxPasswordAge - 24*time.Hour),
+			want:      true,
+		},
+		{
+			name:      "strong and just under the age limit",
+			password:  "correct-horse-battery-staple-1!",
+			updatedAt: now.Add(-maxPasswordAge + time.Hour),
+			want:      false,
+		},
+		{
+			name:      "empty password",
+			password:  "",
+			updatedAt: now,
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needsPasswordRotation(tt.password, tt.updatedAt, now); got != tt.want {
+				t.Errorf("needsPasswordRotation(%q, ...) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// TestShowDataSelectsItemPastFirstPageByFullIndex verifies that entering
+// an index beyond the current page (e.g. 11 in a 15-item vault) selects
+// the correct underlying item, not just any item shown on the first
+// page.
+func TestShowDataSelectsItemPastFirstPageByFullIndex(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+
+	const itemCount = 15
+	items := make([]protocol.DataItem, itemCount)
+	for i := range items {
+		text := fmt.Sprintf(`{"text":"item %d"}`, i+1)
+		encrypted, err := crypto.Encrypt([]byte(text), masterKey)
+		if err != nil {
+			t.Fatalf("encrypt item %d: %v", i, err)
+		}
+		items[i] = protocol.DataItem{
+			ID:   fmt.Sprintf("item-%d", i+1),
+			Type: protocol.DataTypeText,
+			Name: fmt.Sprintf("Item %d", i+1),
+			Data: encrypted,
+		}
+	}
+
+	prompter := newBufferPrompter(strings.Join([]string{
+		"11", // select the 11th item by its full index, past page 1
+		"0",  // copyFieldMenu: cancel
+		"n",  // manage attachments: no
+		"n",  // edit metadata: no
+		"n",  // edit notes: no
+	}, "\n") + "\n")
+
+	u := &UIClient{
+		prompter: prompter,
+		client:   &Client{conn: clientConn, masterKey: masterKey},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		var req protocol.SyncRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			done <- err
+			return
+		}
+		resp, err := json.Marshal(protocol.SyncResponse{Items: items})
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- writeFramedResponse(serverConn, protocol.MsgTypeSyncResponse, msgID, resp)
+	}()
+
+	u.showData()
+
+	if err := <-done; err != nil {
+		t.Fatalf("server: %v", err)
+	}
+
+	out := prompter.out.String()
+	if !strings.Contains(out, "Название: Item 11\n") {
+		t.Fatalf("expected details for item 11 to be shown, got output: %q", out)
+	}
+	if strings.Count(out, "Название:") != 1 {
+		t.Fatalf("expected exactly one item's details to be shown, got output: %q", out)
+	}
+}
+
+// TestShowDataRejectsIndexBeyondVaultSize verifies that an index past
+// the last item is rejected with a clear message instead of panicking
+// or silently wrapping around.
+func TestShowDataRejectsIndexBeyondVaultSize(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	encrypted, err := crypto.Encrypt([]byte(`{"text":"only item"}`), masterKey)
+	if err != nil {
+		t.Fatalf("encrypt item: %v", err)
+	}
+	items := []protocol.DataItem{{ID: "item-1", Type: protocol.DataTypeText, Name: "Only item", Data: encrypted}}
+
+	prompter := newBufferPrompter(strings.Join([]string{
+		"5", // out of range: only one item exists
+		"0", // cancel
+	}, "\n") + "\n")
+
+	u := &UIClient{
+		prompter: prompter,
+		client:   &Client{conn: clientConn, masterKey: masterKey},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		var req protocol.SyncRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			done <- err
+			return
+		}
+		resp, err := json.Marshal(protocol.SyncResponse{Items: items})
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- writeFramedResponse(serverConn, protocol.MsgTypeSyncResponse, msgID, resp)
+	}()
+
+	u.showData()
+
+	if err := <-done; err != nil {
+		t.Fatalf("server: %v", err)
+	}
+
+	out := prompter.out.String()
+	if !strings.Contains(out, "Неверный номер записи") {
+		t.Fatalf("expected a clear rejection message for an out-of-range index, got: %q", out)
+	}
+	if strings.Contains(out, "Только item") {
+		t.Fatalf("expected no item details to be shown, got: %q", out)
+	}
+}
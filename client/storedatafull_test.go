@@ -0,0 +1,77 @@
+package client
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// TestStoreDataFullReturnsServerAssignedFields verifies that
+// StoreDataFull decrypts and returns the server's echoed DataItem,
+// including its ID and timestamps, rather than just the ID.
+func TestStoreDataFullReturnsServerAssignedFields(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{conn: clientConn, masterKey: masterKey}
+
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	done := make(chan error, 1)
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		var req protocol.StoreDataRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			done <- err
+			return
+		}
+		resp, err := json.Marshal(protocol.StoreDataResponse{
+			ID: "item-42",
+			Item: protocol.DataItem{
+				ID:        "item-42",
+				Type:      req.Item.Type,
+				Name:      req.Item.Name,
+				Data:      req.Item.Data,
+				Metadata:  req.Item.Metadata,
+				CreatedAt: createdAt,
+				UpdatedAt: createdAt,
+			},
+		})
+		if err != nil {
+			done <- err
+			return
+		}
+		done <- writeFramedResponse(serverConn, protocol.MsgTypeStoreDataResponse, msgID, resp)
+	}()
+
+	item, err := c.StoreDataFull(protocol.NewDataItem{
+		Type: protocol.DataTypeText,
+		Name: "note",
+		Data: []byte("hello"),
+	})
+	if err != nil {
+		t.Fatalf("StoreDataFull: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("server goroutine: %v", err)
+	}
+
+	if item.ID != "item-42" {
+		t.Fatalf("ID = %q, want item-42", item.ID)
+	}
+	if !item.CreatedAt.Equal(createdAt) || !item.UpdatedAt.Equal(createdAt) {
+		t.Fatalf("expected the server's timestamps to come through, got created=%v updated=%v", item.CreatedAt, item.UpdatedAt)
+	}
+	if string(item.Data) != "hello" {
+		t.Fatalf("Data = %q, want %q", item.Data, "hello")
+	}
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestSyncStreamWithZeroItems(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	c := &Client{conn: clientConn, masterKey: []byte("0123456789abcdef0123456789abcdef")}
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		begin, _ := json.Marshal(protocol.SyncBeginResponse{Count: 0})
+		if err := writeFramedResponse(serverConn, protocol.MsgTypeSyncBegin, msgID, begin); err != nil {
+			return
+		}
+		end, _ := json.Marshal(protocol.SyncEndResponse{})
+		writeFramedResponse(serverConn, protocol.MsgTypeSyncEnd, msgID, end)
+	}()
+
+	var calls int
+	err := c.SyncStream(time.Time{}, func(protocol.DataItem) error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SyncStream: %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("expected no callback invocations, got %d", calls)
+	}
+}
+
+func TestSyncStreamWithSeveralItems(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{conn: clientConn, masterKey: masterKey}
+
+	names := []string{"one", "two", "three"}
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		begin, _ := json.Marshal(protocol.SyncBeginResponse{Count: len(names)})
+		if err := writeFramedResponse(serverConn, protocol.MsgTypeSyncBegin, msgID, begin); err != nil {
+			return
+		}
+		for i, name := range names {
+			encryptedData, err := crypto.Encrypt([]byte(`{"text":"`+name+`"}`), masterKey)
+			if err != nil {
+				return
+			}
+			item, _ := json.Marshal(protocol.SyncItemResponse{Item: protocol.DataItem{
+				ID:   name,
+				Type: protocol.DataTypeText,
+				Data: encryptedData,
+			}})
+			if err := writeFramedResponse(serverConn, protocol.MsgTypeSyncItem, msgID, item); err != nil {
+				return
+			}
+			_ = i
+		}
+		end, _ := json.Marshal(protocol.SyncEndResponse{})
+		writeFramedResponse(serverConn, protocol.MsgTypeSyncEnd, msgID, end)
+	}()
+
+	var got []string
+	err := c.SyncStream(time.Time{}, func(item protocol.DataItem) error {
+		got = append(got, item.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("SyncStream: %v", err)
+	}
+	if len(got) != len(names) {
+		t.Fatalf("expected %d items, got %d (%v)", len(names), len(got), got)
+	}
+	for i, name := range names {
+		if got[i] != name {
+			t.Fatalf("item %d: expected %q, got %q", i, name, got[i])
+		}
+	}
+}
+
+func TestSyncStreamAbortsOnCallbackError(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	masterKey := []byte("0123456789abcdef0123456789abcdef")
+	c := &Client{conn: clientConn, masterKey: masterKey}
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		begin, _ := json.Marshal(protocol.SyncBeginResponse{Count: 3})
+		if err := writeFramedResponse(serverConn, protocol.MsgTypeSyncBegin, msgID, begin); err != nil {
+			return
+		}
+		for i := 0; i < 3; i++ {
+			encryptedData, err := crypto.Encrypt([]byte(`{"text":"x"}`), masterKey)
+			if err != nil {
+				return
+			}
+			item, _ := json.Marshal(protocol.SyncItemResponse{Item: protocol.DataItem{
+				ID:   "item",
+				Type: protocol.DataTypeText,
+				Data: encryptedData,
+			}})
+			if err := writeFramedResponse(serverConn, protocol.MsgTypeSyncItem, msgID, item); err != nil {
+				return
+			}
+		}
+	}()
+
+	wantErr := errors.New("stop here")
+	var calls int
+	err := c.SyncStream(time.Time{}, func(protocol.DataItem) error {
+		calls++
+		if calls == 1 {
+			return wantErr
+		}
+		return nil
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("expected %v, got %v", wantErr, err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected exactly 1 callback invocation, got %d", calls)
+	}
+}
+
+func TestSyncStreamReturnsBeginError(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	c := &Client{conn: clientConn, masterKey: []byte("0123456789abcdef0123456789abcdef")}
+
+	go func() {
+		_, msgID, _, err := readFramedRequest(serverConn)
+		if err != nil {
+			return
+		}
+		begin, _ := json.Marshal(protocol.SyncBeginResponse{Error: "not authenticated"})
+		writeFramedResponse(serverConn, protocol.MsgTypeSyncBegin, msgID, begin)
+	}()
+
+	err := c.SyncStream(time.Time{}, func(protocol.DataItem) error {
+		t.Fatal("callback should not be invoked when SyncBegin carries an error")
+		return nil
+	})
+	if err == nil || err.Error() != "not authenticated" {
+		t.Fatalf("expected 'not authenticated' error, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// renderItemTable formats items as a tab-aligned table showing only
+// metadata safe to display without decrypting anything: name, type,
+// creation/update times, size, tags, and favorite status. Size falls
+// back to the raw (still-encrypted) Data length when the item carries no
+// original-size metadata. Tags and favorite status are optional
+// metadata that most items won't have, and render as "-" when absent.
+func renderItemTable(items []protocol.DataItem) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "Название\tТип\tСоздано\tИзменено\tРазмер\tТеги\tИзбранное")
+	for _, item := range items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			sanitizeForDisplay(item.Name),
+			getDataTypeName(item.Type),
+			item.CreatedAt.Format("2006-01-02 15:04"),
+			item.UpdatedAt.Format("2006-01-02 15:04"),
+			itemDisplaySize(item),
+			itemDisplayOrDash(item.Metadata[protocol.MetaTags]),
+			itemDisplayOrDash(item.Metadata[protocol.MetaFavorite]),
+		)
+	}
+
+	w.Flush()
+	return b.String()
+}
+
+// itemDisplaySize reports item's size in bytes: the original file size
+// if recorded in metadata, otherwise the length of Data as stored
+// (ciphertext for an undecrypted item, so it's an upper bound rather
+// than the plaintext's exact size).
+func itemDisplaySize(item protocol.DataItem) string {
+	if raw, ok := item.Metadata[protocol.MetaOriginalSize]; ok {
+		if size, err := strconv.Atoi(raw); err == nil {
+			return strconv.Itoa(size)
+		}
+	}
+	return strconv.Itoa(len(item.Data))
+}
+
+// itemDisplayOrDash returns s, or "-" if s is empty, so optional columns
+// in the table don't render as blank cells.
+func itemDisplayOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return sanitizeForDisplay(s)
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestRenderItemTableIncludesHeaderAndRows(t *testing.T) {
+	items := []protocol.DataItem{
+		{
+			Name:      "GitHub",
+			Type:      protocol.DataTypeLogin,
+			Data:      []byte(`{"login":"x","password":"y"}`),
+			CreatedAt: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+			UpdatedAt: time.Date(2026, 1, 2, 11, 30, 0, 0, time.UTC),
+			Metadata:  map[string]string{protocol.MetaTags: "work,dev", protocol.MetaFavorite: "true"},
+		},
+	}
+
+	out := renderItemTable(items)
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a header row and one data row, got %d lines: %q", len(lines), out)
+	}
+	if !strings.Contains(lines[0], "Название") {
+		t.Fatalf("expected a header row, got %q", lines[0])
+	}
+	row := lines[1]
+	for _, want := range []string{"GitHub", "2026-01-01 10:00", "2026-01-02 11:30", "work,dev", "true"} {
+		if !strings.Contains(row, want) {
+			t.Errorf("expected row to contain %q, got %q", want, row)
+		}
+	}
+}
+
+// TestRenderItemTableHandlesMissingOptionalFields verifies that an item
+// with no tags or favorite metadata renders "-" placeholders instead of
+// blank cells, and that its size falls back to the raw Data length when
+// no original-size metadata is recorded.
+func TestRenderItemTableHandlesMissingOptionalFields(t *testing.T) {
+	items := []protocol.DataItem{
+		{
+			Name: "Untitled note",
+			Type: protocol.DataTypeText,
+			Data: []byte(`{"text":"hi"}`),
+		},
+	}
+
+	out := renderItemTable(items)
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a header row and one data row, got %d lines: %q", len(lines), out)
+	}
+
+	fields := strings.Fields(lines[1])
+	dashCount := 0
+	for _, f := range fields {
+		if f == "-" {
+			dashCount++
+		}
+	}
+	if dashCount != 2 {
+		t.Fatalf("expected 2 dash placeholders for missing tags/favorite, got %d in row %q", dashCount, lines[1])
+	}
+	if !strings.Contains(lines[1], "13") {
+		t.Fatalf("expected the fallback size (len of Data, 13 bytes) in row %q", lines[1])
+	}
+}
+
+func TestRenderItemTableEmptyItemsProducesOnlyHeader(t *testing.T) {
+	out := renderItemTable(nil)
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected only the header row for no items, got %q", out)
+	}
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// generateSelfSignedCert returns a tls.Certificate and the SHA-256
+// fingerprint of its leaf, for exercising certificate pinning without a
+// real CA.
+func generateSelfSignedCert(t *testing.T) (tls.Certificate, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Unix(0, 0),
+		NotAfter:     time.Unix(0, 0).Add(24 * time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+
+	fingerprint := sha256.Sum256(derBytes)
+
+	cert := tls.Certificate{
+		Certificate: [][]byte{derBytes},
+		PrivateKey:  key,
+	}
+	return cert, hex.EncodeToString(fingerprint[:])
+}
+
+func TestConnectTLSAcceptsMatchingPin(t *testing.T) {
+	cert, fingerprint := generateSelfSignedCert(t)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.(*tls.Conn).Handshake()
+	}()
+
+	c := NewClient("tls://" + listener.Addr().String())
+	c.PinnedCertSHA256 = fingerprint
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect with matching pin: %v", err)
+	}
+	c.Close()
+}
+
+func TestConnectTLSRejectsMismatchedPin(t *testing.T) {
+	cert, _ := generateSelfSignedCert(t)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.(*tls.Conn).Handshake()
+	}()
+
+	c := NewClient("tls://" + listener.Addr().String())
+	c.PinnedCertSHA256 = "0000000000000000000000000000000000000000000000000000000000000000"
+	if err := c.Connect(); err == nil {
+		t.Fatal("expected Connect to fail with a mismatched pin, got nil error")
+	}
+}
@@ -0,0 +1,1403 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// maxItemNameLength mirrors the server's DefaultMaxNameLength, so a name
+// that's too long is rejected here instead of round-tripping to the
+// server first.
+const maxItemNameLength = 256
+
+// UIClient drives the interactive terminal menu on top of a Client.
+type UIClient struct {
+	client   *Client
+	prompter Prompter
+	items    []protocol.DataItem
+
+	// PinnedCertSHA256, if set, is applied to the underlying Client once
+	// the user enters a "tls://" server address.
+	PinnedCertSHA256 string
+
+	// VaultTimeout, if positive, requires the master password to be
+	// re-entered before showItemDetails reveals an item's secrets if
+	// more than VaultTimeout has passed since the last reveal. Zero
+	// disables the check.
+	VaultTimeout time.Duration
+	lastReveal   time.Time
+
+	// RecentlyViewedPath, if set, persists the "recently viewed" item
+	// list (see RecencyList) across runs at this file path. Empty
+	// disables persistence: the list still works within a single
+	// session, it just starts empty each run.
+	RecentlyViewedPath string
+	recentlyViewed     *RecencyList
+
+	// FaviconCacheDir, if set, enables fetching and caching a small
+	// favicon for login items with a URL, whose local path is shown
+	// alongside the item in showData's list view. Empty disables the
+	// feature entirely, so a user never triggers an outbound request
+	// just by opening the vault.
+	FaviconCacheDir string
+	faviconCache    *FaviconCache
+}
+
+// favicons lazily creates u's FaviconCache the first time it's needed,
+// returning nil if FaviconCacheDir isn't set.
+func (u *UIClient) favicons() *FaviconCache {
+	if u.FaviconCacheDir == "" {
+		return nil
+	}
+	if u.faviconCache == nil {
+		u.faviconCache = NewFaviconCache(u.FaviconCacheDir)
+	}
+	return u.faviconCache
+}
+
+// needsReauth reports whether more than timeout has elapsed since last,
+// or last is the zero time (nothing has been revealed yet this
+// session). timeout <= 0 disables the check.
+func needsReauth(last time.Time, timeout time.Duration) bool {
+	if timeout <= 0 {
+		return false
+	}
+	if last.IsZero() {
+		return true
+	}
+	return time.Since(last) > timeout
+}
+
+// NewUIClient creates a UIClient reading from standard input.
+func NewUIClient() *UIClient {
+	return &UIClient{prompter: newStdioPrompter()}
+}
+
+// NewUIClientWithPrompter creates a UIClient driven by prompter instead
+// of standard input, so tests can script an interaction without a real
+// terminal.
+func NewUIClientWithPrompter(prompter Prompter) *UIClient {
+	return &UIClient{prompter: prompter}
+}
+
+// Run prompts for a server address, connects, and enters the main menu
+// loop.
+func (u *UIClient) Run() {
+	addr := u.promptServerAddress()
+	u.client = NewClient(addr)
+	u.client.PinnedCertSHA256 = u.PinnedCertSHA256
+	u.diagnose()
+
+	for {
+		u.prompter.Printf("\n1. Регистрация\n")
+		u.prompter.Printf("2. Вход\n")
+		u.prompter.Printf("3. Выход\n")
+		u.prompter.Printf("Выбор: ")
+		switch u.readLine() {
+		case "1":
+			u.handleRegistration()
+		case "2":
+			if u.handleLogin() {
+				u.mainMenu()
+			}
+		case "3":
+			return
+		default:
+			u.prompter.Printf("Неверный выбор\n")
+		}
+	}
+}
+
+// promptServerAddress prompts for a server address, re-prompting until
+// it parses. A "unix://" address is passed through as-is (it names a
+// socket path, not a host:port pair); a "tls://" address has its
+// host:port portion validated and normalized the same as a plain
+// address, with the prefix preserved.
+func (u *UIClient) promptServerAddress() string {
+	for {
+		u.prompter.Printf("Адрес сервера (host:port или unix:///path/to.sock) [localhost:8080]: ")
+		addr := u.readLine()
+		if addr == "" {
+			addr = "localhost:8080"
+		}
+		if strings.HasPrefix(addr, unixSocketPrefix) {
+			return addr
+		}
+
+		hostPort := strings.TrimPrefix(addr, tlsPrefix)
+		host, portStr, err := net.SplitHostPort(hostPort)
+		if err != nil {
+			u.prompter.Printf("Неверный адрес: %v\n", err)
+			continue
+		}
+		host, port, err := parseHostPort(host, portStr)
+		if err != nil {
+			u.prompter.Printf("Неверный адрес: %v\n", err)
+			continue
+		}
+
+		normalized := net.JoinHostPort(host, strconv.Itoa(port))
+		if strings.HasPrefix(addr, tlsPrefix) {
+			normalized = tlsPrefix + normalized
+		}
+		return normalized
+	}
+}
+
+func (u *UIClient) readLine() string {
+	return u.prompter.ReadLine()
+}
+
+func (u *UIClient) handleRegistration() {
+	u.prompter.Printf("Имя пользователя: ")
+	username := u.readLine()
+	u.prompter.Printf("Пароль: ")
+	password := u.prompter.ReadPassword()
+
+	if err := u.client.Register(username, password); err != nil {
+		u.prompter.Printf("Ошибка регистрации: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Регистрация успешна, теперь можно войти.\n")
+}
+
+func (u *UIClient) handleLogin() bool {
+	u.prompter.Printf("Имя пользователя: ")
+	username := u.readLine()
+	u.prompter.Printf("Пароль: ")
+	password := u.prompter.ReadPassword()
+
+	if err := u.client.Login(username, password); err != nil {
+		u.prompter.Printf("Ошибка входа: %v\n", err)
+		return false
+	}
+	u.prompter.Printf("Вход выполнен.\n")
+	if ageDays, due := u.client.PasswordRotationStatus(); due {
+		u.prompter.Printf("Вашему мастер-паролю %d дней, рекомендуется его сменить.\n", ageDays)
+	}
+	return true
+}
+
+func (u *UIClient) mainMenu() {
+	for {
+		u.prompter.Printf("\n1. Добавить запись\n")
+		u.prompter.Printf("2. Показать записи\n")
+		u.prompter.Printf("3. Поиск по содержимому\n")
+		u.prompter.Printf("4. Статистика\n")
+		u.prompter.Printf("5. Удалить запись\n")
+		u.prompter.Printf("6. Экспорт в CSV\n")
+		u.prompter.Printf("7. Импорт из CSV\n")
+		u.prompter.Printf("8. Изменения за период\n")
+		u.prompter.Printf("9. Таблица записей (без расшифровки)\n")
+		u.prompter.Printf("10. Принудительная полная пересинхронизация\n")
+		u.prompter.Printf("11. Проверить пароли по локальному списку утечек\n")
+		u.prompter.Printf("12. Задержка соединения\n")
+		u.prompter.Printf("13. Выход из аккаунта\n")
+		u.prompter.Printf("14. Массовое удаление\n")
+		u.prompter.Printf("15. Недавно просмотренные\n")
+		u.prompter.Printf("16. Заблокировать хранилище\n")
+		u.prompter.Printf("17. Проверка безопасности (ротация паролей)\n")
+		u.prompter.Printf("Выбор: ")
+		switch u.readLine() {
+		case "1":
+			u.createNewItem()
+		case "2":
+			u.showData()
+		case "3":
+			u.searchItems()
+		case "4":
+			u.showStats()
+		case "5":
+			u.deleteItem()
+		case "6":
+			u.exportCSV()
+		case "7":
+			u.importCSV()
+		case "8":
+			u.showModified()
+		case "9":
+			u.showItemTable()
+		case "10":
+			u.forceFullResync()
+		case "11":
+			u.checkBreached()
+		case "12":
+			u.showLatency()
+		case "13":
+			return
+		case "14":
+			u.batchDeleteItems()
+		case "15":
+			u.showRecentlyViewed()
+		case "16":
+			u.lock()
+			return
+		case "17":
+			u.securityCheckup()
+		default:
+			u.prompter.Printf("Неверный выбор\n")
+		}
+	}
+}
+
+// promptLoginFields prompts for a login item's fields and returns the
+// payload to store, plus any metadata (a validated URL, if one was
+// entered).
+func (u *UIClient) promptLoginFields() (map[string]string, map[string]string) {
+	u.prompter.Printf("Логин: ")
+	login := u.readLine()
+	u.prompter.Printf("Пароль (пусто — сгенерировать пароль): ")
+	password := u.readLine()
+	if password == "" {
+		generated, err := u.generatePassword()
+		if err != nil {
+			u.prompter.Printf("Ошибка генерации пароля: %v\n", err)
+		} else {
+			password = generated
+			u.prompter.Printf("Сгенерированный пароль: %s\n", password)
+		}
+	}
+	payload := map[string]string{protocol.FieldLogin: login, protocol.FieldPassword: password}
+
+	metadata := map[string]string{}
+	u.prompter.Printf("URL (необязательно): ")
+	if rawURL := u.readLine(); rawURL != "" {
+		if err := validateURL(rawURL); err != nil {
+			u.prompter.Printf("Ошибка: %v, URL не сохранён\n", err)
+		} else {
+			metadata[protocol.MetaURL] = rawURL
+		}
+	}
+	return payload, metadata
+}
+
+// generatePassword generates a password for a login item, defaulting to a
+// diceware-style passphrase unless the user asks for advanced options
+// (character classes, length, excluded characters, a required character
+// set), for sites that reject certain symbols or demand a specific class.
+func (u *UIClient) generatePassword() (string, error) {
+	u.prompter.Printf("Расширенные параметры генерации? (y/n, пусто — фраза-пароль по умолчанию): ")
+	if !strings.EqualFold(u.readLine(), "y") {
+		return crypto.GeneratePassphrase(6, "-")
+	}
+
+	opts := crypto.PasswordOptions{Length: 16}
+	u.prompter.Printf("Длина пароля (пусто — 16): ")
+	if raw := u.readLine(); raw != "" {
+		length, err := strconv.Atoi(raw)
+		if err != nil {
+			return "", fmt.Errorf("неверная длина: %w", err)
+		}
+		opts.Length = length
+	}
+
+	u.prompter.Printf("Строчные буквы? (Y/n): ")
+	opts.UseLower = !strings.EqualFold(u.readLine(), "n")
+	u.prompter.Printf("Заглавные буквы? (Y/n): ")
+	opts.UseUpper = !strings.EqualFold(u.readLine(), "n")
+	u.prompter.Printf("Цифры? (Y/n): ")
+	opts.UseDigits = !strings.EqualFold(u.readLine(), "n")
+	u.prompter.Printf("Символы? (Y/n): ")
+	opts.UseSymbols = !strings.EqualFold(u.readLine(), "n")
+
+	u.prompter.Printf("Исключить символы (необязательно): ")
+	opts.ExcludeChars = u.readLine()
+	u.prompter.Printf("Обязательный набор символов (необязательно): ")
+	opts.RequireFromSet = u.readLine()
+
+	return crypto.GeneratePassword(opts)
+}
+
+// promptCardFields prompts for a card item's fields and returns the
+// payload to store; cards carry no metadata.
+func (u *UIClient) promptCardFields() (map[string]string, map[string]string) {
+	u.prompter.Printf("Номер карты: ")
+	number := u.readLine()
+	u.prompter.Printf("Срок действия (MM/YY): ")
+	expiry := u.readLine()
+	if expiresAt, err := ParseExpiry(expiry); err != nil {
+		u.prompter.Printf("Предупреждение: %v\n", err)
+	} else if time.Now().After(expiresAt) {
+		u.prompter.Printf("Предупреждение: срок действия карты уже истёк\n")
+	}
+	u.prompter.Printf("CVV: ")
+	cvv := u.readLine()
+	u.prompter.Printf("Держатель карты: ")
+	holder := u.readLine()
+	return map[string]string{
+		protocol.FieldCardNumber: number,
+		protocol.FieldCardExpiry: expiry,
+		protocol.FieldCardCVV:    cvv,
+		protocol.FieldCardHolder: holder,
+	}, map[string]string{}
+}
+
+// promptTextFields prompts for a free-text item's field and returns the
+// payload to store; text items carry no metadata.
+func (u *UIClient) promptTextFields() (map[string]string, map[string]string) {
+	u.prompter.Printf("Текст: ")
+	text := u.readLine()
+	return map[string]string{protocol.FieldText: text}, map[string]string{}
+}
+
+// promptWiFiFields prompts for a WiFi network's fields and returns the
+// payload to store; WiFi items carry no metadata.
+func (u *UIClient) promptWiFiFields() (map[string]string, map[string]string) {
+	u.prompter.Printf("Имя сети (SSID): ")
+	ssid := u.readLine()
+	u.prompter.Printf("Пароль: ")
+	password := u.readLine()
+	u.prompter.Printf("Тип защиты (WPA/WEP/nopass) [WPA]: ")
+	security := u.readLine()
+	if security == "" {
+		security = "WPA"
+	}
+	return map[string]string{
+		protocol.FieldWiFiSSID:         ssid,
+		protocol.FieldWiFiPassword:     password,
+		protocol.FieldWiFiSecurityType: security,
+	}, map[string]string{}
+}
+
+// createNewItem prompts for and saves items in a loop, so entering many
+// records in a row doesn't mean returning to the main menu each time.
+// The previously chosen type becomes the default for the next item:
+// pressing Enter at the type prompt repeats it.
+func (u *UIClient) createNewItem() {
+	lastChoice := ""
+	for {
+		u.prompter.Printf("Тип записи: 1) Логин  2) Карта  3) Текст  4) Файл  5) WiFi\n")
+		if lastChoice != "" {
+			u.prompter.Printf("Выбор [%s]: ", lastChoice)
+		} else {
+			u.prompter.Printf("Выбор: ")
+		}
+		choice := u.readLine()
+		if choice == "" && lastChoice != "" {
+			choice = lastChoice
+		}
+
+		var dataType uint8
+		var payload, metadata map[string]string
+		switch choice {
+		case "1":
+			dataType = protocol.DataTypeLogin
+			payload, metadata = u.promptLoginFields()
+		case "2":
+			dataType = protocol.DataTypeCard
+			payload, metadata = u.promptCardFields()
+		case "3":
+			dataType = protocol.DataTypeText
+			payload, metadata = u.promptTextFields()
+		case "5":
+			dataType = protocol.DataTypeWiFi
+			payload, metadata = u.promptWiFiFields()
+		default:
+			u.prompter.Printf("Неверный выбор\n")
+			return
+		}
+		lastChoice = choice
+
+		u.prompter.Printf("Название записи: ")
+		name := u.readLine()
+		for len(name) > maxItemNameLength {
+			u.prompter.Printf("Название слишком длинное (максимум %d символов): ", maxItemNameLength)
+			name = u.readLine()
+		}
+
+		u.prompter.Printf("Заметка (необязательно): ")
+		notes := u.readLine()
+
+		data, err := json.Marshal(payload)
+		if err != nil {
+			u.prompter.Printf("Ошибка: %v\n", err)
+			return
+		}
+
+		item := protocol.NewDataItem{
+			Type:     dataType,
+			Name:     name,
+			Data:     data,
+			Notes:    []byte(notes),
+			Metadata: metadata,
+		}
+
+		id, err := u.client.StoreData(item)
+		if err != nil {
+			u.prompter.Printf("Ошибка сохранения: %v\n", err)
+			return
+		}
+		u.prompter.Printf("Запись сохранена, ID: %s\n", id)
+
+		u.prompter.Printf("Сохранить и добавить ещё одну запись? (y/n): ")
+		if !strings.EqualFold(u.readLine(), "y") {
+			return
+		}
+	}
+}
+
+// showDataPageSize is how many items showData lists per page.
+const showDataPageSize = 10
+
+// paginate returns the items on the given 1-indexed page, along with
+// how many pages the full list spans at pageSize items per page. A
+// page outside [1, totalPages] yields no items, but still reports the
+// correct totalPages, so a caller can detect and reject an out-of-range
+// page instead of paginate panicking on it. An empty items slice or a
+// non-positive pageSize likewise yields no items with totalPages 0.
+func paginate(items []protocol.DataItem, page, pageSize int) (pageItems []protocol.DataItem, totalPages int) {
+	if len(items) == 0 || pageSize <= 0 {
+		return nil, 0
+	}
+	totalPages = (len(items) + pageSize - 1) / pageSize
+	if page < 1 || page > totalPages {
+		return nil, totalPages
+	}
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[start:end], totalPages
+}
+
+// showData lists the user's items a page at a time and lets them pick
+// one to view by its full index (not just its position on the current
+// page), so a vault of any size stays navigable and every item remains
+// selectable.
+func (u *UIClient) showData() {
+	items, err := u.client.SyncData(time.Time{})
+	if err != nil {
+		u.prompter.Printf("Ошибка синхронизации: %v\n", err)
+		return
+	}
+	u.items = items
+
+	if len(items) == 0 {
+		u.prompter.Printf("Записей нет.\n")
+		return
+	}
+
+	cache := u.favicons()
+	page := 1
+	for {
+		pageItems, totalPages := paginate(items, page, showDataPageSize)
+		u.prompter.Printf("Страница %d из %d:\n", page, totalPages)
+		start := (page - 1) * showDataPageSize
+		for i, item := range pageItems {
+			favicon := ""
+			if cache != nil && item.Type == protocol.DataTypeLogin {
+				if rawURL := item.Metadata[protocol.MetaURL]; rawURL != "" {
+					if path, err := cache.Get(rawURL); err == nil {
+						favicon = " [" + path + "]"
+					}
+				}
+			}
+			u.prompter.Printf("%d. %s (%s)%s\n", start+i+1, sanitizeForDisplay(item.Name), getDataTypeName(item.Type), favicon)
+		}
+
+		u.prompter.Printf("Номер записи для просмотра, n - след. страница, p - пред. страница, g - перейти к странице, 0 - отмена: ")
+		switch choice := strings.ToLower(strings.TrimSpace(u.readLine())); choice {
+		case "0", "":
+			return
+		case "n":
+			if page < totalPages {
+				page++
+			}
+		case "p":
+			if page > 1 {
+				page--
+			}
+		case "g":
+			u.prompter.Printf("Номер страницы (1-%d): ", totalPages)
+			pageNum, err := strconv.Atoi(u.readLine())
+			if err == nil && pageNum >= 1 && pageNum <= totalPages {
+				page = pageNum
+			} else {
+				u.prompter.Printf("Неверный номер страницы\n")
+			}
+		default:
+			idx, err := strconv.Atoi(choice)
+			if err != nil || idx < 1 || idx > len(items) {
+				u.prompter.Printf("Неверный номер записи\n")
+				continue
+			}
+			u.showItemDetails(items[idx-1])
+			return
+		}
+	}
+}
+
+// forceFullResync discards any incremental progress and re-pulls every
+// item from the server, for when the local cache is suspected to be
+// corrupt or out of sync.
+func (u *UIClient) forceFullResync() {
+	items, err := u.client.FullResync()
+	if err != nil {
+		u.prompter.Printf("Ошибка синхронизации: %v\n", err)
+		return
+	}
+	u.items = items
+	u.prompter.Printf("Полная пересинхронизация завершена, записей: %d\n", len(items))
+}
+
+// checkBreached prompts for a local breached-hash file and reports which
+// stored login passwords appear in it. Nothing about the passwords or
+// their hashes ever leaves this process.
+func (u *UIClient) checkBreached() {
+	u.prompter.Printf("Путь к локальному файлу утечек: ")
+	path := u.readLine()
+	if path == "" {
+		return
+	}
+
+	results, err := u.client.CheckBreached(path)
+	if err != nil {
+		u.prompter.Printf("Ошибка проверки: %v\n", err)
+		return
+	}
+	if len(results) == 0 {
+		u.prompter.Printf("Совпадений не найдено.\n")
+		return
+	}
+	for _, r := range results {
+		if r.Count > 0 {
+			u.prompter.Printf("- %s: пароль встречается в утечках (%d раз)\n", sanitizeForDisplay(r.ItemName), r.Count)
+		} else {
+			u.prompter.Printf("- %s: пароль найден в списке утечек\n", sanitizeForDisplay(r.ItemName))
+		}
+	}
+}
+
+// securityCheckup guides the user through rotating every login whose
+// password client.SecurityCheckup flags as weak or old: it shows each
+// one and lets the user regenerate it or skip it, then reports a
+// summary of what happened.
+func (u *UIClient) securityCheckup() {
+	u.prompter.Printf("Синхронизация и проверка паролей...\n")
+	results, err := u.client.SecurityCheckup(func(item protocol.DataItem, login Login) bool {
+		u.prompter.Printf("\n%s (логин: %s): пароль слабый или устаревший.\n", sanitizeForDisplay(item.Name), sanitizeForDisplay(login.Username))
+		u.prompter.Printf("Сгенерировать новый пароль? (y/n): ")
+		return strings.EqualFold(u.readLine(), "y")
+	})
+	if err != nil {
+		u.prompter.Printf("Ошибка проверки безопасности: %v\n", err)
+		return
+	}
+
+	if len(results) == 0 {
+		u.prompter.Printf("Все пароли в порядке.\n")
+		return
+	}
+
+	rotated, skipped, failed := 0, 0, 0
+	for _, r := range results {
+		switch {
+		case r.Error != nil:
+			failed++
+			u.prompter.Printf("- %s: ошибка обновления: %v\n", sanitizeForDisplay(r.Name), r.Error)
+		case r.Skipped:
+			skipped++
+		case r.Rotated:
+			rotated++
+		}
+	}
+	u.prompter.Printf("\nИтог: обновлено %d, пропущено %d, ошибок %d.\n", rotated, skipped, failed)
+}
+
+// searchItems prompts for a query and searches decrypted item content
+// locally; nothing is sent to the server beyond the sync that already
+// happens for showData.
+func (u *UIClient) searchItems() {
+	u.prompter.Printf("Поисковый запрос: ")
+	query := u.readLine()
+	if query == "" {
+		return
+	}
+
+	matches, err := u.client.Search(query)
+	if err != nil {
+		u.prompter.Printf("Ошибка поиска: %v\n", err)
+		return
+	}
+
+	if len(matches) == 0 {
+		u.prompter.Printf("Совпадений не найдено.\n")
+		return
+	}
+
+	u.items = matches
+	for i, item := range matches {
+		u.prompter.Printf("%d. %s (%s)\n", i+1, sanitizeForDisplay(item.Name), getDataTypeName(item.Type))
+	}
+
+	u.prompter.Printf("Номер записи для просмотра (0 для отмены): ")
+	choice, err := strconv.Atoi(u.readLine())
+	if err != nil || choice == 0 {
+		return
+	}
+	if choice < 1 || choice > len(matches) {
+		u.prompter.Printf("Неверный номер записи\n")
+		return
+	}
+	u.showItemDetails(matches[choice-1])
+}
+
+// deleteItem lets the user pick an item to delete, then offers to undo
+// the delete immediately afterwards.
+func (u *UIClient) deleteItem() {
+	items, err := u.client.SyncData(time.Time{})
+	if err != nil {
+		u.prompter.Printf("Ошибка синхронизации: %v\n", err)
+		return
+	}
+	if len(items) == 0 {
+		u.prompter.Printf("Записей нет.\n")
+		return
+	}
+
+	for i, item := range items {
+		u.prompter.Printf("%d. %s (%s)\n", i+1, sanitizeForDisplay(item.Name), getDataTypeName(item.Type))
+	}
+	u.prompter.Printf("Номер записи для удаления (0 для отмены): ")
+	choice, err := strconv.Atoi(u.readLine())
+	if err != nil || choice == 0 {
+		return
+	}
+	if choice < 1 || choice > len(items) {
+		u.prompter.Printf("Неверный номер записи\n")
+		return
+	}
+
+	item := items[choice-1]
+	if err := u.client.DeleteData(item.ID); err != nil {
+		u.prompter.Printf("Ошибка удаления: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Запись %q удалена.\n", sanitizeForDisplay(item.Name))
+
+	u.prompter.Printf("Отменить удаление? (y/n): ")
+	if strings.EqualFold(u.readLine(), "y") {
+		u.undoLastDelete()
+	}
+}
+
+// batchDeleteItems lets the user pick several items at once by number
+// and deletes them in a single BatchDelete call, reporting per-item
+// outcomes instead of aborting on the first failure.
+func (u *UIClient) batchDeleteItems() {
+	items, err := u.client.SyncData(time.Time{})
+	if err != nil {
+		u.prompter.Printf("Ошибка синхронизации: %v\n", err)
+		return
+	}
+	if len(items) == 0 {
+		u.prompter.Printf("Записей нет.\n")
+		return
+	}
+
+	for i, item := range items {
+		u.prompter.Printf("%d. %s (%s)\n", i+1, sanitizeForDisplay(item.Name), getDataTypeName(item.Type))
+	}
+	u.prompter.Printf("Номера записей через запятую для удаления (0 для отмены): ")
+	input := u.readLine()
+	if input == "0" || input == "" {
+		return
+	}
+
+	var ids []string
+	for _, part := range strings.Split(input, ",") {
+		choice, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || choice < 1 || choice > len(items) {
+			u.prompter.Printf("Неверный номер записи: %q\n", part)
+			return
+		}
+		ids = append(ids, items[choice-1].ID)
+	}
+
+	results, err := u.client.BatchDelete(ids)
+	if err != nil {
+		u.prompter.Printf("Ошибка массового удаления: %v\n", err)
+		return
+	}
+	u.prompter.Printf("%s\n", SummarizeBatchDeleteResults(results))
+}
+
+// recencyList lazily loads u.recentlyViewed from RecentlyViewedPath the
+// first time it's needed, so a session that never views an item never
+// touches disk.
+func (u *UIClient) recencyList() *RecencyList {
+	if u.recentlyViewed != nil {
+		return u.recentlyViewed
+	}
+	if u.RecentlyViewedPath == "" {
+		u.recentlyViewed = NewRecencyList(defaultRecentlyViewedLimit)
+		return u.recentlyViewed
+	}
+	list, err := LoadRecencyList(u.RecentlyViewedPath, defaultRecentlyViewedLimit)
+	if err != nil {
+		u.prompter.Printf("Ошибка загрузки списка недавних записей: %v\n", err)
+		list = NewRecencyList(defaultRecentlyViewedLimit)
+	}
+	u.recentlyViewed = list
+	return u.recentlyViewed
+}
+
+// recordViewed adds id to the recently-viewed list and persists it if
+// RecentlyViewedPath is set.
+func (u *UIClient) recordViewed(id string) {
+	list := u.recencyList()
+	list.Touch(id)
+	u.saveRecencyList()
+}
+
+// saveRecencyList writes u.recentlyViewed to RecentlyViewedPath, if set.
+func (u *UIClient) saveRecencyList() {
+	if u.RecentlyViewedPath == "" {
+		return
+	}
+	if err := u.recencyList().Save(u.RecentlyViewedPath); err != nil {
+		u.prompter.Printf("Ошибка сохранения списка недавних записей: %v\n", err)
+	}
+}
+
+// showRecentlyViewed lists items from the recently-viewed list in order,
+// silently dropping any ID that no longer exists after a sync, and lets
+// the user pick one to view.
+func (u *UIClient) showRecentlyViewed() {
+	ids := u.recencyList().IDs()
+	if len(ids) == 0 {
+		u.prompter.Printf("Список недавно просмотренных пуст.\n")
+		return
+	}
+
+	items, err := u.client.SyncData(time.Time{})
+	if err != nil {
+		u.prompter.Printf("Ошибка синхронизации: %v\n", err)
+		return
+	}
+	byID := make(map[string]protocol.DataItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+	u.recencyList().Prune(func(id string) bool {
+		_, ok := byID[id]
+		return ok
+	})
+	u.saveRecencyList()
+
+	var recent []protocol.DataItem
+	for _, id := range u.recencyList().IDs() {
+		recent = append(recent, byID[id])
+	}
+	if len(recent) == 0 {
+		u.prompter.Printf("Список недавно просмотренных пуст.\n")
+		return
+	}
+
+	for i, item := range recent {
+		u.prompter.Printf("%d. %s (%s)\n", i+1, sanitizeForDisplay(item.Name), getDataTypeName(item.Type))
+	}
+	u.prompter.Printf("Номер записи для просмотра (0 для отмены): ")
+	choice, err := strconv.Atoi(u.readLine())
+	if err != nil || choice == 0 {
+		return
+	}
+	if choice < 1 || choice > len(recent) {
+		u.prompter.Printf("Неверный номер записи\n")
+		return
+	}
+	u.showItemDetails(recent[choice-1])
+}
+
+// undoLastDelete restores the item most recently deleted in this
+// session, if any.
+// lock clears the client's cached master key and session token, then
+// returns to the login prompt without disconnecting from the server or
+// exiting the program. The next operation requires logging in again.
+func (u *UIClient) lock() {
+	u.client.clearCredentials()
+	u.prompter.Printf("Хранилище заблокировано. Требуется повторный вход.\n")
+}
+
+func (u *UIClient) undoLastDelete() {
+	id := u.client.LastDeletedID()
+	if id == "" {
+		u.prompter.Printf("Нечего отменять.\n")
+		return
+	}
+	if err := u.client.RestoreData(id); err != nil {
+		u.prompter.Printf("Ошибка восстановления: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Запись восстановлена.\n")
+}
+
+// exportCSV prompts for a data type and a destination file, then writes
+// a plaintext CSV export of every item of that type.
+func (u *UIClient) exportCSV() {
+	u.prompter.Printf("Тип записи: 1) Логин  2) Карта  3) Текст\n")
+	u.prompter.Printf("Выбор: ")
+	var dataType uint8
+	switch u.readLine() {
+	case "1":
+		dataType = protocol.DataTypeLogin
+	case "2":
+		dataType = protocol.DataTypeCard
+	case "3":
+		dataType = protocol.DataTypeText
+	default:
+		u.prompter.Printf("Неверный выбор\n")
+		return
+	}
+
+	u.prompter.Printf("ВНИМАНИЕ: CSV-файл хранит данные в открытом виде, без шифрования.\n")
+	u.prompter.Printf("Путь к файлу: ")
+	path := u.readLine()
+	if path == "" {
+		return
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		u.prompter.Printf("Ошибка создания файла: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	if err := u.client.ExportCSV(dataType, f); err != nil {
+		u.prompter.Printf("Ошибка экспорта: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Экспорт завершён: %s\n", path)
+}
+
+// importCSV prompts for a data type and a source file, then imports
+// each row as a new item, reporting how many rows were skipped as
+// malformed.
+func (u *UIClient) importCSV() {
+	u.prompter.Printf("Тип записи: 1) Логин  2) Карта  3) Текст\n")
+	u.prompter.Printf("Выбор: ")
+	var dataType uint8
+	switch u.readLine() {
+	case "1":
+		dataType = protocol.DataTypeLogin
+	case "2":
+		dataType = protocol.DataTypeCard
+	case "3":
+		dataType = protocol.DataTypeText
+	default:
+		u.prompter.Printf("Неверный выбор\n")
+		return
+	}
+
+	u.prompter.Printf("Путь к файлу: ")
+	path := u.readLine()
+	if path == "" {
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		u.prompter.Printf("Ошибка открытия файла: %v\n", err)
+		return
+	}
+	defer f.Close()
+
+	items, skipped, err := parseImportCSV(f, dataType)
+	if err != nil {
+		u.prompter.Printf("Ошибка импорта: %v\n", err)
+		return
+	}
+
+	imported := 0
+	for _, item := range items {
+		if _, err := u.client.StoreData(item); err != nil {
+			skipped++
+			continue
+		}
+		imported++
+	}
+
+	u.prompter.Printf("Импортировано записей: %d, пропущено: %d\n", imported, skipped)
+}
+
+// showModified prompts for a date range and lists items changed within
+// it — a bounded audit query, unlike showData's full sync.
+func (u *UIClient) showModified() {
+	u.prompter.Printf("Начало периода (ГГГГ-ММ-ДД): ")
+	since, err := time.Parse("2006-01-02", u.readLine())
+	if err != nil {
+		u.prompter.Printf("Неверная дата: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Конец периода (ГГГГ-ММ-ДД): ")
+	until, err := time.Parse("2006-01-02", u.readLine())
+	if err != nil {
+		u.prompter.Printf("Неверная дата: %v\n", err)
+		return
+	}
+	until = until.AddDate(0, 0, 1) // include the whole end day
+
+	items, err := u.client.ListModified(since, until)
+	if err != nil {
+		u.prompter.Printf("Ошибка получения изменений: %v\n", err)
+		return
+	}
+
+	if len(items) == 0 {
+		u.prompter.Printf("За указанный период изменений нет.\n")
+		return
+	}
+
+	u.items = items
+	for i, item := range items {
+		u.prompter.Printf("%d. %s (%s), изменено: %s\n", i+1, sanitizeForDisplay(item.Name), getDataTypeName(item.Type), item.UpdatedAt.Format("2006-01-02 15:04"))
+	}
+
+	u.prompter.Printf("Номер записи для просмотра (0 для отмены): ")
+	choice, err := strconv.Atoi(u.readLine())
+	if err != nil || choice == 0 {
+		return
+	}
+	if choice < 1 || choice > len(items) {
+		u.prompter.Printf("Неверный номер записи\n")
+		return
+	}
+	u.showItemDetails(items[choice-1])
+}
+
+// showItemTable lists every item in a table of safe, non-decrypted
+// metadata (name, type, dates, size, tags, favorite status), for quickly
+// browsing a large vault without paying to decrypt every item just to
+// list it.
+func (u *UIClient) showItemTable() {
+	items, err := u.client.SyncMetadataOnly(time.Time{})
+	if err != nil {
+		u.prompter.Printf("Ошибка синхронизации: %v\n", err)
+		return
+	}
+	u.items = items
+
+	if len(items) == 0 {
+		u.prompter.Printf("Записей нет.\n")
+		return
+	}
+	u.prompter.Printf("%s", renderItemTable(items))
+}
+
+// showStats prints a quick summary of how much of the vault is used.
+func (u *UIClient) showStats() {
+	stats, err := u.client.Stats()
+	if err != nil {
+		u.prompter.Printf("Ошибка получения статистики: %v\n", err)
+		return
+	}
+	u.prompter.Printf("У вас %d записей, используется ~%d КБ\n", stats.ItemCount, stats.TotalBytes/1024)
+}
+
+// diagnose runs a pre-flight check against the server right after Run
+// connects, so a user with a connectivity or database problem sees a
+// clear message up front instead of a confusing failure deep in
+// registration or login. It never blocks the user from proceeding: even
+// a failed check just prints a warning, since the underlying problem
+// might resolve itself (or the user might know better) by the time they
+// actually try to log in.
+func (u *UIClient) diagnose() {
+	report, err := u.client.Diagnose()
+	if err != nil {
+		u.prompter.Printf("Не удалось подключиться к серверу: %v\n", err)
+		return
+	}
+	if !report.ProtocolCompatible {
+		u.prompter.Printf("Предупреждение: версия протокола сервера (%d) отличается от версии клиента (%d)\n", report.ServerVersion, protocol.Version)
+	}
+	if !report.DBHealthy {
+		u.prompter.Printf("Предупреждение: сервер сообщает о проблемах с базой данных\n")
+	}
+}
+
+// showLatency measures and prints the round-trip time to the server, so
+// a user with a slow connection can tell whether that's the cause.
+func (u *UIClient) showLatency() {
+	rtt, err := u.client.Ping()
+	if err != nil {
+		u.prompter.Printf("Ошибка измерения задержки: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Задержка соединения: %s\n", rtt)
+}
+
+func (u *UIClient) showItemDetails(item protocol.DataItem) {
+	if needsReauth(u.lastReveal, u.VaultTimeout) {
+		u.prompter.Printf("Сессия просмотра истекла, введите мастер-пароль повторно: ")
+		password := u.prompter.ReadPassword()
+		if !u.client.VerifyMasterPassword(password) {
+			u.prompter.Printf("Неверный пароль.\n")
+			return
+		}
+	}
+	u.lastReveal = time.Now()
+	u.recordViewed(item.ID)
+
+	u.prompter.Printf("\nНазвание: %s\n", sanitizeForDisplay(item.Name))
+	u.prompter.Printf("Тип: %s\n", getDataTypeName(item.Type))
+
+	fields := decryptedFields(item)
+	switch item.Type {
+	case protocol.DataTypeLogin:
+		u.prompter.Printf("Логин: %s\n", sanitizeForDisplay(fields[protocol.FieldLogin]))
+		u.prompter.Printf("Пароль: %s\n", sanitizeForDisplay(fields[protocol.FieldPassword]))
+		if rawURL := item.Metadata[protocol.MetaURL]; rawURL != "" {
+			u.prompter.Printf("URL: %s\n", sanitizeForDisplay(rawURL))
+			u.prompter.Printf("Открыть в браузере? (y/n): ")
+			if strings.EqualFold(u.readLine(), "y") {
+				if err := openInBrowser(rawURL); err != nil {
+					u.prompter.Printf("Ошибка открытия браузера: %v\n", err)
+				}
+			}
+		}
+		u.prompter.Printf("Сгенерировать новый пароль? (y/n): ")
+		if strings.EqualFold(u.readLine(), "y") {
+			u.regenerateLoginPassword(item)
+		}
+	case protocol.DataTypeCard:
+		u.prompter.Printf("Номер: %s\n", sanitizeForDisplay(fields[protocol.FieldCardNumber]))
+		u.prompter.Printf("Срок действия: %s\n", sanitizeForDisplay(fields[protocol.FieldCardExpiry]))
+		u.prompter.Printf("CVV: %s\n", sanitizeForDisplay(fields[protocol.FieldCardCVV]))
+		u.prompter.Printf("Держатель: %s\n", sanitizeForDisplay(fields[protocol.FieldCardHolder]))
+	case protocol.DataTypeText:
+		u.prompter.Printf("Текст: %s\n", sanitizeForDisplay(fields[protocol.FieldText]))
+	case protocol.DataTypeBinary:
+		u.prompter.Printf("Файл: %s\n", sanitizeForDisplay(item.Metadata[protocol.MetaOriginalFileName]))
+		u.prompter.Printf("Скачать файл? (y/n): ")
+		if strings.EqualFold(u.readLine(), "y") {
+			u.downloadItemFile(item)
+		}
+	case protocol.DataTypeWiFi:
+		// There's no DataTypeTOTP in this codebase yet, so the terminal QR
+		// code (see printQRCode) only applies to WiFi's re-provisioning URI.
+		wifi := WiFi{
+			SSID:         fields[protocol.FieldWiFiSSID],
+			Password:     fields[protocol.FieldWiFiPassword],
+			SecurityType: fields[protocol.FieldWiFiSecurityType],
+		}
+		u.prompter.Printf("Сеть (SSID): %s\n", sanitizeForDisplay(wifi.SSID))
+		u.prompter.Printf("Пароль: %s\n", sanitizeForDisplay(wifi.Password))
+		u.prompter.Printf("Защита: %s\n", sanitizeForDisplay(wifi.SecurityType))
+		u.prompter.Printf("QR-код (для сканирования телефоном):\n")
+		u.printQRCode(WiFiQRPayload(wifi))
+	}
+
+	if len(item.Notes) > 0 {
+		u.prompter.Printf("Заметка: %s\n", sanitizeForDisplay(string(item.Notes)))
+	}
+
+	u.copyFieldMenu(item, fields)
+
+	u.prompter.Printf("Управление вложениями? (y/n): ")
+	if strings.EqualFold(u.readLine(), "y") {
+		u.manageAttachments(item)
+	}
+
+	u.prompter.Printf("Редактировать метаданные как JSON? (y/n): ")
+	if strings.EqualFold(u.readLine(), "y") {
+		u.editMetadataJSON(item)
+	}
+
+	u.prompter.Printf("Редактировать заметку? (y/n): ")
+	if strings.EqualFold(u.readLine(), "y") {
+		u.editNotes(item)
+	}
+}
+
+// downloadItemFile fetches item's decrypted file content and writes it
+// to a user-chosen path, refusing to silently overwrite an existing file
+// there: it asks first, and if the user declines, resolveSavePath picks
+// a numbered variant of the path instead.
+func (u *UIClient) downloadItemFile(item protocol.DataItem) {
+	u.prompter.Printf("Путь для сохранения: ")
+	path := u.readLine()
+	if path == "" {
+		return
+	}
+
+	overwrite := false
+	if exists, err := fileExists(path); err != nil {
+		u.prompter.Printf("Ошибка проверки файла: %v\n", err)
+		return
+	} else if exists {
+		u.prompter.Printf("Файл уже существует. Перезаписать? (y/n, иначе будет создана копия): ")
+		overwrite = strings.EqualFold(u.readLine(), "y")
+	}
+
+	savePath, err := resolveSavePath(path, overwrite)
+	if err != nil {
+		u.prompter.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	data, err := u.client.DownloadFile(item.ID)
+	if err != nil {
+		u.prompter.Printf("Ошибка загрузки: %v\n", err)
+		return
+	}
+	if want, ok := item.Metadata[protocol.MetaChecksumSHA256]; ok {
+		got := sha256.Sum256(data)
+		if hex.EncodeToString(got[:]) != want {
+			u.prompter.Printf("Предупреждение: контрольная сумма файла не совпадает с сохранённой при загрузке\n")
+		}
+	}
+	if err := os.WriteFile(savePath, data, 0o600); err != nil {
+		u.prompter.Printf("Ошибка сохранения: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Файл сохранён: %s\n", savePath)
+}
+
+// manageAttachments lets the user attach, list, download, or delete small
+// files linked to item, regardless of the item's own type.
+func (u *UIClient) manageAttachments(item protocol.DataItem) {
+	for {
+		attachments, err := u.client.ListAttachments(item.ID)
+		if err != nil {
+			u.prompter.Printf("Ошибка получения вложений: %v\n", err)
+			return
+		}
+		if len(attachments) == 0 {
+			u.prompter.Printf("Вложений нет.\n")
+		}
+		for i, a := range attachments {
+			u.prompter.Printf("%d) %s (%d байт)\n", i+1, sanitizeForDisplay(a.Filename), a.Size)
+		}
+
+		u.prompter.Printf("Добавить (a), скачать (d), удалить (r) или выйти (пусто): ")
+		switch strings.ToLower(u.readLine()) {
+		case "a":
+			u.attachFile(item)
+		case "d":
+			u.selectAttachment(attachments, u.downloadAttachment)
+		case "r":
+			u.selectAttachment(attachments, u.deleteAttachment)
+		default:
+			return
+		}
+	}
+}
+
+// selectAttachment prompts for a 1-based index into attachments and calls
+// fn with the chosen one, printing an error instead of calling fn on an
+// out-of-range choice.
+func (u *UIClient) selectAttachment(attachments []protocol.Attachment, fn func(protocol.Attachment)) {
+	if len(attachments) == 0 {
+		return
+	}
+	u.prompter.Printf("Номер вложения: ")
+	choice, err := strconv.Atoi(u.readLine())
+	if err != nil || choice < 1 || choice > len(attachments) {
+		u.prompter.Printf("Некорректный номер.\n")
+		return
+	}
+	fn(attachments[choice-1])
+}
+
+// attachFile reads a local file and links it to item as a new attachment.
+func (u *UIClient) attachFile(item protocol.DataItem) {
+	u.prompter.Printf("Путь к файлу: ")
+	path := u.readLine()
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		u.prompter.Printf("Ошибка чтения файла: %v\n", err)
+		return
+	}
+	if _, err := u.client.AttachFile(item.ID, filepath.Base(path), data); err != nil {
+		u.prompter.Printf("Ошибка добавления вложения: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Вложение добавлено.\n")
+}
+
+// downloadAttachment fetches and decrypts a's content and writes it to a
+// user-chosen path, the same way downloadItemFile does for item data.
+func (u *UIClient) downloadAttachment(a protocol.Attachment) {
+	u.prompter.Printf("Путь для сохранения: ")
+	path := u.readLine()
+	if path == "" {
+		return
+	}
+
+	overwrite := false
+	if exists, err := fileExists(path); err != nil {
+		u.prompter.Printf("Ошибка проверки файла: %v\n", err)
+		return
+	} else if exists {
+		u.prompter.Printf("Файл уже существует. Перезаписать? (y/n, иначе будет создана копия): ")
+		overwrite = strings.EqualFold(u.readLine(), "y")
+	}
+
+	savePath, err := resolveSavePath(path, overwrite)
+	if err != nil {
+		u.prompter.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	data, _, err := u.client.DownloadAttachment(a.ID)
+	if err != nil {
+		u.prompter.Printf("Ошибка загрузки: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(savePath, data, 0o600); err != nil {
+		u.prompter.Printf("Ошибка сохранения: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Файл сохранён: %s\n", savePath)
+}
+
+// deleteAttachment removes a after asking the user to confirm.
+func (u *UIClient) deleteAttachment(a protocol.Attachment) {
+	u.prompter.Printf("Удалить вложение %s? (y/n): ", sanitizeForDisplay(a.Filename))
+	if !strings.EqualFold(u.readLine(), "y") {
+		return
+	}
+	if err := u.client.DeleteAttachment(a.ID); err != nil {
+		u.prompter.Printf("Ошибка удаления: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Вложение удалено.\n")
+}
+
+// editNotes replaces item's note with a freshly typed one and
+// re-uploads the item via UpdateData, since Notes is encrypted like Data
+// and can't be changed through the metadata-only update path.
+func (u *UIClient) editNotes(item protocol.DataItem) {
+	u.prompter.Printf("Новая заметка (пусто — удалить заметку): ")
+	notes := u.readLine()
+
+	if err := u.client.UpdateData(item.ID, protocol.NewDataItem{
+		Type:     item.Type,
+		Name:     item.Name,
+		Data:     item.Data,
+		Notes:    []byte(notes),
+		Metadata: item.Metadata,
+	}); err != nil {
+		u.prompter.Printf("Ошибка обновления заметки: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Заметка обновлена.\n")
+}
+
+// regenerateLoginPassword generates a new password for item in place via
+// RegenerateLoginPassword, keeping the old one in the item's password
+// history, and offers to copy the new password to the clipboard.
+func (u *UIClient) regenerateLoginPassword(item protocol.DataItem) {
+	newPassword, err := u.client.RegenerateLoginPassword(item.ID)
+	if err != nil {
+		u.prompter.Printf("Ошибка генерации пароля: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Новый пароль: %s\n", newPassword)
+
+	u.prompter.Printf("Скопировать новый пароль в буфер обмена? (y/n): ")
+	if strings.EqualFold(u.readLine(), "y") {
+		if err := CopyWithTimeout(newPassword); err != nil {
+			u.prompter.Printf("Ошибка копирования: %v\n", err)
+			return
+		}
+		u.prompter.Printf("Пароль скопирован, буфер обмена очистится через %s.\n", clipboardAutoClear)
+	}
+}
+
+// editMetadataJSON dumps item's metadata as pretty JSON, lets the user
+// paste a replacement object, and updates it via the metadata-only
+// update path so the item's encrypted data isn't re-uploaded.
+func (u *UIClient) editMetadataJSON(item protocol.DataItem) {
+	current, err := json.MarshalIndent(item.Metadata, "", "  ")
+	if err != nil {
+		u.prompter.Printf("Ошибка сериализации метаданных: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Текущие метаданные:\n")
+	u.prompter.Printf("%s\n", string(current))
+
+	u.prompter.Printf("Новые метаданные (JSON-объект, одна строка): ")
+	raw := u.readLine()
+	if raw == "" {
+		return
+	}
+
+	metadata, err := parseMetadataJSON(raw)
+	if err != nil {
+		u.prompter.Printf("Ошибка: %v\n", err)
+		return
+	}
+
+	if err := u.client.UpdateMetadata(item.ID, metadata); err != nil {
+		u.prompter.Printf("Ошибка обновления метаданных: %v\n", err)
+		return
+	}
+	u.prompter.Printf("Метаданные обновлены.\n")
+}
+
+// copyFieldMenu offers a numbered picker of item's copyable fields and
+// copies the chosen one to the clipboard with the shared auto-clear
+// timer.
+func (u *UIClient) copyFieldMenu(item protocol.DataItem, fields map[string]string) {
+	copyable := copyableFieldsFor(item.Type)
+	if len(copyable) == 0 {
+		return
+	}
+
+	u.prompter.Printf("\nСкопировать в буфер обмена:\n")
+	for i, f := range copyable {
+		u.prompter.Printf("%d. %s\n", i+1, f.Label)
+	}
+	u.prompter.Printf("Номер поля (0 для отмены): ")
+	choice, err := strconv.Atoi(u.readLine())
+	if err != nil || choice == 0 {
+		return
+	}
+	if choice < 1 || choice > len(copyable) {
+		u.prompter.Printf("Неверный номер поля\n")
+		return
+	}
+
+	field := copyable[choice-1]
+	if err := CopyWithTimeout(field.value(fields, item)); err != nil {
+		u.prompter.Printf("Ошибка копирования: %v\n", err)
+		return
+	}
+	u.prompter.Printf("%s скопирован(о), буфер обмена очистится через %s.\n", field.Label, clipboardAutoClear)
+}
+
+// getDataTypeName returns the Russian label shown for a data type in the
+// UI.
+func getDataTypeName(t uint8) string {
+	switch t {
+	case protocol.DataTypeLogin:
+		return "Логин"
+	case protocol.DataTypeCard:
+		return "Карта"
+	case protocol.DataTypeText:
+		return "Текст"
+	case protocol.DataTypeBinary:
+		return "Файл"
+	case protocol.DataTypeWiFi:
+		return "WiFi"
+	default:
+		return fmt.Sprintf("Неизвестный тип (%s)", protocol.DataType(t))
+	}
+}
@@ -0,0 +1,289 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// bufferPrompter is a Prompter backed by an in-memory input buffer and an
+// output buffer, so a test can script a UIClient interaction and, if it
+// wants, assert on what was printed.
+type bufferPrompter struct {
+	in  *bufio.Reader
+	out bytes.Buffer
+}
+
+func newBufferPrompter(input string) *bufferPrompter {
+	return &bufferPrompter{in: bufio.NewReader(strings.NewReader(input))}
+}
+
+func (p *bufferPrompter) ReadLine() string {
+	line, _ := p.in.ReadString('\n')
+	return strings.TrimSpace(line)
+}
+
+func (p *bufferPrompter) ReadPassword() string {
+	return p.ReadLine()
+}
+
+func (p *bufferPrompter) Printf(format string, args ...any) {
+	fmt.Fprintf(&p.out, format, args...)
+}
+
+func newTestUIClient(input string) *UIClient {
+	return &UIClient{prompter: newBufferPrompter(input)}
+}
+
+func TestNeedsReauthDisabledWhenTimeoutIsZeroOrNegative(t *testing.T) {
+	if needsReauth(time.Time{}, 0) {
+		t.Fatal("expected no reauth required with a zero timeout")
+	}
+	if needsReauth(time.Now(), -time.Minute) {
+		t.Fatal("expected no reauth required with a negative timeout")
+	}
+}
+
+func TestNeedsReauthTrueOnFirstReveal(t *testing.T) {
+	if !needsReauth(time.Time{}, 5*time.Minute) {
+		t.Fatal("expected reauth required before any reveal has happened")
+	}
+}
+
+func TestNeedsReauthFalseWithinTimeoutWindow(t *testing.T) {
+	last := time.Now().Add(-time.Minute)
+	if needsReauth(last, 5*time.Minute) {
+		t.Fatal("expected no reauth required within the timeout window")
+	}
+}
+
+func TestNeedsReauthTrueAfterTimeoutWindow(t *testing.T) {
+	last := time.Now().Add(-10 * time.Minute)
+	if !needsReauth(last, 5*time.Minute) {
+		t.Fatal("expected reauth required after the timeout window has elapsed")
+	}
+}
+
+func TestPromptLoginFields(t *testing.T) {
+	u := newTestUIClient("alice\nhunter2\nhttps://example.com\n")
+
+	payload, metadata := u.promptLoginFields()
+	if payload["login"] != "alice" || payload["password"] != "hunter2" {
+		t.Fatalf("unexpected payload: %v", payload)
+	}
+	if metadata["url"] != "https://example.com" {
+		t.Fatalf("expected a valid URL to be recorded in metadata, got %v", metadata)
+	}
+}
+
+func TestPromptLoginFieldsRejectsInvalidURL(t *testing.T) {
+	u := newTestUIClient("alice\nhunter2\nnot-a-url\n")
+
+	_, metadata := u.promptLoginFields()
+	if _, ok := metadata["url"]; ok {
+		t.Fatalf("expected an invalid URL to be dropped, got metadata %v", metadata)
+	}
+}
+
+func TestPromptLoginFieldsSkipsEmptyURL(t *testing.T) {
+	u := newTestUIClient("alice\nhunter2\n\n")
+
+	_, metadata := u.promptLoginFields()
+	if len(metadata) != 0 {
+		t.Fatalf("expected no metadata when the URL prompt is left blank, got %v", metadata)
+	}
+}
+
+func TestPromptCardFields(t *testing.T) {
+	u := newTestUIClient("4111111111111111\n12/30\n123\nJohn Doe\n")
+
+	payload, metadata := u.promptCardFields()
+	want := map[string]string{"number": "4111111111111111", "expiry": "12/30", "cvv": "123", "holder": "John Doe"}
+	for k, v := range want {
+		if payload[k] != v {
+			t.Fatalf("unexpected payload: got %v, want %v", payload, want)
+		}
+	}
+	if len(metadata) != 0 {
+		t.Fatalf("expected card items to carry no metadata, got %v", metadata)
+	}
+}
+
+// TestPromptLoginFieldsRoundTripsWithLoginStruct asserts that
+// promptLoginFields keys its payload with the protocol.Field* constants
+// by round-tripping it through the typed Login struct used by
+// SaveLogin/GetLogin: if either side drifted back to a bare string
+// literal, the two would disagree on a field name and this would fail.
+func TestPromptLoginFieldsRoundTripsWithLoginStruct(t *testing.T) {
+	u := newTestUIClient("alice\nhunter2\n\n")
+
+	payload, _ := u.promptLoginFields()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	var login Login
+	if err := json.Unmarshal(data, &login); err != nil {
+		t.Fatalf("unmarshal into Login: %v", err)
+	}
+	if login.Username != payload[protocol.FieldLogin] || login.Password != payload[protocol.FieldPassword] {
+		t.Fatalf("round trip lost fields: payload %v, got Login %+v", payload, login)
+	}
+}
+
+// TestPromptCardFieldsRoundTripsWithCardStruct is the card analogue of
+// TestPromptLoginFieldsRoundTripsWithLoginStruct.
+func TestPromptCardFieldsRoundTripsWithCardStruct(t *testing.T) {
+	u := newTestUIClient("4111111111111111\n12/30\n123\nJohn Doe\n")
+
+	payload, _ := u.promptCardFields()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	var card Card
+	if err := json.Unmarshal(data, &card); err != nil {
+		t.Fatalf("unmarshal into Card: %v", err)
+	}
+	if card.Number != payload[protocol.FieldCardNumber] ||
+		card.Expiry != payload[protocol.FieldCardExpiry] ||
+		card.CVV != payload[protocol.FieldCardCVV] ||
+		card.Holder != payload[protocol.FieldCardHolder] {
+		t.Fatalf("round trip lost fields: payload %v, got Card %+v", payload, card)
+	}
+}
+
+func TestPromptTextFields(t *testing.T) {
+	u := newTestUIClient("just a note\n")
+
+	payload, metadata := u.promptTextFields()
+	if payload["text"] != "just a note" {
+		t.Fatalf("unexpected payload: %v", payload)
+	}
+	if len(metadata) != 0 {
+		t.Fatalf("expected text items to carry no metadata, got %v", metadata)
+	}
+}
+
+// TestCreateNewItemReprompsOnNameBeyondMaxLength asserts that
+// createNewItem rejects an over-limit item name locally, without a
+// round trip to the server, and re-prompts until an acceptable name is
+// entered.
+func TestCreateNewItemReprompsOnNameBeyondMaxLength(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	prompter := newBufferPrompter(strings.Join([]string{
+		"3",                                      // item type: text
+		"a note to keep",                         // text
+		strings.Repeat("a", maxItemNameLength+1), // item name: too long
+		"short name",                             // item name: retried, acceptable
+		"",                                       // notes (skipped)
+		"n",                                      // don't add another
+	}, "\n") + "\n")
+
+	u := &UIClient{
+		prompter: prompter,
+		client:   &Client{conn: clientConn, masterKey: []byte("0123456789abcdef0123456789abcdef")},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		var req protocol.StoreDataRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			done <- err
+			return
+		}
+		if req.Item.Name != "short name" {
+			done <- fmt.Errorf("expected the retried, acceptable name to be sent, got %q", req.Item.Name)
+			return
+		}
+		resp, _ := json.Marshal(protocol.StoreDataResponse{ID: "note-1"})
+		done <- writeFramedResponse(serverConn, protocol.MsgTypeStoreDataResponse, msgID, resp)
+	}()
+
+	u.createNewItem()
+
+	if err := <-done; err != nil {
+		t.Fatalf("server: %v", err)
+	}
+}
+
+// TestCreateNewItemDrivesFullFlowThroughScriptedPrompter drives
+// createNewItem end to end through a scripted Prompter: picking the text
+// item type, entering its text, name, and notes, saving it, and
+// declining to add another. It verifies both that the right request
+// reaches the (mocked) server and that the confirmation is printed to
+// the prompter's output buffer.
+func TestCreateNewItemDrivesFullFlowThroughScriptedPrompter(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	prompter := newBufferPrompter(strings.Join([]string{
+		"3",              // item type: text
+		"a note to keep", // text
+		"my note",        // item name
+		"",               // notes (skipped)
+		"n",              // don't add another
+	}, "\n") + "\n")
+
+	u := &UIClient{
+		prompter: prompter,
+		client:   &Client{conn: clientConn, masterKey: []byte("0123456789abcdef0123456789abcdef")},
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, msgID, payload, err := readFramedRequest(serverConn)
+		if err != nil {
+			done <- err
+			return
+		}
+		var req protocol.StoreDataRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			done <- err
+			return
+		}
+		if req.Item.Type != protocol.DataTypeText || req.Item.Name != "my note" {
+			done <- fmt.Errorf("unexpected item: %+v", req.Item)
+			return
+		}
+		decrypted, err := crypto.Decrypt(req.Item.Data, u.client.masterKey)
+		if err != nil {
+			done <- err
+			return
+		}
+		if string(decrypted) != `{"text":"a note to keep"}` {
+			done <- fmt.Errorf("unexpected decrypted data: %s", decrypted)
+			return
+		}
+		resp, _ := json.Marshal(protocol.StoreDataResponse{ID: "note-1"})
+		done <- writeFramedResponse(serverConn, protocol.MsgTypeStoreDataResponse, msgID, resp)
+	}()
+
+	u.createNewItem()
+
+	if err := <-done; err != nil {
+		t.Fatalf("server: %v", err)
+	}
+	if !strings.Contains(prompter.out.String(), "note-1") {
+		t.Fatalf("expected the saved item's ID to be printed, got %q", prompter.out.String())
+	}
+}
@@ -0,0 +1,28 @@
+package client
+
+import "strings"
+
+// wifiQREscaper escapes the characters that are significant delimiters in
+// the WIFI: QR code format (backslash, semicolon, comma, colon) so an SSID
+// or password containing them round-trips through a scanner correctly.
+var wifiQREscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`;`, `\;`,
+	`,`, `\,`,
+	`:`, `\:`,
+)
+
+// WiFiQRPayload builds the WIFI: QR code payload for w, in the format
+// scanned by phone camera apps to join a network without typing the
+// password: "WIFI:T:<security>;S:<ssid>;P:<password>;;". SecurityType
+// defaults to "WPA" when w.SecurityType is empty.
+func WiFiQRPayload(w WiFi) string {
+	security := w.SecurityType
+	if security == "" {
+		security = "WPA"
+	}
+	return "WIFI:T:" + wifiQREscaper.Replace(security) +
+		";S:" + wifiQREscaper.Replace(w.SSID) +
+		";P:" + wifiQREscaper.Replace(w.Password) +
+		";;"
+}
@@ -0,0 +1,27 @@
+package client
+
+import "testing"
+
+func TestWiFiQRPayloadFormatsFields(t *testing.T) {
+	got := WiFiQRPayload(WiFi{SSID: "HomeNet", Password: "hunter2", SecurityType: "WPA2"})
+	want := "WIFI:T:WPA2;S:HomeNet;P:hunter2;;"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestWiFiQRPayloadDefaultsSecurityType(t *testing.T) {
+	got := WiFiQRPayload(WiFi{SSID: "OpenNet", Password: ""})
+	want := "WIFI:T:WPA;S:OpenNet;P:;;"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestWiFiQRPayloadEscapesDelimiters(t *testing.T) {
+	got := WiFiQRPayload(WiFi{SSID: `Home;Net,2`, Password: `p:a\ss`, SecurityType: "WPA2"})
+	want := `WIFI:T:WPA2;S:Home\;Net\,2;P:p\:a\\ss;;`
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
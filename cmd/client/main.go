@@ -0,0 +1,132 @@
+// Command client runs the password manager's interactive terminal UI, or,
+// with -op, a single non-interactive read operation for scripting.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/client"
+)
+
+func main() {
+	fs := flag.NewFlagSet("client", flag.ExitOnError)
+	pinCertSHA256 := fs.String("pin-cert-sha256", "", "expect the server's TLS certificate to have this SHA-256 fingerprint (hex); only used for tls:// server addresses, and aborts the handshake on a mismatch")
+	vaultTimeout := fs.Duration("vault-timeout", 0, "require the master password to be re-entered before viewing an item's secrets if this long has passed since the last reveal (0 disables the check)")
+	recentlyViewedFile := fs.String("recently-viewed-file", "", "persist the \"recently viewed\" item list to this file across runs (empty disables persistence)")
+
+	op := fs.String("op", "", `non-interactive read operation: "list", "get", or "search" (empty runs the interactive UI)`)
+	addr := fs.String("addr", "localhost:8080", "server address (host:port, unix://path, or tls://host:port)")
+	username := fs.String("username", "", "account username, required with -op")
+	password := fs.String("password", "", "master password, required with -op")
+	id := fs.String("id", "", `item ID, required for -op get`)
+	query := fs.String("query", "", `search query, required for -op search`)
+	jsonOutput := fs.Bool("json", false, "print results as JSON instead of a plain table")
+	reveal := fs.Bool("reveal", false, "include decrypted field values and notes in the output; omitted by default")
+	metadataOnly := fs.Bool("metadata-only", false, `for -op list, skip fetching each item's encrypted data (implies no revealed fields)`)
+	fs.Parse(os.Args[1:])
+
+	if *op == "" {
+		ui := client.NewUIClient()
+		ui.PinnedCertSHA256 = *pinCertSHA256
+		ui.VaultTimeout = *vaultTimeout
+		ui.RecentlyViewedPath = *recentlyViewedFile
+		ui.Run()
+		return
+	}
+
+	if err := runNonInteractive(nonInteractiveOptions{
+		op:            *op,
+		addr:          *addr,
+		username:      *username,
+		password:      *password,
+		id:            *id,
+		query:         *query,
+		json:          *jsonOutput,
+		reveal:        *reveal,
+		metadataOnly:  *metadataOnly,
+		pinCertSHA256: *pinCertSHA256,
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+type nonInteractiveOptions struct {
+	op            string
+	addr          string
+	username      string
+	password      string
+	id            string
+	query         string
+	json          bool
+	reveal        bool
+	metadataOnly  bool
+	pinCertSHA256 string
+}
+
+func runNonInteractive(opts nonInteractiveOptions) error {
+	if opts.username == "" || opts.password == "" {
+		return fmt.Errorf("-op requires -username and -password")
+	}
+
+	c := client.NewClient(opts.addr)
+	c.PinnedCertSHA256 = opts.pinCertSHA256
+	if err := c.Login(opts.username, opts.password); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	defer c.Close()
+
+	switch opts.op {
+	case "list":
+		return runList(c, opts)
+	case "get":
+		if opts.id == "" {
+			return fmt.Errorf("-op get requires -id")
+		}
+		return runGet(c, opts)
+	case "search":
+		if opts.query == "" {
+			return fmt.Errorf("-op search requires -query")
+		}
+		return runSearch(c, opts)
+	default:
+		return fmt.Errorf("unknown -op %q, expected list, get, or search", opts.op)
+	}
+}
+
+func runList(c *client.Client, opts nonInteractiveOptions) error {
+	if opts.metadataOnly {
+		items, err := c.SyncMetadataOnly(time.Time{})
+		if err != nil {
+			return err
+		}
+		// A metadata-only sync never fetched Data, so there's nothing to
+		// reveal regardless of what -reveal asked for.
+		opts.reveal = false
+		return printItems(items, opts)
+	}
+	items, err := c.SyncData(time.Time{})
+	if err != nil {
+		return err
+	}
+	return printItems(items, opts)
+}
+
+func runGet(c *client.Client, opts nonInteractiveOptions) error {
+	item, err := c.GetData(opts.id)
+	if err != nil {
+		return err
+	}
+	return printItem(item, opts)
+}
+
+func runSearch(c *client.Client, opts nonInteractiveOptions) error {
+	items, err := c.Search(opts.query)
+	if err != nil {
+		return err
+	}
+	return printItems(items, opts)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/client"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func printItems(items []protocol.DataItem, opts nonInteractiveOptions) error {
+	if opts.json {
+		out, err := client.RenderItemsJSON(items, opts.reveal)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tTYPE\tUPDATED")
+	for _, item := range items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", item.ID, item.Name, client.DataTypeSlug(item.Type), item.UpdatedAt.Format(time.RFC3339))
+	}
+	return w.Flush()
+}
+
+func printItem(item protocol.DataItem, opts nonInteractiveOptions) error {
+	if opts.json {
+		out, err := client.RenderItemJSON(item, opts.reveal)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	fmt.Printf("ID:      %s\n", item.ID)
+	fmt.Printf("Name:    %s\n", item.Name)
+	fmt.Printf("Type:    %s\n", client.DataTypeSlug(item.Type))
+	fmt.Printf("Updated: %s\n", item.UpdatedAt.Format(time.RFC3339))
+	if opts.reveal {
+		fmt.Printf("Data:    %s\n", item.Data)
+		if len(item.Notes) > 0 {
+			fmt.Printf("Notes:   %s\n", item.Notes)
+		}
+	}
+	return nil
+}
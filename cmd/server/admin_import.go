@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+	"github.com/SemAnton-2007/go-password-manager/server"
+)
+
+// importItem is one entry of the -file JSON array: a NewDataItem plus
+// the original timestamps to preserve, since protocol.NewDataItem itself
+// carries no timestamps (the normal StoreData path always assigns them
+// server-side).
+type importItem struct {
+	protocol.NewDataItem
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// runAdminImport loads already-encrypted items from a JSON file and
+// inserts them directly into the database under an existing user,
+// preserving each item's original created_at/updated_at via
+// Database.StoreDataWithTimestamps instead of the server-assigned
+// timestamps the normal client-facing StoreData request would get. This
+// bypasses the network protocol entirely, so it's for operators
+// restoring a backup or migrating from another tool, not for ordinary
+// client use.
+func runAdminImport(args []string) {
+	fs := flag.NewFlagSet("admin import", flag.ExitOnError)
+	dbURL := fs.String("db", "postgres://localhost:5432/passwordmanager", "database connection string")
+	username := fs.String("user", "", "username to import items into")
+	file := fs.String("file", "", "path to a JSON file containing an array of items to import")
+	fs.Parse(args)
+
+	if *username == "" || *file == "" {
+		fmt.Fprintln(os.Stderr, "must specify -user and -file")
+		os.Exit(1)
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read %s: %v\n", *file, err)
+		os.Exit(1)
+	}
+
+	var items []importItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to parse %s: %v\n", *file, err)
+		os.Exit(1)
+	}
+
+	db, err := server.NewDatabase(*dbURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	userID, _, _, _, _, err := db.GetUserByUsername(*username)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to look up user %q: %v\n", *username, err)
+		os.Exit(1)
+	}
+
+	imported := 0
+	for _, item := range items {
+		if _, err := db.StoreDataWithTimestamps(userID, item.NewDataItem, item.CreatedAt, item.UpdatedAt); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to import item %q: %v\n", item.Name, err)
+			os.Exit(1)
+		}
+		imported++
+	}
+
+	fmt.Printf("Imported %d item(s) for %q.\n", imported, *username)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/SemAnton-2007/go-password-manager/server"
+)
+
+// runAdminListUsers prints every account's username, creation date, and
+// item count for a quick operator inventory. It never prints password
+// hashes or key material, since Database.ListUsers doesn't select them.
+func runAdminListUsers(args []string) {
+	fs := flag.NewFlagSet("admin list-users", flag.ExitOnError)
+	dbURL := fs.String("db", "postgres://localhost:5432/passwordmanager", "database connection string")
+	jsonOutput := fs.Bool("json", false, "print as a JSON array instead of a table")
+	fs.Parse(args)
+
+	db, err := server.NewDatabase(*dbURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	users, err := db.ListUsers()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to list users: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(users); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to encode users: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "USERNAME\tCREATED\tITEMS")
+	for _, u := range users {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", u.Username, u.CreatedAt.Format("2006-01-02"), u.ItemCount)
+	}
+	w.Flush()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/SemAnton-2007/go-password-manager/server"
+)
+
+// runAdminResetPassword sets a user's login password hash directly in
+// the database, without going through the network protocol. This is for
+// operators helping a locked-out user; it does not and cannot recover
+// the user's existing vault data, since the data-encryption key is
+// derived from the old master password.
+func runAdminResetPassword(args []string) {
+	fs := flag.NewFlagSet("admin reset-password", flag.ExitOnError)
+	dbURL := fs.String("db", "postgres://localhost:5432/passwordmanager", "database connection string")
+	username := fs.String("user", "", "username to reset")
+	fs.Parse(args)
+
+	if *username == "" {
+		fmt.Fprintln(os.Stderr, "must specify -user")
+		os.Exit(1)
+	}
+
+	fmt.Println("WARNING: resetting the login password does not recover this user's")
+	fmt.Println("existing vault data. Their data-encryption key is derived from the")
+	fmt.Println("old master password, so items will be unreadable after this reset")
+	fmt.Println("unless the account has a recovery key set up.")
+
+	fmt.Print("New password: ")
+	reader := bufio.NewReader(os.Stdin)
+	newPassword, _ := reader.ReadString('\n')
+	newPassword = strings.TrimSpace(newPassword)
+	if newPassword == "" {
+		fmt.Fprintln(os.Stderr, "password must not be empty")
+		os.Exit(1)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to hash password: %v\n", err)
+		os.Exit(1)
+	}
+
+	db, err := server.NewDatabase(*dbURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	if err := db.SetPasswordHash(*username, string(hash)); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to reset password: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Password for %q has been reset.\n", *username)
+}
@@ -0,0 +1,121 @@
+// Command server runs the password manager's TCP server, plus offline
+// admin and migrate subcommands that operate directly on the database.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/SemAnton-2007/go-password-manager/server"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		runAdmin(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+	runServer(os.Args[1:])
+}
+
+func runServer(args []string) {
+	fs := flag.NewFlagSet("server", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "address to listen on")
+	dbURL := fs.String("db", "postgres://localhost:5432/passwordmanager", "database connection string")
+	passwordMaxAge := fs.Int("password-max-age", 90, "days before a master password is flagged for rotation (0 disables the check)")
+	protocolMode := fs.String("protocol", "binary", `wire framing: "binary" or "jsonl"`)
+	dbMaxConns := fs.Int("db-max-conns", 0, "maximum database pool connections (0 uses pgxpool's default)")
+	dbMinConns := fs.Int("db-min-conns", 0, "minimum database pool connections (0 uses pgxpool's default)")
+	dbConnMaxLifetime := fs.Duration("db-conn-max-lifetime", 0, "maximum lifetime of a pooled database connection (0 uses pgxpool's default)")
+	strictMetadata := fs.Bool("strict-metadata", false, "fail a query outright if any row has corrupt metadata JSON, instead of logging and using empty metadata for that row")
+	socket := fs.String("socket", "", "listen on this Unix domain socket path instead of -addr")
+	bcryptCost := fs.Int("bcrypt-cost", bcrypt.DefaultCost, "bcrypt cost for hashing new master passwords and recovery keys; higher is slower but harder to crack offline")
+	maxMessageSize := fs.Int("max-message-size", 64*1024*1024, "maximum declared request payload size in bytes; larger declared lengths are rejected before allocating")
+	keepAlivePeriod := fs.Duration("tcp-keepalive", 0, "TCP keepalive probe interval for accepted connections (0 disables keepalive)")
+	dbCreate := fs.Bool("db-create", false, "create the target database if it doesn't already exist, before running migrations")
+	allowTypeChange := fs.Bool("allow-type-change", false, "allow UpdateData to change an item's data type instead of rejecting the update")
+	logTraffic := fs.Bool("log-traffic", false, "log every message's type and length; off by default since it can reveal usage patterns")
+	readTimeout := fs.Duration("read-timeout", 0, "close a connection that hasn't sent a complete request within this duration (0 disables the timeout)")
+	sessionTTL := fs.Duration("session-ttl", 0, "how long a session token stays valid after login (0 means sessions never expire)")
+	sessionJanitorInterval := fs.Duration("session-janitor-interval", 0, "how often to sweep expired sessions out of memory (0 disables the janitor)")
+	serverEncryptionKey := fs.String("server-encryption-key", "", "if set, additionally encrypt item data at rest under this key, on top of the client's own encryption (defense against a raw database dump)")
+	maxNameLength := fs.Int("max-name-length", server.DefaultMaxNameLength, "maximum length, in bytes, of an item's name; a negative value disables the check")
+	minProtocolVersion := fs.Int("min-protocol-version", 0, "reject Register/Auth from a client reporting an older protocol version (0 accepts any client)")
+	fs.Parse(args)
+
+	if *bcryptCost < bcrypt.MinCost || *bcryptCost > bcrypt.MaxCost {
+		log.Fatalf("-bcrypt-cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, *bcryptCost)
+	}
+
+	if *dbCreate {
+		if err := server.CreateDatabaseIfMissing(*dbURL); err != nil {
+			log.Fatalf("Failed to create database: %v", err)
+		}
+	}
+
+	if err := server.RunMigrations(*dbURL); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	db, err := server.NewDatabaseWithOptions(*dbURL, server.PoolOptions{
+		MaxConns:        int32(*dbMaxConns),
+		MinConns:        int32(*dbMinConns),
+		ConnMaxLifetime: *dbConnMaxLifetime,
+	})
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+	db.StrictMetadata = *strictMetadata
+	if *serverEncryptionKey != "" {
+		db.EncryptionKey = server.NewEncryptionKeyFromPassphrase(*serverEncryptionKey)
+	}
+	db.MaxNameLength = *maxNameLength
+
+	srv := server.NewServer(*addr, db)
+	srv.Protocol = *protocolMode
+	srv.Socket = *socket
+	srv.BcryptCost = *bcryptCost
+	srv.MaxMessageSize = uint32(*maxMessageSize)
+	srv.KeepAlivePeriod = *keepAlivePeriod
+	srv.AllowTypeChange = *allowTypeChange
+	srv.MinProtocolVersion = *minProtocolVersion
+	srv.LogTraffic = *logTraffic
+	srv.ReadTimeout = *readTimeout
+	srv.SessionTTL = *sessionTTL
+	srv.SessionJanitorInterval = *sessionJanitorInterval
+	if *passwordMaxAge <= 0 {
+		srv.PasswordMaxAge = 0
+	} else {
+		srv.PasswordMaxAge = time.Duration(*passwordMaxAge) * 24 * time.Hour
+	}
+	if err := srv.Start(); err != nil {
+		log.Fatalf("Server stopped: %v", err)
+	}
+}
+
+func runAdmin(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: server admin <reset-password|list-users|import> [flags]")
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "reset-password":
+		runAdminResetPassword(args[1:])
+	case "list-users":
+		runAdminListUsers(args[1:])
+	case "import":
+		runAdminImport(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown admin subcommand: %s\n", args[0])
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/SemAnton-2007/go-password-manager/server"
+)
+
+// runMigrate handles the "server migrate" subcommand, which inspects or
+// repairs migration state without starting the server.
+func runMigrate(args []string) {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dbURL := fs.String("db", "postgres://localhost:5432/passwordmanager", "database connection string")
+	status := fs.Bool("status", false, "print the current migration version and dirty state, without applying anything")
+	force := fs.Int("force", -1, "clear a dirty flag and set the migration version to this value by hand, without applying anything (use after fixing or rolling back a failed migration)")
+	fs.Parse(args)
+
+	if *status {
+		version, dirty, ok, err := server.MigrationStatus(*dbURL)
+		if err != nil {
+			log.Fatalf("Failed to get migration status: %v", err)
+		}
+		if !ok {
+			fmt.Println("no migrations applied")
+			return
+		}
+		fmt.Printf("version: %d, dirty: %t\n", version, dirty)
+		return
+	}
+
+	if *force >= 0 {
+		if err := server.ForceMigrationVersion(*dbURL, *force); err != nil {
+			log.Fatalf("Failed to force migration version: %v", err)
+		}
+		fmt.Printf("migration version forced to %d\n", *force)
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "usage: server migrate -status | -force <version> [-db connString]")
+	os.Exit(1)
+}
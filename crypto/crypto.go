@@ -0,0 +1,393 @@
+// Package crypto provides the symmetric encryption and key derivation
+// used to protect vault data before it ever leaves the client.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// KeySize is the length in bytes of keys produced by DeriveKey (AES-256).
+const KeySize = 32
+
+// kdfIterations is the PBKDF2 iteration count used to derive the vault
+// key from the master password.
+const kdfIterations = 100000
+
+// DeriveKey derives a 32-byte AES-256 key from a password and salt using
+// PBKDF2-HMAC-SHA256.
+func DeriveKey(password string, salt []byte) []byte {
+	key, _ := DeriveKeyN(password, salt, KeySize)
+	return key
+}
+
+// DeriveKeyN derives an AES key of keyLen bytes (16, 24, or 32, for
+// AES-128/192/256) from a password and salt using PBKDF2-HMAC-SHA256.
+func DeriveKeyN(password string, salt []byte, keyLen int) ([]byte, error) {
+	switch keyLen {
+	case 16, 24, 32:
+	default:
+		return nil, fmt.Errorf("crypto: invalid key length %d, must be 16, 24, or 32", keyLen)
+	}
+	return pbkdf2.Key([]byte(password), salt, kdfIterations, keyLen, sha256.New), nil
+}
+
+// SaltSize is the length in bytes of a salt produced by GenerateSalt.
+const SaltSize = 16
+
+// GenerateSalt returns random bytes suitable for use as a per-account
+// KDF salt, so DeriveKey doesn't have to rely on data like the username
+// that can change or repeat across accounts.
+func GenerateSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// NewKeyFromPassword generates a fresh random salt and derives a
+// 32-byte AES-256 key from password, so callers that don't already
+// manage their own salt (unlike DeriveKey) get a ready-to-store pair in
+// one call. Use KeyFromPasswordAndSalt with the returned salt to
+// re-derive the same key later.
+func NewKeyFromPassword(password string) (key, salt []byte, err error) {
+	salt, err = GenerateSalt()
+	if err != nil {
+		return nil, nil, err
+	}
+	return DeriveKey(password, salt), salt, nil
+}
+
+// KeyFromPasswordAndSalt re-derives the key NewKeyFromPassword produced
+// for password and salt. It's just DeriveKey under a name that pairs
+// with NewKeyFromPassword at call sites.
+func KeyFromPasswordAndSalt(password string, salt []byte) []byte {
+	return DeriveKey(password, salt)
+}
+
+// recoveryKeyBytes is the amount of entropy packed into a generated
+// recovery key.
+const recoveryKeyBytes = 20
+
+// GenerateRecoveryKey returns a high-entropy, human-typeable recovery
+// key (base32, grouped in dashes) suitable for wrapping a data key so it
+// can be recovered without the master password.
+func GenerateRecoveryKey() (string, error) {
+	buf := make([]byte, recoveryKeyBytes)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return "", err
+	}
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)
+
+	var groups []string
+	for i := 0; i < len(encoded); i += 4 {
+		end := i + 4
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		groups = append(groups, encoded[i:end])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// GeneratePassphrase returns a diceware-style passphrase of the given
+// number of words drawn from an embedded wordlist and joined by
+// separator, e.g. GeneratePassphrase(4, "-") might return
+// "anchor-nimbus-quartz-heron". Each word is chosen independently with
+// crypto/rand via randomIndex, so every word in the list has exactly
+// equal probability regardless of the list's length.
+func GeneratePassphrase(words int, separator string) (string, error) {
+	if words <= 0 {
+		return "", fmt.Errorf("crypto: word count must be positive, got %d", words)
+	}
+
+	chosen := make([]string, words)
+	for i := range chosen {
+		idx, err := randomIndex(len(passphraseWordlist))
+		if err != nil {
+			return "", err
+		}
+		chosen[i] = passphraseWordlist[idx]
+	}
+	return strings.Join(chosen, separator), nil
+}
+
+// randomIndex returns a uniformly distributed random index in [0, n)
+// using crypto/rand.Int, which itself rejects biased draws rather than
+// reducing modulo n.
+func randomIndex(n int) (int, error) {
+	max := big.NewInt(int64(n))
+	i, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return 0, err
+	}
+	return int(i.Int64()), nil
+}
+
+// Character classes GeneratePassword draws from.
+const (
+	lowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	upperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars  = "0123456789"
+	symbolChars = "!@#$%^&*()-_=+[]{};:,.<>?"
+)
+
+// PasswordOptions configures GeneratePassword: which character classes to
+// draw from, how long the result should be, and any constraints imposed
+// by the site the password is for.
+type PasswordOptions struct {
+	Length     int
+	UseLower   bool
+	UseUpper   bool
+	UseDigits  bool
+	UseSymbols bool
+
+	// ExcludeChars lists characters GeneratePassword must never use, e.g.
+	// characters a site's login form rejects.
+	ExcludeChars string
+
+	// RequireFromSet, if non-empty, forces at least one character of the
+	// result to come from this set, e.g. a site that demands a symbol
+	// from a specific short list.
+	RequireFromSet string
+}
+
+// GeneratePassword returns a random password built from the character
+// classes enabled in opts, using crypto/rand throughout. At least one
+// character of each enabled class is guaranteed to appear (as is one from
+// RequireFromSet, if set), so a site's "must contain a digit" style rule
+// can't be defeated by unlucky sampling. It errors instead of silently
+// producing a weaker password if ExcludeChars removes every character of
+// a requested class or of RequireFromSet, or if Length is too short to
+// fit every required character.
+func GeneratePassword(opts PasswordOptions) (string, error) {
+	if opts.Length <= 0 {
+		return "", fmt.Errorf("crypto: password length must be positive, got %d", opts.Length)
+	}
+
+	type class struct {
+		name  string
+		chars string
+	}
+	var required []class
+	var pool string
+	add := func(name, chars string, use bool) {
+		if !use {
+			return
+		}
+		filtered := stripChars(chars, opts.ExcludeChars)
+		pool += filtered
+		required = append(required, class{name: name, chars: filtered})
+	}
+	add("lowercase", lowerChars, opts.UseLower)
+	add("uppercase", upperChars, opts.UseUpper)
+	add("digit", digitChars, opts.UseDigits)
+	add("symbol", symbolChars, opts.UseSymbols)
+
+	if pool == "" {
+		return "", errors.New("crypto: no character classes selected (or all excluded)")
+	}
+	for _, c := range required {
+		if c.chars == "" {
+			return "", fmt.Errorf("crypto: ExcludeChars removes every %s character, so that class can no longer be satisfied", c.name)
+		}
+	}
+
+	requireFromSet := stripChars(opts.RequireFromSet, opts.ExcludeChars)
+	if opts.RequireFromSet != "" && requireFromSet == "" {
+		return "", errors.New("crypto: ExcludeChars removes every character in RequireFromSet, so it can no longer be satisfied")
+	}
+
+	neededSlots := len(required)
+	if requireFromSet != "" {
+		neededSlots++
+	}
+	if opts.Length < neededSlots {
+		return "", fmt.Errorf("crypto: password length %d is too short to fit %d required character(s)", opts.Length, neededSlots)
+	}
+
+	result := make([]byte, opts.Length)
+	pos := 0
+	for _, c := range required {
+		ch, err := randomChar(c.chars)
+		if err != nil {
+			return "", err
+		}
+		result[pos] = ch
+		pos++
+	}
+	if requireFromSet != "" {
+		ch, err := randomChar(requireFromSet)
+		if err != nil {
+			return "", err
+		}
+		result[pos] = ch
+		pos++
+	}
+	for ; pos < opts.Length; pos++ {
+		ch, err := randomChar(pool)
+		if err != nil {
+			return "", err
+		}
+		result[pos] = ch
+	}
+	if err := shuffleBytes(result); err != nil {
+		return "", err
+	}
+	return string(result), nil
+}
+
+// randomChar returns a single character drawn uniformly at random from
+// set via randomIndex.
+func randomChar(set string) (byte, error) {
+	idx, err := randomIndex(len(set))
+	if err != nil {
+		return 0, err
+	}
+	return set[idx], nil
+}
+
+// stripChars returns s with every character in exclude removed.
+func stripChars(s, exclude string) string {
+	if exclude == "" {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(exclude, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// shuffleBytes performs a Fisher-Yates shuffle using crypto/rand so the
+// mandatory-class characters GeneratePassword places first aren't
+// predictably positioned in the result.
+func shuffleBytes(b []byte) error {
+	for i := len(b) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return err
+		}
+		b[i], b[j] = b[j], b[i]
+	}
+	return nil
+}
+
+// Encrypt encrypts plaintext with AES-GCM under key (16, 24, or 32
+// bytes), returning a tag byte recording the key size, followed by the
+// randomly generated nonce, followed by the ciphertext. The tag lets
+// Decrypt detect a key-size mismatch instead of failing on GCM auth.
+func Encrypt(plaintext, key []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+
+	out := make([]byte, 1+len(sealed))
+	out[0] = byte(len(key))
+	copy(out[1:], sealed)
+	return out, nil
+}
+
+// Decrypt reverses Encrypt, validating that key's size matches the tag
+// recorded at encryption time before attempting to open the ciphertext.
+func Decrypt(ciphertext, key []byte) ([]byte, error) {
+	if len(ciphertext) < 1 {
+		return nil, errors.New("crypto: ciphertext too short")
+	}
+	keySize := int(ciphertext[0])
+	if keySize != len(key) {
+		return nil, fmt.Errorf("crypto: key size mismatch: ciphertext was sealed with a %d-byte key, got %d bytes", keySize, len(key))
+	}
+	ciphertext = ciphertext[1:]
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("crypto: ciphertext too short")
+	}
+	nonce, ct := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	return gcm.Open(nil, nonce, ct, nil)
+}
+
+// EstimatePasswordStrengthBits estimates a password's entropy in bits: the
+// number of distinct character classes it draws from (from lowerChars,
+// upperChars, digitChars, symbolChars, and a catch-all for anything
+// else) determines an assumed pool size, and the estimate is
+// log2(poolSize) * len(password). This mirrors how GeneratePassword
+// itself builds a password, so a password this package generated scores
+// close to its true entropy; a human-chosen password with, say, a
+// repeated pattern is scored more generously than it deserves, but that
+// bias is the same one every simple strength meter makes.
+func EstimatePasswordStrengthBits(password string) float64 {
+	if password == "" {
+		return 0
+	}
+
+	var poolSize float64
+	hasLower, hasUpper, hasDigit, hasSymbol, hasOther := false, false, false, false, false
+	for _, r := range password {
+		switch {
+		case strings.ContainsRune(lowerChars, r):
+			hasLower = true
+		case strings.ContainsRune(upperChars, r):
+			hasUpper = true
+		case strings.ContainsRune(digitChars, r):
+			hasDigit = true
+		case strings.ContainsRune(symbolChars, r):
+			hasSymbol = true
+		default:
+			hasOther = true
+		}
+	}
+	if hasLower {
+		poolSize += float64(len(lowerChars))
+	}
+	if hasUpper {
+		poolSize += float64(len(upperChars))
+	}
+	if hasDigit {
+		poolSize += float64(len(digitChars))
+	}
+	if hasSymbol {
+		poolSize += float64(len(symbolChars))
+	}
+	if hasOther {
+		// Unicode letters, punctuation outside symbolChars, etc: assume a
+		// conservative pool the size of the printable ASCII symbol set.
+		poolSize += float64(len(symbolChars))
+	}
+	if poolSize == 0 {
+		return 0
+	}
+	return float64(len([]rune(password))) * math.Log2(poolSize)
+}
@@ -0,0 +1,280 @@
+package crypto
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	for _, keyLen := range []int{16, 24, 32} {
+		key, err := DeriveKeyN("hunter2", []byte("salt"), keyLen)
+		if err != nil {
+			t.Fatalf("DeriveKeyN(%d): %v", keyLen, err)
+		}
+		if len(key) != keyLen {
+			t.Fatalf("expected key length %d, got %d", keyLen, len(key))
+		}
+
+		plaintext := []byte("the quick brown fox")
+		ciphertext, err := Encrypt(plaintext, key)
+		if err != nil {
+			t.Fatalf("Encrypt(%d): %v", keyLen, err)
+		}
+
+		decrypted, err := Decrypt(ciphertext, key)
+		if err != nil {
+			t.Fatalf("Decrypt(%d): %v", keyLen, err)
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Fatalf("round-trip mismatch for key size %d", keyLen)
+		}
+	}
+}
+
+func TestDeriveKeyNRejectsInvalidLength(t *testing.T) {
+	if _, err := DeriveKeyN("password", []byte("salt"), 20); err == nil {
+		t.Fatal("expected error for invalid key length")
+	}
+}
+
+func TestDecryptDetectsKeySizeMismatch(t *testing.T) {
+	key32, _ := DeriveKeyN("password", []byte("salt"), 32)
+	key16, _ := DeriveKeyN("password", []byte("salt"), 16)
+
+	ciphertext, err := Encrypt([]byte("secret"), key32)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	if _, err := Decrypt(ciphertext, key16); err == nil {
+		t.Fatal("expected key size mismatch error")
+	}
+}
+
+func TestGenerateRecoveryKeyIsUniqueAndFormatted(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		key, err := GenerateRecoveryKey()
+		if err != nil {
+			t.Fatalf("GenerateRecoveryKey: %v", err)
+		}
+		if key == "" {
+			t.Fatal("expected non-empty recovery key")
+		}
+		if seen[key] {
+			t.Fatalf("GenerateRecoveryKey produced a duplicate: %s", key)
+		}
+		seen[key] = true
+	}
+}
+
+func TestGeneratePassphraseWordCount(t *testing.T) {
+	passphrase, err := GeneratePassphrase(5, "-")
+	if err != nil {
+		t.Fatalf("GeneratePassphrase: %v", err)
+	}
+	words := strings.Split(passphrase, "-")
+	if len(words) != 5 {
+		t.Fatalf("expected 5 words, got %d in %q", len(words), passphrase)
+	}
+}
+
+func TestGeneratePassphraseUsesGivenSeparator(t *testing.T) {
+	passphrase, err := GeneratePassphrase(3, "_")
+	if err != nil {
+		t.Fatalf("GeneratePassphrase: %v", err)
+	}
+	if strings.Count(passphrase, "_") != 2 {
+		t.Fatalf("expected 2 underscores joining 3 words, got %q", passphrase)
+	}
+	if strings.Contains(passphrase, "-") {
+		t.Fatalf("expected no dashes with a custom separator, got %q", passphrase)
+	}
+}
+
+func TestGeneratePassphraseRejectsNonPositiveWordCount(t *testing.T) {
+	if _, err := GeneratePassphrase(0, "-"); err == nil {
+		t.Fatal("expected an error for zero words")
+	}
+}
+
+// TestGeneratePassphraseCoversFullWordlistRange draws enough passphrases
+// that, if word selection were skewed toward part of the list (e.g. a
+// modulo-biased index), some wordlist entries would never appear.
+func TestGeneratePassphraseCoversFullWordlistRange(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 4000 && len(seen) < PassphraseWordlistSize; i++ {
+		passphrase, err := GeneratePassphrase(1, "-")
+		if err != nil {
+			t.Fatalf("GeneratePassphrase: %v", err)
+		}
+		seen[passphrase] = true
+	}
+	if len(seen) != PassphraseWordlistSize {
+		t.Fatalf("expected all %d wordlist entries to appear, saw %d", PassphraseWordlistSize, len(seen))
+	}
+}
+
+func TestGenerateSaltIsUniqueAndSized(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		salt, err := GenerateSalt()
+		if err != nil {
+			t.Fatalf("GenerateSalt: %v", err)
+		}
+		if len(salt) != SaltSize {
+			t.Fatalf("expected salt of length %d, got %d", SaltSize, len(salt))
+		}
+		key := string(salt)
+		if seen[key] {
+			t.Fatalf("GenerateSalt produced a duplicate")
+		}
+		seen[key] = true
+	}
+}
+
+func TestGeneratePasswordRespectsExcludeChars(t *testing.T) {
+	opts := PasswordOptions{
+		Length:       24,
+		UseLower:     true,
+		UseUpper:     true,
+		UseDigits:    true,
+		ExcludeChars: "il1O0o",
+	}
+	for i := 0; i < 50; i++ {
+		password, err := GeneratePassword(opts)
+		if err != nil {
+			t.Fatalf("GeneratePassword: %v", err)
+		}
+		if len(password) != opts.Length {
+			t.Fatalf("expected length %d, got %d in %q", opts.Length, len(password), password)
+		}
+		if strings.ContainsAny(password, opts.ExcludeChars) {
+			t.Fatalf("password %q contains an excluded character", password)
+		}
+	}
+}
+
+func TestGeneratePasswordHonorsRequireFromSet(t *testing.T) {
+	opts := PasswordOptions{
+		Length:         12,
+		UseLower:       true,
+		RequireFromSet: "!@#",
+	}
+	for i := 0; i < 20; i++ {
+		password, err := GeneratePassword(opts)
+		if err != nil {
+			t.Fatalf("GeneratePassword: %v", err)
+		}
+		if !strings.ContainsAny(password, opts.RequireFromSet) {
+			t.Fatalf("password %q contains none of RequireFromSet %q", password, opts.RequireFromSet)
+		}
+	}
+}
+
+func TestGeneratePasswordErrorsWhenExclusionEmptiesAClass(t *testing.T) {
+	opts := PasswordOptions{
+		Length:       8,
+		UseDigits:    true,
+		ExcludeChars: "0123456789",
+	}
+	if _, err := GeneratePassword(opts); err == nil {
+		t.Fatal("expected an error when ExcludeChars removes every digit")
+	}
+}
+
+func TestGeneratePasswordErrorsWhenExclusionEmptiesRequireFromSet(t *testing.T) {
+	opts := PasswordOptions{
+		Length:         8,
+		UseLower:       true,
+		RequireFromSet: "!@#",
+		ExcludeChars:   "!@#",
+	}
+	if _, err := GeneratePassword(opts); err == nil {
+		t.Fatal("expected an error when ExcludeChars removes every character in RequireFromSet")
+	}
+}
+
+func TestGeneratePasswordErrorsWhenLengthTooShortForRequiredClasses(t *testing.T) {
+	opts := PasswordOptions{
+		Length:     2,
+		UseLower:   true,
+		UseUpper:   true,
+		UseDigits:  true,
+		UseSymbols: true,
+	}
+	if _, err := GeneratePassword(opts); err == nil {
+		t.Fatal("expected an error when length is too short to fit every required class")
+	}
+}
+
+func TestGeneratePasswordErrorsWhenNoClassSelected(t *testing.T) {
+	if _, err := GeneratePassword(PasswordOptions{Length: 8}); err == nil {
+		t.Fatal("expected an error when no character classes are selected")
+	}
+}
+
+func TestRecoveryKeyWrapsAndUnwrapsDataKey(t *testing.T) {
+	recoveryKey, err := GenerateRecoveryKey()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryKey: %v", err)
+	}
+
+	dek := []byte("0123456789abcdef0123456789abcdef")[:KeySize]
+	wrapped, err := Encrypt(dek, DeriveKey(recoveryKey, []byte("alice")))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	unwrapped, err := Decrypt(wrapped, DeriveKey(recoveryKey, []byte("alice")))
+	if err != nil {
+		t.Fatalf("Decrypt with correct recovery key: %v", err)
+	}
+	if !bytes.Equal(unwrapped, dek) {
+		t.Fatal("expected unwrapped data key to match original")
+	}
+
+	wrongKey, err := GenerateRecoveryKey()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryKey: %v", err)
+	}
+	if _, err := Decrypt(wrapped, DeriveKey(wrongKey, []byte("alice"))); err == nil {
+		t.Fatal("expected wrong recovery key to fail to unwrap the data key")
+	}
+}
+
+func TestKeyFromPasswordAndSaltReproducesNewKeyFromPassword(t *testing.T) {
+	key, salt, err := NewKeyFromPassword("hunter2")
+	if err != nil {
+		t.Fatalf("NewKeyFromPassword: %v", err)
+	}
+	if len(key) != KeySize {
+		t.Fatalf("expected key of length %d, got %d", KeySize, len(key))
+	}
+	if len(salt) != SaltSize {
+		t.Fatalf("expected salt of length %d, got %d", SaltSize, len(salt))
+	}
+
+	rederived := KeyFromPasswordAndSalt("hunter2", salt)
+	if !bytes.Equal(key, rederived) {
+		t.Fatal("KeyFromPasswordAndSalt did not reproduce the key derived by NewKeyFromPassword")
+	}
+}
+
+func TestNewKeyFromPasswordDivergesAcrossSalts(t *testing.T) {
+	key1, salt1, err := NewKeyFromPassword("hunter2")
+	if err != nil {
+		t.Fatalf("NewKeyFromPassword: %v", err)
+	}
+	key2, salt2, err := NewKeyFromPassword("hunter2")
+	if err != nil {
+		t.Fatalf("NewKeyFromPassword: %v", err)
+	}
+	if bytes.Equal(salt1, salt2) {
+		t.Fatal("expected two calls to generate different salts")
+	}
+	if bytes.Equal(key1, key2) {
+		t.Fatal("expected keys derived under different salts to diverge")
+	}
+}
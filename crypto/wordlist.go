@@ -0,0 +1,27 @@
+package crypto
+
+// passphraseWordlist is a short, fixed wordlist used by GeneratePassphrase.
+// It's intentionally not a full 7776-word diceware list — just enough
+// distinct, easy-to-type words that a handful of them concatenated give a
+// memorable, high-entropy passphrase.
+var passphraseWordlist = []string{
+	"anchor", "banner", "canyon", "dagger", "ember", "falcon", "glacier", "harbor",
+	"island", "jungle", "kernel", "lantern", "meadow", "nectar", "oyster", "pebble",
+	"quartz", "raptor", "summit", "temple", "umbrella", "velvet", "walnut", "xenon",
+	"yonder", "zephyr", "amber", "birch", "cobalt", "drizzle", "echo", "fable",
+	"granite", "hollow", "ivory", "jasper", "kindle", "lagoon", "marble", "nimbus",
+	"orbit", "pillar", "quiver", "ridge", "sable", "thistle", "utopia", "vertex",
+	"willow", "yeoman", "zenith", "acorn", "basalt", "clover", "delta", "ferret",
+	"gopher", "heron", "indigo", "juniper", "koala", "lily", "mantle", "nova",
+	"opal", "pixel", "quokka", "raven", "scarlet", "tundra", "urchin", "violet",
+	"wren", "yarrow", "zircon", "alder", "brook", "cedar", "dune", "ebony",
+	"fern", "grove", "hazel", "iris", "jade", "knoll", "lark", "moss",
+	"nettle", "olive", "pine", "quail", "reed", "sage", "thorn", "vale",
+	"wisp", "yew", "zinnia", "aspen", "briar", "creek", "dove", "elm",
+	"fjord", "gale", "heath", "ivy", "jetty", "knox", "loch", "moor",
+	"nook", "oak", "peak", "quill", "rapids", "spruce", "tide", "vane",
+}
+
+// PassphraseWordlistSize is the number of distinct words GeneratePassphrase
+// can draw from.
+var PassphraseWordlistSize = len(passphraseWordlist)
@@ -0,0 +1,66 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrMessageTooLarge is returned by ReadMessage when a header declares a
+// payload length exceeding the maxLen it was called with.
+var ErrMessageTooLarge = errors.New("declared message length exceeds the limit")
+
+// ReadMessage reads one framed message (a MessageHeader followed by its
+// payload) off r. It's the single place client and server framing
+// logic lives, so both get exactly the same behavior on a split read,
+// a coalesced read of several messages back-to-back, or a stream
+// truncated mid-header or mid-payload (io.ReadFull's usual io.EOF /
+// io.ErrUnexpectedEOF).
+//
+// maxLen caps the declared payload length: if header.Length exceeds it,
+// ReadMessage returns ErrMessageTooLarge (along with the parsed header,
+// so a caller can drain or otherwise account for the declared bytes)
+// before allocating a buffer for the payload. maxLen of 0 means
+// unlimited.
+func ReadMessage(r io.Reader, maxLen uint32) (MessageHeader, []byte, error) {
+	headerBuf := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(r, headerBuf); err != nil {
+		return MessageHeader{}, nil, err
+	}
+	header := MessageHeader{
+		Type:      headerBuf[0],
+		MessageID: binary.BigEndian.Uint32(headerBuf[1:5]),
+		Length:    binary.BigEndian.Uint32(headerBuf[5:9]),
+	}
+
+	if maxLen > 0 && header.Length > maxLen {
+		return header, nil, ErrMessageTooLarge
+	}
+
+	payload := make([]byte, header.Length)
+	if header.Length > 0 {
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return header, nil, err
+		}
+	}
+	return header, payload, nil
+}
+
+// WriteMessage writes one framed message (a MessageHeader followed by
+// data) to w.
+func WriteMessage(w io.Writer, msgType uint8, id uint32, data []byte) error {
+	header := make([]byte, HeaderSize)
+	header[0] = msgType
+	binary.BigEndian.PutUint32(header[1:5], id)
+	binary.BigEndian.PutUint32(header[5:9], uint32(len(data)))
+
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
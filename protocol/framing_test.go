@@ -0,0 +1,161 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+// slowReader dribbles out data a few bytes at a time, simulating a
+// message split across several TCP reads.
+type slowReader struct {
+	data  []byte
+	chunk int
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := r.chunk
+	if n > len(p) {
+		n = len(p)
+	}
+	if n > len(r.data) {
+		n = len(r.data)
+	}
+	copy(p, r.data[:n])
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestReadMessageAcrossSplitReads(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMessage(&buf, MsgTypePingRequest, 42, []byte("hello")); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+
+	header, payload, err := ReadMessage(&slowReader{data: buf.Bytes(), chunk: 3}, 0)
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if header.Type != MsgTypePingRequest || header.MessageID != 42 {
+		t.Fatalf("unexpected header: %+v", header)
+	}
+	if string(payload) != "hello" {
+		t.Fatalf("payload = %q, want %q", payload, "hello")
+	}
+}
+
+func TestReadMessageCoalescedMessages(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMessage(&buf, MsgTypePingRequest, 1, []byte("first")); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+	if err := WriteMessage(&buf, MsgTypePingResponse, 2, []byte("second")); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+
+	header1, payload1, err := ReadMessage(&buf, 0)
+	if err != nil {
+		t.Fatalf("ReadMessage (first): %v", err)
+	}
+	if header1.MessageID != 1 || string(payload1) != "first" {
+		t.Fatalf("first message = %+v %q, want id=1 payload=first", header1, payload1)
+	}
+
+	header2, payload2, err := ReadMessage(&buf, 0)
+	if err != nil {
+		t.Fatalf("ReadMessage (second): %v", err)
+	}
+	if header2.MessageID != 2 || string(payload2) != "second" {
+		t.Fatalf("second message = %+v %q, want id=2 payload=second", header2, payload2)
+	}
+}
+
+func TestReadMessageTruncatedHeaderReturnsUnexpectedEOF(t *testing.T) {
+	_, _, err := ReadMessage(bytes.NewReader([]byte{0x01, 0x02, 0x03}), 0)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestReadMessageTruncatedPayloadReturnsUnexpectedEOF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMessage(&buf, MsgTypePingRequest, 1, []byte("hello")); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+	truncated := buf.Bytes()[:HeaderSize+2]
+
+	_, _, err := ReadMessage(bytes.NewReader(truncated), 0)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+// hugeLengthReader hands out a header declaring an implausibly large
+// payload and then, if asked to read any more, fails the test: a
+// correct ReadMessage must reject the declared length before trying to
+// allocate or read a payload buffer for it.
+type hugeLengthReader struct {
+	t        *testing.T
+	header   []byte
+	consumed bool
+}
+
+func newHugeLengthReader(t *testing.T) *hugeLengthReader {
+	header := make([]byte, HeaderSize)
+	header[0] = MsgTypePingRequest
+	binary.BigEndian.PutUint32(header[1:5], 1)
+	binary.BigEndian.PutUint32(header[5:9], 1<<30)
+	return &hugeLengthReader{t: t, header: header}
+}
+
+func (r *hugeLengthReader) Read(p []byte) (int, error) {
+	if !r.consumed {
+		r.consumed = true
+		n := copy(p, r.header)
+		return n, nil
+	}
+	r.t.Fatal("ReadMessage read past the header despite an oversized declared length")
+	return 0, io.EOF
+}
+
+func TestReadMessageRejectsOversizedLengthWithoutAllocatingPayload(t *testing.T) {
+	_, _, err := ReadMessage(newHugeLengthReader(t), 1024)
+	if !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("expected ErrMessageTooLarge, got %v", err)
+	}
+}
+
+func TestReadMessageAllowsMessageWithinMaxLen(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMessage(&buf, MsgTypePingRequest, 1, []byte("hello")); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+
+	header, payload, err := ReadMessage(&buf, 1024)
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if header.MessageID != 1 || string(payload) != "hello" {
+		t.Fatalf("header=%+v payload=%q, want id=1 payload=hello", header, payload)
+	}
+}
+
+func TestWriteMessageThenReadMessageRoundTripsEmptyPayload(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteMessage(&buf, MsgTypePingRequest, 7, nil); err != nil {
+		t.Fatalf("WriteMessage: %v", err)
+	}
+
+	header, payload, err := ReadMessage(&buf, 0)
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	if header.MessageID != 7 || len(payload) != 0 {
+		t.Fatalf("header=%+v payload=%q, want id=7 empty payload", header, payload)
+	}
+}
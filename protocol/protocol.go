@@ -0,0 +1,350 @@
+// Package protocol defines the wire format shared by the password manager
+// client and server: the fixed-size message header, message/data type
+// identifiers, and the JSON request/response payloads exchanged once a
+// connection is established.
+package protocol
+
+import "fmt"
+
+// HeaderSize is the size in bytes of a MessageHeader once encoded:
+// 1 byte type + 4 bytes message ID + 4 bytes payload length.
+const HeaderSize = 9
+
+// Version identifies this build's protocol revision. A server reports it
+// in PingResponse so a client can detect an incompatible peer (see
+// Client.Diagnose) before attempting to authenticate against it.
+const Version = 1
+
+// MessageHeader precedes every JSON payload sent over the wire.
+type MessageHeader struct {
+	Type      uint8
+	MessageID uint32
+	Length    uint32
+}
+
+// Message types.
+const (
+	MsgTypeRegisterRequest  uint8 = 0x01
+	MsgTypeRegisterResponse uint8 = 0x02
+	MsgTypeAuthRequest      uint8 = 0x03
+	MsgTypeAuthResponse     uint8 = 0x04
+
+	MsgTypeStoreDataRequest  uint8 = 0x05
+	MsgTypeStoreDataResponse uint8 = 0x06
+
+	MsgTypeGetDataRequest  uint8 = 0x07
+	MsgTypeGetDataResponse uint8 = 0x08
+
+	MsgTypeSyncRequest  uint8 = 0x09
+	MsgTypeSyncResponse uint8 = 0x0A
+
+	MsgTypeUpdateDataRequest  uint8 = 0x0B
+	MsgTypeUpdateDataResponse uint8 = 0x0C
+
+	MsgTypeDeleteDataRequest  uint8 = 0x0D
+	MsgTypeDeleteDataResponse uint8 = 0x0E
+
+	MsgTypeDownloadRequest  uint8 = 0x0F
+	MsgTypeDownloadResponse uint8 = 0x10
+
+	MsgTypeStatsRequest  uint8 = 0x11
+	MsgTypeStatsResponse uint8 = 0x12
+
+	MsgTypeRestoreDataRequest  uint8 = 0x13
+	MsgTypeRestoreDataResponse uint8 = 0x14
+
+	MsgTypeRecoveryInfoRequest  uint8 = 0x15
+	MsgTypeRecoveryInfoResponse uint8 = 0x16
+	MsgTypeRecoverRequest       uint8 = 0x17
+	MsgTypeRecoverResponse      uint8 = 0x18
+
+	MsgTypeRenameUserRequest  uint8 = 0x19
+	MsgTypeRenameUserResponse uint8 = 0x1A
+
+	MsgTypeListModifiedRequest  uint8 = 0x1B
+	MsgTypeListModifiedResponse uint8 = 0x1C
+
+	MsgTypeRekeyRequest  uint8 = 0x1D
+	MsgTypeRekeyResponse uint8 = 0x1E
+
+	MsgTypeUpdateMetadataRequest  uint8 = 0x1F
+	MsgTypeUpdateMetadataResponse uint8 = 0x20
+
+	MsgTypeCreateShareRequest  uint8 = 0x21
+	MsgTypeCreateShareResponse uint8 = 0x22
+
+	MsgTypeRedeemShareRequest  uint8 = 0x23
+	MsgTypeRedeemShareResponse uint8 = 0x24
+
+	MsgTypePingRequest  uint8 = 0x25
+	MsgTypePingResponse uint8 = 0x26
+
+	MsgTypeAutocompleteRequest  uint8 = 0x27
+	MsgTypeAutocompleteResponse uint8 = 0x28
+
+	MsgTypeBatchDeleteRequest  uint8 = 0x29
+	MsgTypeBatchDeleteResponse uint8 = 0x2A
+
+	// MsgTypeSyncStreamRequest starts a streaming sync: the server
+	// answers with one MsgTypeSyncBegin, then one MsgTypeSyncItem per
+	// item, then one MsgTypeSyncEnd, instead of a single MsgTypeSyncResponse
+	// JSON array — see SyncBeginResponse, SyncItemResponse, SyncEndResponse.
+	MsgTypeSyncStreamRequest uint8 = 0x2B
+	MsgTypeSyncBegin         uint8 = 0x2C
+	MsgTypeSyncItem          uint8 = 0x2D
+	MsgTypeSyncEnd           uint8 = 0x2E
+
+	MsgTypeAttachFileRequest  uint8 = 0x2F
+	MsgTypeAttachFileResponse uint8 = 0x30
+
+	MsgTypeListAttachmentsRequest  uint8 = 0x31
+	MsgTypeListAttachmentsResponse uint8 = 0x32
+
+	MsgTypeDownloadAttachmentRequest  uint8 = 0x33
+	MsgTypeDownloadAttachmentResponse uint8 = 0x34
+
+	MsgTypeDeleteAttachmentRequest  uint8 = 0x35
+	MsgTypeDeleteAttachmentResponse uint8 = 0x36
+
+	// MsgTypeManifestRequest asks for a lightweight summary of every item
+	// (id, updated_at, content_hash) instead of full item bodies, so the
+	// client can diff it against what it already has and fetch only the
+	// items that actually changed.
+	MsgTypeManifestRequest  uint8 = 0x37
+	MsgTypeManifestResponse uint8 = 0x38
+
+	MsgTypeErrorResponse uint8 = 0xFF
+)
+
+// MsgType names a message type for logging: MsgType(header.Type).String()
+// reads "AuthRequest" instead of a bare "3". The wire format itself
+// stays a plain uint8 header field; this is purely a debugging aid.
+type MsgType uint8
+
+// String returns a human-readable name for t, or "MsgType(N)" for a
+// value that isn't one of the MsgType* constants.
+func (t MsgType) String() string {
+	switch uint8(t) {
+	case MsgTypeRegisterRequest:
+		return "RegisterRequest"
+	case MsgTypeRegisterResponse:
+		return "RegisterResponse"
+	case MsgTypeAuthRequest:
+		return "AuthRequest"
+	case MsgTypeAuthResponse:
+		return "AuthResponse"
+	case MsgTypeStoreDataRequest:
+		return "StoreDataRequest"
+	case MsgTypeStoreDataResponse:
+		return "StoreDataResponse"
+	case MsgTypeGetDataRequest:
+		return "GetDataRequest"
+	case MsgTypeGetDataResponse:
+		return "GetDataResponse"
+	case MsgTypeSyncRequest:
+		return "SyncRequest"
+	case MsgTypeSyncResponse:
+		return "SyncResponse"
+	case MsgTypeUpdateDataRequest:
+		return "UpdateDataRequest"
+	case MsgTypeUpdateDataResponse:
+		return "UpdateDataResponse"
+	case MsgTypeDeleteDataRequest:
+		return "DeleteDataRequest"
+	case MsgTypeDeleteDataResponse:
+		return "DeleteDataResponse"
+	case MsgTypeDownloadRequest:
+		return "DownloadRequest"
+	case MsgTypeDownloadResponse:
+		return "DownloadResponse"
+	case MsgTypeStatsRequest:
+		return "StatsRequest"
+	case MsgTypeStatsResponse:
+		return "StatsResponse"
+	case MsgTypeRestoreDataRequest:
+		return "RestoreDataRequest"
+	case MsgTypeRestoreDataResponse:
+		return "RestoreDataResponse"
+	case MsgTypeRecoveryInfoRequest:
+		return "RecoveryInfoRequest"
+	case MsgTypeRecoveryInfoResponse:
+		return "RecoveryInfoResponse"
+	case MsgTypeRecoverRequest:
+		return "RecoverRequest"
+	case MsgTypeRecoverResponse:
+		return "RecoverResponse"
+	case MsgTypeRenameUserRequest:
+		return "RenameUserRequest"
+	case MsgTypeRenameUserResponse:
+		return "RenameUserResponse"
+	case MsgTypeListModifiedRequest:
+		return "ListModifiedRequest"
+	case MsgTypeListModifiedResponse:
+		return "ListModifiedResponse"
+	case MsgTypeRekeyRequest:
+		return "RekeyRequest"
+	case MsgTypeRekeyResponse:
+		return "RekeyResponse"
+	case MsgTypeUpdateMetadataRequest:
+		return "UpdateMetadataRequest"
+	case MsgTypeUpdateMetadataResponse:
+		return "UpdateMetadataResponse"
+	case MsgTypeCreateShareRequest:
+		return "CreateShareRequest"
+	case MsgTypeCreateShareResponse:
+		return "CreateShareResponse"
+	case MsgTypeRedeemShareRequest:
+		return "RedeemShareRequest"
+	case MsgTypeRedeemShareResponse:
+		return "RedeemShareResponse"
+	case MsgTypePingRequest:
+		return "PingRequest"
+	case MsgTypePingResponse:
+		return "PingResponse"
+	case MsgTypeAutocompleteRequest:
+		return "AutocompleteRequest"
+	case MsgTypeAutocompleteResponse:
+		return "AutocompleteResponse"
+	case MsgTypeBatchDeleteRequest:
+		return "BatchDeleteRequest"
+	case MsgTypeBatchDeleteResponse:
+		return "BatchDeleteResponse"
+	case MsgTypeSyncStreamRequest:
+		return "SyncStreamRequest"
+	case MsgTypeSyncBegin:
+		return "SyncBegin"
+	case MsgTypeSyncItem:
+		return "SyncItem"
+	case MsgTypeSyncEnd:
+		return "SyncEnd"
+	case MsgTypeAttachFileRequest:
+		return "AttachFileRequest"
+	case MsgTypeAttachFileResponse:
+		return "AttachFileResponse"
+	case MsgTypeListAttachmentsRequest:
+		return "ListAttachmentsRequest"
+	case MsgTypeListAttachmentsResponse:
+		return "ListAttachmentsResponse"
+	case MsgTypeDownloadAttachmentRequest:
+		return "DownloadAttachmentRequest"
+	case MsgTypeDownloadAttachmentResponse:
+		return "DownloadAttachmentResponse"
+	case MsgTypeDeleteAttachmentRequest:
+		return "DeleteAttachmentRequest"
+	case MsgTypeDeleteAttachmentResponse:
+		return "DeleteAttachmentResponse"
+	case MsgTypeManifestRequest:
+		return "ManifestRequest"
+	case MsgTypeManifestResponse:
+		return "ManifestResponse"
+	case MsgTypeErrorResponse:
+		return "ErrorResponse"
+	default:
+		return fmt.Sprintf("MsgType(%d)", uint8(t))
+	}
+}
+
+// Data types.
+const (
+	DataTypeLogin  uint8 = 0x01
+	DataTypeCard   uint8 = 0x02
+	DataTypeText   uint8 = 0x03
+	DataTypeBinary uint8 = 0x04
+
+	DataTypeWiFi uint8 = 0x08
+)
+
+// DataType names a data type for logging: DataType(item.Type).String()
+// reads "Login" instead of a bare "1". DataItem.Type itself stays a
+// plain uint8 on the wire; this is purely a debugging aid.
+type DataType uint8
+
+// String returns a human-readable name for t, or "DataType(N)" for a
+// value that isn't one of the DataType* constants.
+func (t DataType) String() string {
+	switch uint8(t) {
+	case DataTypeLogin:
+		return "Login"
+	case DataTypeCard:
+		return "Card"
+	case DataTypeText:
+		return "Text"
+	case DataTypeBinary:
+		return "Binary"
+	case DataTypeWiFi:
+		return "WiFi"
+	default:
+		return fmt.Sprintf("DataType(%d)", uint8(t))
+	}
+}
+
+// IsValidDataType reports whether t is one of the known DataType*
+// constants. Callers accepting a type from a client (e.g. StoreData,
+// UpdateData) should check this before storing it, so an unrecognized
+// value is rejected up front instead of silently persisting and later
+// rendering as an unknown type.
+func IsValidDataType(t uint8) bool {
+	switch t {
+	case DataTypeLogin, DataTypeCard, DataTypeText, DataTypeBinary, DataTypeWiFi:
+		return true
+	default:
+		return false
+	}
+}
+
+// Well-known metadata keys used across the client and server so field
+// names don't drift between the code that writes them and the code that
+// reads them back.
+const (
+	MetaOriginalFileName = "original_file_name"
+	MetaOriginalSize     = "original_size"
+	MetaURL              = "url"
+
+	// MetaTags and MetaFavorite are optional, client-set metadata: a
+	// comma-separated tag list and a "true"/"false" favorite flag. Unlike
+	// the fields above, nothing currently sets them server-side, so
+	// callers should expect them to be absent on most items.
+	MetaTags     = "tags"
+	MetaFavorite = "favorite"
+
+	// MetaChecksumSHA256 holds a hex-encoded SHA-256 of an item's
+	// decrypted Data, set by the client at save time so a later decrypt
+	// can detect corruption (or a wrong key) that GCM's own integrity
+	// check wouldn't catch, since GCM only proves the ciphertext wasn't
+	// tampered with under whatever key was used to decrypt it.
+	MetaChecksumSHA256 = "checksum_sha256"
+
+	// MetaPasswordHistory holds a comma-separated list of a login item's
+	// previous passwords, newest first, set by the client when a
+	// password is regenerated in place so the old value isn't lost
+	// outright.
+	MetaPasswordHistory = "password_history"
+
+	// MetaCompression records which compression, if any, was applied to
+	// Data before encryption; its absence means Data was stored
+	// uncompressed. See CompressionGzip.
+	MetaCompression = "compression"
+)
+
+// CompressionGzip is MetaCompression's value when Data was gzip-compressed
+// before encryption.
+const CompressionGzip = "gzip"
+
+// Well-known keys inside a DataItem's decrypted payload map, one set per
+// data type, so the code that builds an item and the code that later
+// reads it back can't drift apart on a typo'd field name.
+const (
+	FieldLogin    = "login"
+	FieldPassword = "password"
+
+	FieldCardNumber = "number"
+	FieldCardExpiry = "expiry"
+	FieldCardCVV    = "cvv"
+	FieldCardHolder = "holder"
+
+	FieldText = "text"
+
+	FieldWiFiSSID         = "ssid"
+	FieldWiFiPassword     = "password"
+	FieldWiFiSecurityType = "security_type"
+)
@@ -0,0 +1,54 @@
+package protocol
+
+import "testing"
+
+func TestMsgTypeStringKnownValues(t *testing.T) {
+	tests := []struct {
+		t    MsgType
+		want string
+	}{
+		{MsgType(MsgTypeRegisterRequest), "RegisterRequest"},
+		{MsgType(MsgTypeAuthResponse), "AuthResponse"},
+		{MsgType(MsgTypeDownloadRequest), "DownloadRequest"},
+		{MsgType(MsgTypePingResponse), "PingResponse"},
+		{MsgType(MsgTypeErrorResponse), "ErrorResponse"},
+	}
+	for _, tt := range tests {
+		if got := tt.t.String(); got != tt.want {
+			t.Errorf("MsgType(%d).String() = %q, want %q", uint8(tt.t), got, tt.want)
+		}
+	}
+}
+
+func TestMsgTypeStringUnknownValue(t *testing.T) {
+	got := MsgType(0x99).String()
+	want := "MsgType(153)"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDataTypeStringKnownValues(t *testing.T) {
+	tests := []struct {
+		t    DataType
+		want string
+	}{
+		{DataType(DataTypeLogin), "Login"},
+		{DataType(DataTypeCard), "Card"},
+		{DataType(DataTypeText), "Text"},
+		{DataType(DataTypeBinary), "Binary"},
+	}
+	for _, tt := range tests {
+		if got := tt.t.String(); got != tt.want {
+			t.Errorf("DataType(%d).String() = %q, want %q", uint8(tt.t), got, tt.want)
+		}
+	}
+}
+
+func TestDataTypeStringUnknownValue(t *testing.T) {
+	got := DataType(0x7F).String()
+	want := "DataType(127)"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,507 @@
+package protocol
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DataItem is a single vault entry as returned by the server. Data holds
+// the encrypted payload; the server never sees it in plaintext. Notes is
+// an optional encrypted free-text note attached to the item, sharing the
+// same encryption as Data but kept in its own field so a caller can
+// update one without re-encrypting the other.
+type DataItem struct {
+	ID        string            `json:"id"`
+	Type      uint8             `json:"type"`
+	Name      string            `json:"name"`
+	Data      []byte            `json:"data"`
+	Notes     []byte            `json:"notes,omitempty"`
+	Metadata  map[string]string `json:"metadata"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// NewDataItem is the client-supplied shape for creating or updating an
+// item; it omits server-assigned fields like ID and timestamps.
+type NewDataItem struct {
+	Type     uint8             `json:"type"`
+	Name     string            `json:"name"`
+	Data     []byte            `json:"data"`
+	Notes    []byte            `json:"notes,omitempty"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// SerializeDataItem marshals a DataItem for inclusion in a message
+// payload.
+func SerializeDataItem(item DataItem) ([]byte, error) {
+	return json.Marshal(item)
+}
+
+// DeserializeDataItem is the inverse of SerializeDataItem.
+func DeserializeDataItem(data []byte) (DataItem, error) {
+	var item DataItem
+	err := json.Unmarshal(data, &item)
+	return item, err
+}
+
+type RegisterRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+
+	// WrappedDEK is the client's data-encryption key, encrypted under a
+	// key derived from Password. The server stores it opaquely.
+	WrappedDEK []byte `json:"wrapped_dek"`
+
+	// KDFSalt is the random salt the client used, alongside Password, to
+	// derive the key that produced WrappedDEK. The server stores and
+	// later returns it so every device derives the same key, instead of
+	// deriving it from data like the username that can change.
+	KDFSalt []byte `json:"kdf_salt"`
+
+	// RecoveryKey and WrappedDEKRecovery are set together, only when the
+	// account opts into recovery: RecoveryKey is hashed and stored so a
+	// later recovery request can prove possession, and
+	// WrappedDEKRecovery is the same DEK encrypted under a key derived
+	// from RecoveryKey instead of Password.
+	RecoveryKey        string `json:"recovery_key,omitempty"`
+	WrappedDEKRecovery []byte `json:"wrapped_dek_recovery,omitempty"`
+
+	// ClientVersion is this client's protocol Version, so a server
+	// enforcing Server.MinProtocolVersion can reject it before creating
+	// an account. Zero (an old client that predates this field) is
+	// rejected by any minimum above zero.
+	ClientVersion int `json:"client_version,omitempty"`
+}
+
+type RegisterResponse struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+type AuthRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+
+	// ClientVersion is this client's protocol Version, so a server
+	// enforcing Server.MinProtocolVersion can reject it before creating
+	// a session. Zero (an old client that predates this field) is
+	// rejected by any minimum above zero.
+	ClientVersion int `json:"client_version,omitempty"`
+}
+
+type AuthResponse struct {
+	Success bool   `json:"success"`
+	Token   string `json:"token,omitempty"`
+	Error   string `json:"error,omitempty"`
+
+	// PasswordAge is how many days old the master password is, and
+	// RotationDue is set once it exceeds the server's configured
+	// maximum age.
+	PasswordAge int  `json:"password_age_days,omitempty"`
+	RotationDue bool `json:"rotation_due,omitempty"`
+
+	// WrappedDEK is the caller's data-encryption key, encrypted under a
+	// key derived from their password. The client unwraps it locally.
+	WrappedDEK []byte `json:"wrapped_dek,omitempty"`
+
+	// KDFSalt is the salt stored at registration, needed alongside the
+	// password to re-derive the key that unwraps WrappedDEK.
+	KDFSalt []byte `json:"kdf_salt,omitempty"`
+}
+
+type StoreDataRequest struct {
+	Token string      `json:"token"`
+	Item  NewDataItem `json:"item"`
+}
+
+type StoreDataResponse struct {
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+
+	// Item is the full stored item, including the server-assigned
+	// CreatedAt/UpdatedAt timestamps, so a client can update its local
+	// state without a follow-up GetData call. It's the zero DataItem on
+	// error.
+	Item DataItem `json:"item,omitempty"`
+}
+
+type GetDataRequest struct {
+	Token string `json:"token"`
+	ID    string `json:"id"`
+}
+
+type GetDataResponse struct {
+	Item  DataItem `json:"item"`
+	Error string   `json:"error,omitempty"`
+}
+
+type SyncRequest struct {
+	Token string    `json:"token"`
+	Since time.Time `json:"since"`
+
+	// MetadataOnly, when set, asks the server to omit each item's Data
+	// blob from the response, so a listing sync doesn't pay to transfer
+	// ciphertext the caller isn't ready to decrypt yet. Use GetData to
+	// fetch a specific item's blob afterward.
+	MetadataOnly bool `json:"metadata_only,omitempty"`
+}
+
+type SyncResponse struct {
+	Items []DataItem `json:"items"`
+	Error string     `json:"error,omitempty"`
+}
+
+// SyncStreamRequest is a SyncRequest answered as a stream of messages
+// (MsgTypeSyncBegin, then one MsgTypeSyncItem per item, then
+// MsgTypeSyncEnd) instead of a single SyncResponse, so a very large
+// vault can be processed incrementally instead of buffered as one JSON
+// array.
+type SyncStreamRequest struct {
+	Token        string    `json:"token"`
+	Since        time.Time `json:"since"`
+	MetadataOnly bool      `json:"metadata_only,omitempty"`
+}
+
+// SyncBeginResponse is sent once, before any SyncItemResponse, so the
+// receiver knows how many items to expect. An Error here means the
+// stream never starts: no SyncItemResponse or SyncEndResponse follows.
+type SyncBeginResponse struct {
+	Count int    `json:"count"`
+	Error string `json:"error,omitempty"`
+}
+
+// SyncItemResponse carries one item of a streaming sync.
+type SyncItemResponse struct {
+	Item DataItem `json:"item"`
+}
+
+// SyncEndResponse terminates a streaming sync. An Error here means the
+// stream was cut short partway through and the receiver should discard
+// what it collected rather than treat it as a complete sync.
+type SyncEndResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+type UpdateDataRequest struct {
+	Token string      `json:"token"`
+	ID    string      `json:"id"`
+	Item  NewDataItem `json:"item"`
+}
+
+type UpdateDataResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+type DeleteDataRequest struct {
+	Token string `json:"token"`
+	ID    string `json:"id"`
+}
+
+type DeleteDataResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+// BatchDeleteRequest asks the server to delete every item in IDs,
+// continuing past per-item failures (e.g. an already-deleted or
+// nonexistent ID) instead of aborting the whole batch.
+type BatchDeleteRequest struct {
+	Token string   `json:"token"`
+	IDs   []string `json:"ids"`
+}
+
+// BatchDeleteResult reports the outcome of deleting one item: Error is
+// empty on success.
+type BatchDeleteResult struct {
+	ID    string `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+type BatchDeleteResponse struct {
+	Results []BatchDeleteResult `json:"results"`
+	Error   string              `json:"error,omitempty"`
+}
+
+// DownloadRequest fetches an item's stored payload, optionally scoped to
+// a byte range for chunked/resumable transfer over unreliable links.
+// Offset and Length are both zero for an ordinary whole-file download;
+// Length zero with a positive Offset means "everything from Offset to
+// the end", the same convention resuming a partial download uses.
+type DownloadRequest struct {
+	Token  string `json:"token"`
+	ID     string `json:"id"`
+	Offset int64  `json:"offset,omitempty"`
+	Length int64  `json:"length,omitempty"`
+}
+
+// DownloadResponse carries the requested (possibly partial) slice of the
+// item's stored payload plus TotalSize, so a chunked client knows how
+// many more bytes remain without a separate stat request. ChecksumSHA256
+// is a hex-encoded SHA-256 of the item's *full* stored ciphertext
+// (regardless of any Offset/Length in the request), letting a client
+// that has reassembled every chunk confirm it matches what the server
+// holds before decrypting.
+type DownloadResponse struct {
+	Data           []byte `json:"data"`
+	TotalSize      int64  `json:"total_size"`
+	ChecksumSHA256 string `json:"checksum_sha256,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// PingRequest carries no data; it's unauthenticated so a client can
+// measure round-trip latency before, or instead of, logging in.
+type PingRequest struct{}
+
+// PingResponse reports enough for a client to diagnose a connection
+// before logging in: the RTT itself is measured by the client from how
+// long the round trip took, not from anything here. ServerVersion is
+// this build's Version, so a client can decide whether it's compatible
+// before attempting to authenticate. DBHealthy reflects the server's own
+// most recent database health check, so a client sees a clear signal
+// instead of a confusing failure deeper in the auth flow when the
+// database, rather than the network, is the problem.
+type PingResponse struct {
+	ServerVersion int  `json:"server_version"`
+	DBHealthy     bool `json:"db_healthy"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// UserStats summarizes how much of a vault a user is using.
+type UserStats struct {
+	ItemCount  int   `json:"item_count"`
+	TotalBytes int64 `json:"total_bytes"`
+}
+
+type StatsRequest struct {
+	Token string `json:"token"`
+}
+
+type StatsResponse struct {
+	Stats UserStats `json:"stats"`
+	Error string    `json:"error,omitempty"`
+}
+
+type RestoreDataRequest struct {
+	Token string `json:"token"`
+	ID    string `json:"id"`
+}
+
+type RestoreDataResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+// RecoveryInfoRequest is unauthenticated: it only reveals an opaque,
+// still-encrypted blob that's useless without the recovery key.
+type RecoveryInfoRequest struct {
+	Username string `json:"username"`
+}
+
+type RecoveryInfoResponse struct {
+	Available          bool   `json:"available"`
+	WrappedDEKRecovery []byte `json:"wrapped_dek_recovery,omitempty"`
+	KDFSalt            []byte `json:"kdf_salt,omitempty"`
+	Error              string `json:"error,omitempty"`
+}
+
+// RecoverRequest re-wraps the account's DEK under a new password. The
+// server verifies RecoveryKey against the stored hash before accepting
+// NewWrappedDEK, which the client has already produced locally.
+type RecoverRequest struct {
+	Username      string `json:"username"`
+	RecoveryKey   string `json:"recovery_key"`
+	NewPassword   string `json:"new_password"`
+	NewWrappedDEK []byte `json:"new_wrapped_dek"`
+}
+
+type RecoverResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+// RenameUserRequest changes the caller's username. Password is required
+// even though Token already authenticates the session, as confirmation
+// for a sensitive account change. NewWrappedDEK is only set for legacy
+// accounts predating KDFSalt, whose data key is still wrapped under a
+// username-derived key and must be re-wrapped on rename (see
+// Client.RenameAccount).
+type RenameUserRequest struct {
+	Token         string `json:"token"`
+	NewUsername   string `json:"new_username"`
+	Password      string `json:"password"`
+	NewWrappedDEK []byte `json:"new_wrapped_dek"`
+}
+
+type RenameUserResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+// ListModifiedRequest asks for every item whose updated_at falls in
+// [Since, Until) — unlike SyncRequest, this is a bounded range, not an
+// open-ended since-cursor.
+type ListModifiedRequest struct {
+	Token string    `json:"token"`
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+}
+
+type ListModifiedResponse struct {
+	Items []DataItem `json:"items"`
+	Error string     `json:"error,omitempty"`
+}
+
+// RekeyRequest replaces the caller's stored wrapped data-encryption key,
+// e.g. after the client has generated a new DEK and re-encrypted every
+// item under it. The login password is untouched, so NewWrappedDEK must
+// already be wrapped under the same key-wrapping key the password
+// derives.
+type RekeyRequest struct {
+	Token         string `json:"token"`
+	NewWrappedDEK []byte `json:"new_wrapped_dek"`
+}
+
+type RekeyResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+// UpdateMetadataRequest replaces an item's metadata in place, without
+// touching its encrypted data blob — for bulk edits that only need to
+// change metadata, not the item content.
+type UpdateMetadataRequest struct {
+	Token    string            `json:"token"`
+	ID       string            `json:"id"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+type UpdateMetadataResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+// CreateShareRequest asks the server to snapshot an item into a
+// time-limited share. EncryptedData is the item's data re-encrypted by
+// the client under a one-off share key the server never sees — the
+// server only ever stores and returns opaque ciphertext.
+type CreateShareRequest struct {
+	Token         string    `json:"token"`
+	ItemID        string    `json:"item_id"`
+	Type          uint8     `json:"type"`
+	Name          string    `json:"name"`
+	EncryptedData []byte    `json:"encrypted_data"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	MaxAccesses   int       `json:"max_accesses"`
+}
+
+type CreateShareResponse struct {
+	ShareID string `json:"share_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RedeemShareRequest is unauthenticated: possession of ShareID (and,
+// client-side, the share key embedded in the share link) is the only
+// proof of access a share requires.
+type RedeemShareRequest struct {
+	ShareID string `json:"share_id"`
+}
+
+type RedeemShareResponse struct {
+	Type  uint8  `json:"type,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Data  []byte `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// AutocompleteRequest asks for the names of items starting with Prefix,
+// for an interactive picker. Limit bounds how many results come back;
+// the server clamps it rather than trusting the client outright.
+type AutocompleteRequest struct {
+	Token  string `json:"token"`
+	Prefix string `json:"prefix"`
+	Limit  int    `json:"limit"`
+}
+
+type AutocompleteResponse struct {
+	Items []DataItem `json:"items"`
+	Error string     `json:"error,omitempty"`
+}
+
+// Attachment describes a small encrypted file linked to a parent item
+// (e.g. a recovery-codes file attached to a login), without its content.
+// Data is client-encrypted the same way as DataItem.Data, under the
+// same master key, so the server never sees it in plaintext.
+type Attachment struct {
+	ID        string    `json:"id"`
+	ItemID    string    `json:"item_id"`
+	Filename  string    `json:"filename"`
+	Size      int       `json:"size"`
+	Checksum  string    `json:"checksum_sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AttachFileRequest asks the server to link an already client-encrypted
+// file to ItemID, which must belong to the caller.
+type AttachFileRequest struct {
+	Token    string `json:"token"`
+	ItemID   string `json:"item_id"`
+	Filename string `json:"filename"`
+	Data     []byte `json:"data"`
+}
+
+type AttachFileResponse struct {
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ListAttachmentsRequest asks for every attachment linked to ItemID,
+// without their file contents.
+type ListAttachmentsRequest struct {
+	Token  string `json:"token"`
+	ItemID string `json:"item_id"`
+}
+
+type ListAttachmentsResponse struct {
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// DownloadAttachmentRequest fetches one attachment's full, still
+// client-encrypted content by ID.
+type DownloadAttachmentRequest struct {
+	Token string `json:"token"`
+	ID    string `json:"id"`
+}
+
+type DownloadAttachmentResponse struct {
+	Filename       string `json:"filename,omitempty"`
+	Data           []byte `json:"data"`
+	ChecksumSHA256 string `json:"checksum_sha256,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+type DeleteAttachmentRequest struct {
+	Token string `json:"token"`
+	ID    string `json:"id"`
+}
+
+type DeleteAttachmentResponse struct {
+	Error string `json:"error,omitempty"`
+}
+
+// ManifestEntry summarizes one item for sync diffing, without its
+// content: ContentHash lets the client tell whether an item it already
+// has changed, without downloading it again.
+type ManifestEntry struct {
+	ID          string    `json:"id"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	ContentHash string    `json:"content_hash"`
+}
+
+// ManifestRequest asks for a lightweight summary of every item the
+// caller owns.
+type ManifestRequest struct {
+	Token string `json:"token"`
+}
+
+type ManifestResponse struct {
+	Entries []ManifestEntry `json:"entries,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
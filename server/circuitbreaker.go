@@ -0,0 +1,143 @@
+package server
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrServiceUnavailable is returned in place of the underlying database
+// error once the circuit breaker has opened, so a Postgres outage fails
+// requests immediately instead of piling up goroutines behind a series
+// of connection timeouts.
+var ErrServiceUnavailable = errors.New("database temporarily unavailable")
+
+// breakerState is one of the three states a circuitBreaker can be in.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breakerFailureThreshold is how many consecutive failures trip the
+// breaker open, and breakerCooldown is how long it stays open before
+// letting a single trial request through to test recovery.
+const (
+	breakerFailureThreshold = 5
+	breakerCooldown         = 30 * time.Second
+)
+
+// circuitBreaker tracks consecutive database failures and, once they
+// cross a threshold, fast-fails calls for a cool-down window rather than
+// letting each one run to its own timeout. After the cool-down it
+// half-opens: exactly one call is allowed through as a trial, and its
+// outcome decides whether the breaker closes again or reopens.
+type circuitBreaker struct {
+	mu sync.Mutex
+
+	state               breakerState
+	consecutiveFailures int
+	openedAt            time.Time
+	trialInFlight       bool
+
+	failureThreshold int
+	cooldown         time.Duration
+	now              func() time.Time
+}
+
+// newCircuitBreaker builds a circuitBreaker using the package defaults.
+func newCircuitBreaker() *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: breakerFailureThreshold,
+		cooldown:         breakerCooldown,
+		now:              time.Now,
+	}
+}
+
+// allow reports whether a call should be attempted: always when closed,
+// never when open (until the cooldown has elapsed, which transitions to
+// half-open), and only for a single trial call at a time when half-open.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerClosed:
+		return true
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.trialInFlight = true
+		return true
+	case breakerHalfOpen:
+		if b.trialInFlight {
+			return false
+		}
+		b.trialInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// recordResult updates the breaker's state from the outcome of a call
+// that allow permitted. err should be the raw error the call returned
+// (before it's translated to ErrServiceUnavailable), so recordResult can
+// tell a real database failure apart from an ordinary "not found".
+func (b *circuitBreaker) recordResult(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failed := isBreakerFailure(err)
+
+	if b.state == breakerHalfOpen {
+		b.trialInFlight = false
+		if failed {
+			b.state = breakerOpen
+			b.openedAt = b.now()
+		} else {
+			b.state = breakerClosed
+			b.consecutiveFailures = 0
+		}
+		return
+	}
+
+	if !failed {
+		b.consecutiveFailures = 0
+		return
+	}
+	b.consecutiveFailures++
+	if b.consecutiveFailures >= b.failureThreshold {
+		b.state = breakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// isBreakerFailure reports whether err represents the database itself
+// being unreachable, as opposed to an ordinary business-logic outcome
+// (no rows, a constraint violation) that says the database is working
+// fine and simply rejected this particular query. It reuses
+// retryablePgCodes, the same classification withRetry uses to decide
+// whether a failure is transient.
+func isBreakerFailure(err error) bool {
+	if err == nil || errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+	if isRetryablePgError(err) {
+		return true
+	}
+	// A well-formed Postgres error (a constraint violation, an
+	// application-level check failing) means the connection itself is
+	// fine; anything else - a dial failure, a timeout, pool exhaustion -
+	// isn't a recognized Postgres protocol error, which is exactly the
+	// "database is unreachable" case this breaker exists for.
+	var pgErr *pgconn.PgError
+	return !errors.As(err, &pgErr)
+}
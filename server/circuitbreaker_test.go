@@ -0,0 +1,140 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// fixedClock returns a func() time.Time that always reports t, so tests
+// can move the breaker's notion of "now" forward explicitly instead of
+// sleeping through the real cooldown window.
+func fixedClock(t *time.Time) func() time.Time {
+	return func() time.Time { return *t }
+}
+
+func newTestBreaker(threshold int, cooldown time.Duration, now func() time.Time) *circuitBreaker {
+	return &circuitBreaker{failureThreshold: threshold, cooldown: cooldown, now: now}
+}
+
+var errUnreachable = &pgconn.PgError{Code: "08006"} // connection_failure, matches retryablePgCodes
+
+func TestCircuitBreakerOpensAfterThresholdConsecutiveFailures(t *testing.T) {
+	now := time.Now()
+	b := newTestBreaker(3, time.Minute, fixedClock(&now))
+
+	for i := 0; i < 2; i++ {
+		if !b.allow() {
+			t.Fatalf("expected breaker to still allow calls before the threshold, call %d", i)
+		}
+		b.recordResult(errUnreachable)
+	}
+	if b.state != breakerClosed {
+		t.Fatalf("expected breaker to stay closed below threshold, got state %v", b.state)
+	}
+
+	if !b.allow() {
+		t.Fatal("expected the threshold-tripping call itself to be allowed")
+	}
+	b.recordResult(errUnreachable)
+	if b.state != breakerOpen {
+		t.Fatalf("expected breaker to open after %d consecutive failures, got state %v", 3, b.state)
+	}
+}
+
+func TestCircuitBreakerFastFailsWhileOpen(t *testing.T) {
+	now := time.Now()
+	b := newTestBreaker(1, time.Minute, fixedClock(&now))
+
+	b.allow()
+	b.recordResult(errUnreachable)
+	if b.state != breakerOpen {
+		t.Fatalf("expected breaker to be open, got %v", b.state)
+	}
+
+	if b.allow() {
+		t.Fatal("expected the breaker to refuse calls while open and within the cooldown")
+	}
+}
+
+func TestCircuitBreakerHalfOpensAfterCooldownAndClosesOnSuccess(t *testing.T) {
+	now := time.Now()
+	b := newTestBreaker(1, time.Minute, fixedClock(&now))
+
+	b.allow()
+	b.recordResult(errUnreachable)
+	if b.state != breakerOpen {
+		t.Fatalf("expected breaker to be open, got %v", b.state)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !b.allow() {
+		t.Fatal("expected a trial call to be allowed once the cooldown has elapsed")
+	}
+	if b.state != breakerHalfOpen {
+		t.Fatalf("expected breaker to be half-open during the trial, got %v", b.state)
+	}
+
+	b.recordResult(nil)
+	if b.state != breakerClosed {
+		t.Fatalf("expected breaker to close after a successful trial, got %v", b.state)
+	}
+	if b.consecutiveFailures != 0 {
+		t.Fatalf("expected the failure count to reset on close, got %d", b.consecutiveFailures)
+	}
+}
+
+func TestCircuitBreakerReopensOnFailedTrial(t *testing.T) {
+	now := time.Now()
+	b := newTestBreaker(1, time.Minute, fixedClock(&now))
+
+	b.allow()
+	b.recordResult(errUnreachable)
+
+	now = now.Add(2 * time.Minute)
+	if !b.allow() {
+		t.Fatal("expected a trial call to be allowed once the cooldown has elapsed")
+	}
+	b.recordResult(errUnreachable)
+	if b.state != breakerOpen {
+		t.Fatalf("expected breaker to reopen after a failed trial, got %v", b.state)
+	}
+
+	if b.allow() {
+		t.Fatal("expected the breaker to refuse calls immediately after reopening")
+	}
+}
+
+func TestCircuitBreakerOnlyAllowsOneTrialAtATimeWhileHalfOpen(t *testing.T) {
+	now := time.Now()
+	b := newTestBreaker(1, time.Minute, fixedClock(&now))
+
+	b.allow()
+	b.recordResult(errUnreachable)
+
+	now = now.Add(2 * time.Minute)
+	if !b.allow() {
+		t.Fatal("expected the first trial call to be allowed")
+	}
+	if b.allow() {
+		t.Fatal("expected a second concurrent call to be refused while a trial is in flight")
+	}
+}
+
+func TestIsBreakerFailureIgnoresNoRowsAndBusinessErrors(t *testing.T) {
+	if isBreakerFailure(nil) {
+		t.Error("nil should not count as a failure")
+	}
+	if isBreakerFailure(pgx.ErrNoRows) {
+		t.Error("an ordinary no-rows result should not count as a failure")
+	}
+	uniqueViolation := &pgconn.PgError{Code: pgUniqueViolation}
+	if isBreakerFailure(uniqueViolation) {
+		t.Error("a well-formed constraint violation should not count as a failure")
+	}
+	if !isBreakerFailure(errUnreachable) {
+		t.Error("a connection-failure Postgres error should count as a failure")
+	}
+}
@@ -0,0 +1,1067 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/SemAnton-2007/go-password-manager/crypto"
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// ErrUserNotFound is returned when a lookup by username finds no row.
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrDataNotFound is returned when a lookup by item ID finds no row for
+// the given user.
+var ErrDataNotFound = errors.New("data item not found")
+
+// ErrUsernameTaken is returned by RenameUser when another account
+// already has the requested username.
+var ErrUsernameTaken = errors.New("username already taken")
+
+// ErrShareNotFound is returned by RedeemShare when the share ID doesn't
+// exist, has expired, or has already been redeemed its maximum number
+// of times. The three cases are indistinguishable on purpose, so a
+// stranger probing IDs can't learn which is true.
+var ErrShareNotFound = errors.New("share not found")
+
+// ErrTypeChangeNotAllowed is returned by UpdateData when the caller
+// requests a different data type than the item currently has and
+// allowTypeChange is false.
+var ErrTypeChangeNotAllowed = errors.New("changing an item's data type is not allowed")
+
+// ErrInvalidDataType is returned by StoreData and UpdateData when the
+// item's Type isn't one of the known protocol.DataType* constants.
+var ErrInvalidDataType = errors.New("invalid data type")
+
+// ErrNameTooLong is returned by StoreData and UpdateData when an item's
+// Name is longer than MaxNameLength.
+var ErrNameTooLong = errors.New("item name exceeds the maximum allowed length")
+
+// ErrAttachmentNotFound is returned when a lookup by attachment ID finds
+// no row for the given user.
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// DefaultMaxNameLength is the item name length, in bytes, enforced when
+// Database.MaxNameLength is left at its zero value.
+const DefaultMaxNameLength = 256
+
+// pgUniqueViolation is the Postgres error code for a unique constraint
+// violation.
+const pgUniqueViolation = "23505"
+
+// Database wraps a pgx connection pool and exposes the queries the
+// server needs.
+type Database struct {
+	pool    *pgxpool.Pool
+	breaker *circuitBreaker
+
+	// StrictMetadata makes a row with unparsable metadata JSON fail its
+	// whole query. By default a corrupt row is logged and treated as
+	// having empty metadata instead, so it can't take down an otherwise
+	// healthy sync.
+	StrictMetadata bool
+
+	// EncryptionKey, if set, makes the database additionally encrypt each
+	// item's data column at rest under this key, on top of whatever
+	// encryption the client already applied. This defends against a raw
+	// database dump exposing item contents even though every item.Data
+	// blob is already client-side ciphertext, since a leaked database
+	// alone shouldn't be enough to recover it if this key is held
+	// separately (e.g. in a KMS). See NewEncryptionKeyFromPassphrase.
+	EncryptionKey []byte
+
+	// MaxNameLength caps how long an item's Name may be, in bytes, for
+	// StoreData, StoreDataAndReturn, StoreDataWithTimestamps, and
+	// UpdateData. Zero means DefaultMaxNameLength; a negative value
+	// disables the check.
+	MaxNameLength int
+}
+
+// maxNameLength returns the effective name length limit, resolving the
+// Database's zero value to DefaultMaxNameLength.
+func (d *Database) maxNameLength() int {
+	if d.MaxNameLength == 0 {
+		return DefaultMaxNameLength
+	}
+	return d.MaxNameLength
+}
+
+// validateNameLength returns ErrNameTooLong if name exceeds the
+// configured limit. A negative MaxNameLength disables the check.
+func (d *Database) validateNameLength(name string) error {
+	if max := d.maxNameLength(); max > 0 && len(name) > max {
+		return ErrNameTooLong
+	}
+	return nil
+}
+
+// NewEncryptionKeyFromPassphrase turns an operator-supplied passphrase
+// (e.g. from the -server-encryption-key flag or a KMS-managed secret)
+// into a fixed-size AES key via SHA-256, so Database.EncryptionKey always
+// has a valid AES key size regardless of the passphrase's length.
+func NewEncryptionKeyFromPassphrase(passphrase string) []byte {
+	key := sha256.Sum256([]byte(passphrase))
+	return key[:]
+}
+
+// encryptAtRest wraps data under d.EncryptionKey before it's written to
+// the data column. It's a no-op when EncryptionKey is unset, so the
+// feature stays fully opt-in.
+func (d *Database) encryptAtRest(data []byte) ([]byte, error) {
+	if len(d.EncryptionKey) == 0 || len(data) == 0 {
+		return data, nil
+	}
+	return crypto.Encrypt(data, d.EncryptionKey)
+}
+
+// decryptAtRest reverses encryptAtRest. It's a no-op when EncryptionKey
+// is unset, matching encryptAtRest.
+func (d *Database) decryptAtRest(data []byte) ([]byte, error) {
+	if len(d.EncryptionKey) == 0 || len(data) == 0 {
+		return data, nil
+	}
+	return crypto.Decrypt(data, d.EncryptionKey)
+}
+
+// withBreaker runs fn if the circuit breaker currently allows it,
+// returning ErrServiceUnavailable without calling fn otherwise. It's the
+// read-query counterpart to withRetry: reads aren't worth retrying the
+// way a write's serialization conflict is, but they still need to
+// fast-fail once the database looks down rather than each hang out to
+// its own timeout.
+func (d *Database) withBreaker(fn func() error) error {
+	if !d.breaker.allow() {
+		return ErrServiceUnavailable
+	}
+	err := fn()
+	d.breaker.recordResult(err)
+	return err
+}
+
+// unmarshalMetadata decodes a row's stored metadata JSON. In the default,
+// non-strict mode a decode failure is logged and reported as empty
+// metadata rather than failing the caller's whole query.
+func (d *Database) unmarshalMetadata(itemID string, raw []byte) (map[string]string, error) {
+	var metadata map[string]string
+	if err := json.Unmarshal(raw, &metadata); err != nil {
+		if d.StrictMetadata {
+			return nil, fmt.Errorf("unmarshal metadata for item %s: %w", itemID, err)
+		}
+		log.Printf("item %s has corrupt metadata, using empty metadata: %v", itemID, err)
+		return map[string]string{}, nil
+	}
+	return metadata, nil
+}
+
+// PoolOptions tunes the underlying pgxpool beyond the connection string.
+// Zero values leave pgxpool's own defaults in place.
+type PoolOptions struct {
+	MaxConns        int32
+	MinConns        int32
+	ConnMaxLifetime time.Duration
+}
+
+// applyPoolOptions overlays opts onto config, leaving pgxpool's parsed
+// defaults untouched for any zero-valued field.
+func applyPoolOptions(config *pgxpool.Config, opts PoolOptions) {
+	if opts.MaxConns > 0 {
+		config.MaxConns = opts.MaxConns
+	}
+	if opts.MinConns > 0 {
+		config.MinConns = opts.MinConns
+	}
+	if opts.ConnMaxLifetime > 0 {
+		config.MaxConnLifetime = opts.ConnMaxLifetime
+	}
+}
+
+// NewDatabase connects to Postgres using connString and verifies the
+// connection with a ping, using pgxpool's default pool sizing. Use
+// NewDatabaseWithOptions to tune concurrency under load.
+func NewDatabase(connString string) (*Database, error) {
+	return NewDatabaseWithOptions(connString, PoolOptions{})
+}
+
+// NewDatabaseWithOptions is like NewDatabase but applies opts (max/min
+// connections, connection lifetime) to the pool config before
+// connecting.
+func NewDatabaseWithOptions(connString string, opts PoolOptions) (*Database, error) {
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, fmt.Errorf("parse database config: %w", err)
+	}
+	applyPoolOptions(config, opts)
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	if err != nil {
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+	if err := pool.Ping(context.Background()); err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("ping database: %w", err)
+	}
+	return &Database{pool: pool, breaker: newCircuitBreaker()}, nil
+}
+
+// Close releases the underlying connection pool.
+func (d *Database) Close() {
+	d.pool.Close()
+}
+
+// Healthy reports whether the database is currently reachable, via a
+// lightweight ping. It's meant for surfacing in PingResponse, so a
+// client can tell a database outage apart from a network problem before
+// it gets as far as authenticating.
+func (d *Database) Healthy() bool {
+	return d.pool.Ping(context.Background()) == nil
+}
+
+// CreateUser inserts a new user with a wrapped data-encryption key and
+// returns its ID. recoveryKeyHash and wrappedDEKRecovery are optional
+// (pass "" / nil when the account isn't opting into recovery).
+func (d *Database) CreateUser(username, passwordHash string, wrappedDEK []byte, kdfSalt []byte, recoveryKeyHash string, wrappedDEKRecovery []byte) (int, error) {
+	var id int
+	err := d.withRetry(context.Background(), func() error {
+		return d.pool.QueryRow(context.Background(),
+			`INSERT INTO users (username, password_hash, wrapped_dek, kdf_salt, recovery_key_hash, wrapped_dek_recovery)
+			 VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6) RETURNING id`,
+			username, passwordHash, wrappedDEK, kdfSalt, recoveryKeyHash, wrappedDEKRecovery,
+		).Scan(&id)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// GetUserByUsername returns the user's ID, stored password hash, the
+// time their password was last changed, their wrapped data-encryption
+// key, and the KDF salt used to derive the key that wraps it. KdfSalt is
+// empty for accounts registered before it existed; callers should fall
+// back to the legacy username-salted derivation in that case.
+func (d *Database) GetUserByUsername(username string) (id int, passwordHash string, passwordChangedAt time.Time, wrappedDEK []byte, kdfSalt []byte, err error) {
+	err = d.withBreaker(func() error {
+		return d.pool.QueryRow(context.Background(),
+			`SELECT id, password_hash, password_changed_at, wrapped_dek, kdf_salt FROM users WHERE username = $1`,
+			username,
+		).Scan(&id, &passwordHash, &passwordChangedAt, &wrappedDEK, &kdfSalt)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, "", time.Time{}, nil, nil, ErrUserNotFound
+	}
+	if err != nil {
+		return 0, "", time.Time{}, nil, nil, fmt.Errorf("get user %q: %w", username, err)
+	}
+	return id, passwordHash, passwordChangedAt, wrappedDEK, kdfSalt, nil
+}
+
+// GetUserByID returns a user's username and password hash by ID. Used
+// where a request is authenticated by session token (which carries the
+// ID) but still needs to re-verify the password, e.g. before a rename.
+func (d *Database) GetUserByID(userID int) (username, passwordHash string, err error) {
+	err = d.withBreaker(func() error {
+		return d.pool.QueryRow(context.Background(),
+			`SELECT username, password_hash FROM users WHERE id = $1`,
+			userID,
+		).Scan(&username, &passwordHash)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", ErrUserNotFound
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("get user %d: %w", userID, err)
+	}
+	return username, passwordHash, nil
+}
+
+// RenameUser changes a user's username, failing with ErrUsernameTaken if
+// another account already has newUsername.
+func (d *Database) RenameUser(userID int, newUsername string) error {
+	var rowsAffected int64
+	err := d.withRetry(context.Background(), func() error {
+		tag, err := d.pool.Exec(context.Background(),
+			`UPDATE users SET username = $1 WHERE id = $2`,
+			newUsername, userID,
+		)
+		rowsAffected = tag.RowsAffected()
+		return err
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return ErrUsernameTaken
+		}
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// UpdateWrappedDEK overwrites a user's wrapped data-encryption key
+// without touching their password hash. Used when the key's wrapping
+// salt changes (e.g. a rename, since key derivation is currently salted
+// by username) but the password itself doesn't.
+func (d *Database) UpdateWrappedDEK(userID int, wrappedDEK []byte) error {
+	var rowsAffected int64
+	err := d.withRetry(context.Background(), func() error {
+		tag, err := d.pool.Exec(context.Background(),
+			`UPDATE users SET wrapped_dek = $1 WHERE id = $2`,
+			wrappedDEK, userID,
+		)
+		rowsAffected = tag.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// GetRecoveryInfo returns the bcrypt hash of the account's recovery key
+// and the DEK wrapped under it. Both are empty if the account never set
+// up recovery.
+func (d *Database) GetRecoveryInfo(username string) (recoveryKeyHash string, wrappedDEKRecovery []byte, kdfSalt []byte, err error) {
+	err = d.withBreaker(func() error {
+		return d.pool.QueryRow(context.Background(),
+			`SELECT COALESCE(recovery_key_hash, ''), wrapped_dek_recovery, kdf_salt FROM users WHERE username = $1`,
+			username,
+		).Scan(&recoveryKeyHash, &wrappedDEKRecovery, &kdfSalt)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil, nil, ErrUserNotFound
+	}
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("get recovery info for %q: %w", username, err)
+	}
+	return recoveryKeyHash, wrappedDEKRecovery, kdfSalt, nil
+}
+
+// UpdateWrappedDEKAndPassword re-wraps a user's DEK under a new password
+// and updates the stored password hash, as part of recovery.
+func (d *Database) UpdateWrappedDEKAndPassword(userID int, wrappedDEK []byte, passwordHash string) error {
+	var rowsAffected int64
+	err := d.withRetry(context.Background(), func() error {
+		tag, err := d.pool.Exec(context.Background(),
+			`UPDATE users SET wrapped_dek = $1, password_hash = $2, password_changed_at = now() WHERE id = $3`,
+			wrappedDEK, passwordHash, userID,
+		)
+		rowsAffected = tag.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// SetPasswordHash overwrites a user's stored password hash directly,
+// bypassing the normal auth flow, and stamps password_changed_at. Used
+// by the offline admin reset-password command.
+func (d *Database) SetPasswordHash(username, passwordHash string) error {
+	var rowsAffected int64
+	err := d.withRetry(context.Background(), func() error {
+		tag, err := d.pool.Exec(context.Background(),
+			`UPDATE users SET password_hash = $1, password_changed_at = now() WHERE username = $2`,
+			passwordHash, username,
+		)
+		rowsAffected = tag.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// UserSummary is a non-sensitive per-account inventory row: no password
+// hash, wrapped keys, or salts, so it's safe to print or export as-is.
+type UserSummary struct {
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+	ItemCount int       `json:"item_count"`
+}
+
+// ListUsers returns every account with its creation date and how many
+// non-deleted items it owns, for admin inventory reporting. It never
+// touches password_hash, wrapped_dek, or kdf_salt.
+func (d *Database) ListUsers() ([]UserSummary, error) {
+	var rows pgx.Rows
+	err := d.withBreaker(func() error {
+		var err error
+		rows, err = d.pool.Query(context.Background(),
+			`SELECT u.username, u.created_at, count(i.id)
+			 FROM users u
+			 LEFT JOIN items i ON i.user_id = u.id AND i.deleted_at IS NULL
+			 GROUP BY u.id, u.username, u.created_at
+			 ORDER BY u.username`,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []UserSummary
+	for rows.Next() {
+		var s UserSummary
+		if err := rows.Scan(&s.Username, &s.CreatedAt, &s.ItemCount); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate users: %w", err)
+	}
+	return summaries, nil
+}
+
+// StoreData inserts a new item for userID and returns its generated ID.
+func (d *Database) StoreData(userID int, item protocol.NewDataItem) (string, error) {
+	if !protocol.IsValidDataType(item.Type) {
+		return "", ErrInvalidDataType
+	}
+	if err := d.validateNameLength(item.Name); err != nil {
+		return "", err
+	}
+
+	metadataJSON, err := json.Marshal(item.Metadata)
+	if err != nil {
+		return "", fmt.Errorf("marshal metadata: %w", err)
+	}
+	storedData, err := d.encryptAtRest(item.Data)
+	if err != nil {
+		return "", fmt.Errorf("encrypt data at rest: %w", err)
+	}
+	var id string
+	err = d.withRetry(context.Background(), func() error {
+		return d.pool.QueryRow(context.Background(),
+			`INSERT INTO items (user_id, type, name, data, notes, metadata)
+			 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+			userID, item.Type, item.Name, storedData, item.Notes, metadataJSON,
+		).Scan(&id)
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// StoreDataAndReturn is StoreData plus a RETURNING clause: it inserts
+// item for userID and hands back the full stored DataItem, including the
+// ID and created_at/updated_at timestamps the database assigned, so a
+// caller doesn't need a separate GetDataByID round trip to learn them.
+func (d *Database) StoreDataAndReturn(userID int, item protocol.NewDataItem) (protocol.DataItem, error) {
+	if !protocol.IsValidDataType(item.Type) {
+		return protocol.DataItem{}, ErrInvalidDataType
+	}
+	if err := d.validateNameLength(item.Name); err != nil {
+		return protocol.DataItem{}, err
+	}
+
+	metadataJSON, err := json.Marshal(item.Metadata)
+	if err != nil {
+		return protocol.DataItem{}, fmt.Errorf("marshal metadata: %w", err)
+	}
+	storedData, err := d.encryptAtRest(item.Data)
+	if err != nil {
+		return protocol.DataItem{}, fmt.Errorf("encrypt data at rest: %w", err)
+	}
+	result := protocol.DataItem{
+		Type:     item.Type,
+		Name:     item.Name,
+		Data:     item.Data,
+		Notes:    item.Notes,
+		Metadata: item.Metadata,
+	}
+	err = d.withRetry(context.Background(), func() error {
+		return d.pool.QueryRow(context.Background(),
+			`INSERT INTO items (user_id, type, name, data, notes, metadata)
+			 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at`,
+			userID, item.Type, item.Name, storedData, item.Notes, metadataJSON,
+		).Scan(&result.ID, &result.CreatedAt, &result.UpdatedAt)
+	})
+	if err != nil {
+		return protocol.DataItem{}, err
+	}
+	return result, nil
+}
+
+// StoreDataWithTimestamps is StoreData for imports: it inserts item with
+// caller-supplied createdAt/updatedAt instead of letting the database
+// assign them, so restoring from a backup or another tool preserves the
+// original dates. It's only meant to be reached from an operator-driven
+// import path, never from the normal client-facing StoreData request.
+func (d *Database) StoreDataWithTimestamps(userID int, item protocol.NewDataItem, createdAt, updatedAt time.Time) (string, error) {
+	if !protocol.IsValidDataType(item.Type) {
+		return "", ErrInvalidDataType
+	}
+	if err := d.validateNameLength(item.Name); err != nil {
+		return "", err
+	}
+
+	metadataJSON, err := json.Marshal(item.Metadata)
+	if err != nil {
+		return "", fmt.Errorf("marshal metadata: %w", err)
+	}
+	storedData, err := d.encryptAtRest(item.Data)
+	if err != nil {
+		return "", fmt.Errorf("encrypt data at rest: %w", err)
+	}
+	var id string
+	err = d.withRetry(context.Background(), func() error {
+		return d.pool.QueryRow(context.Background(),
+			`INSERT INTO items (user_id, type, name, data, notes, metadata, created_at, updated_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+			userID, item.Type, item.Name, storedData, item.Notes, metadataJSON, createdAt, updatedAt,
+		).Scan(&id)
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// GetData returns every item belonging to userID last updated after
+// since, for use by client sync.
+// GetData returns items updated after since. When metadataOnly is true,
+// the data column is left out of the query entirely, so a listing sync
+// doesn't pay to transfer blobs the client isn't ready to decrypt yet;
+// callers fetch the blob later via GetDataByID.
+func (d *Database) GetData(userID int, since time.Time, metadataOnly bool) ([]protocol.DataItem, error) {
+	dataColumn, notesColumn := "data", "notes"
+	if metadataOnly {
+		dataColumn, notesColumn = "NULL", "NULL"
+	}
+	var rows pgx.Rows
+	err := d.withBreaker(func() error {
+		var err error
+		rows, err = d.pool.Query(context.Background(),
+			fmt.Sprintf(`SELECT id, type, name, %s, %s, metadata, created_at, updated_at
+			 FROM items WHERE user_id = $1 AND updated_at > $2 AND deleted_at IS NULL
+			 ORDER BY updated_at ASC`, dataColumn, notesColumn),
+			userID, since,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []protocol.DataItem
+	for rows.Next() {
+		var item protocol.DataItem
+		var metadataJSON []byte
+		if err := rows.Scan(&item.ID, &item.Type, &item.Name, &item.Data, &item.Notes, &metadataJSON, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		item.Data, err = d.decryptAtRest(item.Data)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt data at rest for item %s: %w", item.ID, err)
+		}
+		item.Metadata, err = d.unmarshalMetadata(item.ID, metadataJSON)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate items: %w", err)
+	}
+	return items, nil
+}
+
+// GetDataModifiedBetween returns every non-deleted item belonging to
+// userID whose updated_at falls in [since, until): since is inclusive,
+// until is exclusive, so adjacent ranges never double-count a boundary
+// timestamp.
+func (d *Database) GetDataModifiedBetween(userID int, since, until time.Time) ([]protocol.DataItem, error) {
+	var rows pgx.Rows
+	err := d.withBreaker(func() error {
+		var err error
+		rows, err = d.pool.Query(context.Background(),
+			`SELECT id, type, name, data, notes, metadata, created_at, updated_at
+			 FROM items WHERE user_id = $1 AND updated_at >= $2 AND updated_at < $3 AND deleted_at IS NULL
+			 ORDER BY updated_at ASC`,
+			userID, since, until,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []protocol.DataItem
+	for rows.Next() {
+		var item protocol.DataItem
+		var metadataJSON []byte
+		if err := rows.Scan(&item.ID, &item.Type, &item.Name, &item.Data, &item.Notes, &metadataJSON, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		item.Data, err = d.decryptAtRest(item.Data)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt data at rest for item %s: %w", item.ID, err)
+		}
+		item.Metadata, err = d.unmarshalMetadata(item.ID, metadataJSON)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate items: %w", err)
+	}
+	return items, nil
+}
+
+// escapeLikePattern escapes the characters LIKE treats specially (%, _,
+// and the escape character itself) so a caller-supplied string can be
+// used as a literal prefix rather than a wildcard pattern.
+func escapeLikePattern(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(s)
+}
+
+// SearchByNamePrefix returns up to limit non-deleted items belonging to
+// userID whose name starts with prefix, ordered by name. It's meant for
+// interactive autocomplete rather than full-text search, so it relies on
+// the items_user_id_name_idx index instead of scanning every row.
+func (d *Database) SearchByNamePrefix(userID int, prefix string, limit int) ([]protocol.DataItem, error) {
+	pattern := escapeLikePattern(prefix) + "%"
+
+	var rows pgx.Rows
+	err := d.withBreaker(func() error {
+		var err error
+		rows, err = d.pool.Query(context.Background(),
+			`SELECT id, type, name, data, notes, metadata, created_at, updated_at
+			 FROM items WHERE user_id = $1 AND name LIKE $2 ESCAPE '\' AND deleted_at IS NULL
+			 ORDER BY name ASC LIMIT $3`,
+			userID, pattern, limit,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []protocol.DataItem
+	for rows.Next() {
+		var item protocol.DataItem
+		var metadataJSON []byte
+		if err := rows.Scan(&item.ID, &item.Type, &item.Name, &item.Data, &item.Notes, &metadataJSON, &item.CreatedAt, &item.UpdatedAt); err != nil {
+			return nil, err
+		}
+		item.Data, err = d.decryptAtRest(item.Data)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt data at rest for item %s: %w", item.ID, err)
+		}
+		item.Metadata, err = d.unmarshalMetadata(item.ID, metadataJSON)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate items: %w", err)
+	}
+	return items, nil
+}
+
+// GetDataByID returns a single item, scoped to userID so one user can't
+// read another's data.
+func (d *Database) GetDataByID(userID int, id string) (protocol.DataItem, error) {
+	var item protocol.DataItem
+	var metadataJSON []byte
+	err := d.withBreaker(func() error {
+		return d.pool.QueryRow(context.Background(),
+			`SELECT id, type, name, data, notes, metadata, created_at, updated_at
+			 FROM items WHERE user_id = $1 AND id = $2 AND deleted_at IS NULL`,
+			userID, id,
+		).Scan(&item.ID, &item.Type, &item.Name, &item.Data, &item.Notes, &metadataJSON, &item.CreatedAt, &item.UpdatedAt)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return protocol.DataItem{}, ErrDataNotFound
+	}
+	if err != nil {
+		return protocol.DataItem{}, fmt.Errorf("get data %q for user %d: %w", id, userID, err)
+	}
+	item.Data, err = d.decryptAtRest(item.Data)
+	if err != nil {
+		return protocol.DataItem{}, fmt.Errorf("decrypt data at rest for item %s: %w", item.ID, err)
+	}
+	item.Metadata, err = d.unmarshalMetadata(item.ID, metadataJSON)
+	if err != nil {
+		return protocol.DataItem{}, err
+	}
+	return item, nil
+}
+
+// UpdateData overwrites an existing item's content, refreshing
+// updated_at. created_at is never part of this (or any) write query, so
+// it always reflects true insertion time: NewDataItem has no
+// created_at field for a client to supply, and even if it did, this
+// query wouldn't touch the column.
+//
+// Unless allowTypeChange is set, a request to change the item's data
+// type is rejected with ErrTypeChangeNotAllowed: switching e.g. a login
+// into a binary item mid-edit leaves metadata that was built for the old
+// type in a confusing state.
+func (d *Database) UpdateData(userID int, id string, item protocol.NewDataItem, allowTypeChange bool) error {
+	if !protocol.IsValidDataType(item.Type) {
+		return ErrInvalidDataType
+	}
+	if err := d.validateNameLength(item.Name); err != nil {
+		return err
+	}
+
+	if !allowTypeChange {
+		var currentType uint8
+		err := d.withBreaker(func() error {
+			return d.pool.QueryRow(context.Background(),
+				`SELECT type FROM items WHERE user_id = $1 AND id = $2`,
+				userID, id,
+			).Scan(&currentType)
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrDataNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if currentType != item.Type {
+			return ErrTypeChangeNotAllowed
+		}
+	}
+
+	metadataJSON, err := json.Marshal(item.Metadata)
+	if err != nil {
+		return fmt.Errorf("marshal metadata: %w", err)
+	}
+	storedData, err := d.encryptAtRest(item.Data)
+	if err != nil {
+		return fmt.Errorf("encrypt data at rest: %w", err)
+	}
+	var rowsAffected int64
+	err = d.withRetry(context.Background(), func() error {
+		tag, err := d.pool.Exec(context.Background(),
+			`UPDATE items SET type = $1, name = $2, data = $3, notes = $4, metadata = $5, updated_at = now()
+			 WHERE user_id = $6 AND id = $7`,
+			item.Type, item.Name, storedData, item.Notes, metadataJSON, userID, id,
+		)
+		rowsAffected = tag.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrDataNotFound
+	}
+	return nil
+}
+
+// UpdateMetadata replaces an item's metadata without touching its data
+// column, so a metadata-only edit doesn't need to re-upload the
+// (potentially large) encrypted blob.
+func (d *Database) UpdateMetadata(userID int, id string, metadata map[string]string) error {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("marshal metadata: %w", err)
+	}
+	var rowsAffected int64
+	err = d.withRetry(context.Background(), func() error {
+		tag, err := d.pool.Exec(context.Background(),
+			`UPDATE items SET metadata = $1, updated_at = now()
+			 WHERE user_id = $2 AND id = $3 AND deleted_at IS NULL`,
+			metadataJSON, userID, id,
+		)
+		rowsAffected = tag.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrDataNotFound
+	}
+	return nil
+}
+
+// GetUserStats returns the number of items userID owns and the total
+// size in bytes of their stored data and metadata.
+func (d *Database) GetUserStats(userID int) (protocol.UserStats, error) {
+	var stats protocol.UserStats
+	err := d.withBreaker(func() error {
+		return d.pool.QueryRow(context.Background(),
+			`SELECT count(*), COALESCE(SUM(length(data) + length(metadata::text)), 0)
+			 FROM items WHERE user_id = $1`,
+			userID,
+		).Scan(&stats.ItemCount, &stats.TotalBytes)
+	})
+	if err != nil {
+		return protocol.UserStats{}, err
+	}
+	return stats, nil
+}
+
+// DeleteData soft-deletes an item by stamping deleted_at, so it drops
+// out of sync/get results but can still be restored.
+func (d *Database) DeleteData(userID int, id string) error {
+	var rowsAffected int64
+	err := d.withRetry(context.Background(), func() error {
+		tag, err := d.pool.Exec(context.Background(),
+			`UPDATE items SET deleted_at = now() WHERE user_id = $1 AND id = $2 AND deleted_at IS NULL`,
+			userID, id,
+		)
+		rowsAffected = tag.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrDataNotFound
+	}
+	return nil
+}
+
+// CreateShare snapshots an item's type, name, and already
+// share-key-encrypted data into a new share, redeemable up to
+// maxAccesses times before expiresAt. The server never sees the share
+// key: encryptedData arrives from the client already sealed under it.
+func (d *Database) CreateShare(userID int, itemID string, itemType uint8, itemName string, encryptedData []byte, expiresAt time.Time, maxAccesses int) (string, error) {
+	idBuf := make([]byte, 16)
+	if _, err := rand.Read(idBuf); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(idBuf)
+
+	err := d.withRetry(context.Background(), func() error {
+		_, err := d.pool.Exec(context.Background(),
+			`INSERT INTO shares (id, user_id, item_id, type, name, data, expires_at, max_accesses)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			id, userID, itemID, itemType, itemName, encryptedData, expiresAt, maxAccesses,
+		)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// RedeemShare atomically consumes one access of share id, returning the
+// snapshot recorded at CreateShare time. It fails once id has expired or
+// been redeemed max_accesses times.
+func (d *Database) RedeemShare(id string) (itemType uint8, name string, data []byte, err error) {
+	err = d.withRetry(context.Background(), func() error {
+		return d.pool.QueryRow(context.Background(),
+			`UPDATE shares SET access_count = access_count + 1
+			 WHERE id = $1 AND expires_at > now() AND access_count < max_accesses
+			 RETURNING type, name, data`,
+			id,
+		).Scan(&itemType, &name, &data)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, "", nil, ErrShareNotFound
+	}
+	if err != nil {
+		return 0, "", nil, err
+	}
+	return itemType, name, data, nil
+}
+
+// AttachFile links an already client-encrypted file to itemID, owned by
+// userID. It doesn't itself verify itemID belongs to userID; callers
+// (see handleAttachFile) are expected to check ownership first via
+// GetDataByID, the same as CreateShare does for the item it snapshots.
+func (d *Database) AttachFile(userID int, itemID, filename string, data []byte) (string, error) {
+	storedData, err := d.encryptAtRest(data)
+	if err != nil {
+		return "", fmt.Errorf("encrypt attachment at rest: %w", err)
+	}
+	checksum := sha256.Sum256(data)
+
+	var id string
+	err = d.withRetry(context.Background(), func() error {
+		return d.pool.QueryRow(context.Background(),
+			`INSERT INTO attachments (item_id, user_id, filename, data, size, checksum)
+			 VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+			itemID, userID, filename, storedData, len(data), hex.EncodeToString(checksum[:]),
+		).Scan(&id)
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// ListAttachments returns every attachment linked to itemID and owned by
+// userID, without their file contents.
+func (d *Database) ListAttachments(userID int, itemID string) ([]protocol.Attachment, error) {
+	var rows pgx.Rows
+	err := d.withBreaker(func() error {
+		var err error
+		rows, err = d.pool.Query(context.Background(),
+			`SELECT id, item_id, filename, size, checksum, created_at
+			 FROM attachments WHERE user_id = $1 AND item_id = $2 ORDER BY created_at`,
+			userID, itemID,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attachments []protocol.Attachment
+	for rows.Next() {
+		var a protocol.Attachment
+		if err := rows.Scan(&a.ID, &a.ItemID, &a.Filename, &a.Size, &a.Checksum, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+// GetAttachment returns one attachment's metadata and full stored
+// content, owned by userID.
+func (d *Database) GetAttachment(userID int, id string) (protocol.Attachment, []byte, error) {
+	var a protocol.Attachment
+	var data []byte
+	err := d.withBreaker(func() error {
+		return d.pool.QueryRow(context.Background(),
+			`SELECT id, item_id, filename, data, checksum, created_at
+			 FROM attachments WHERE user_id = $1 AND id = $2`,
+			userID, id,
+		).Scan(&a.ID, &a.ItemID, &a.Filename, &data, &a.Checksum, &a.CreatedAt)
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return protocol.Attachment{}, nil, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return protocol.Attachment{}, nil, fmt.Errorf("get attachment %q for user %d: %w", id, userID, err)
+	}
+	data, err = d.decryptAtRest(data)
+	if err != nil {
+		return protocol.Attachment{}, nil, fmt.Errorf("decrypt attachment at rest for %s: %w", a.ID, err)
+	}
+	a.Size = len(data)
+	return a, data, nil
+}
+
+// DeleteAttachment removes one attachment owned by userID.
+func (d *Database) DeleteAttachment(userID int, id string) error {
+	var rowsAffected int64
+	err := d.withRetry(context.Background(), func() error {
+		tag, err := d.pool.Exec(context.Background(),
+			`DELETE FROM attachments WHERE user_id = $1 AND id = $2`,
+			userID, id,
+		)
+		rowsAffected = tag.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrAttachmentNotFound
+	}
+	return nil
+}
+
+// GetManifest returns a lightweight {id, updated_at, content_hash} entry
+// for every one of userID's items, without their data or notes, so a
+// client can diff it against what it already has and fetch only the
+// items that actually changed via GetDataByID or a batch equivalent.
+// ContentHash is a SHA-256 of the item's stored data, notes, and metadata
+// exactly as persisted, so it also changes if at-rest encryption
+// re-wraps the data under a new key.
+func (d *Database) GetManifest(userID int) ([]protocol.ManifestEntry, error) {
+	var rows pgx.Rows
+	err := d.withBreaker(func() error {
+		var err error
+		rows, err = d.pool.Query(context.Background(),
+			`SELECT id, data, notes, metadata, updated_at
+			 FROM items WHERE user_id = $1 AND deleted_at IS NULL
+			 ORDER BY updated_at ASC`,
+			userID,
+		)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []protocol.ManifestEntry
+	for rows.Next() {
+		var entry protocol.ManifestEntry
+		var data, notes, metadata []byte
+		if err := rows.Scan(&entry.ID, &data, &notes, &metadata, &entry.UpdatedAt); err != nil {
+			return nil, err
+		}
+		hash := sha256.New()
+		hash.Write(data)
+		hash.Write(notes)
+		hash.Write(metadata)
+		entry.ContentHash = hex.EncodeToString(hash.Sum(nil))
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate items: %w", err)
+	}
+	return entries, nil
+}
+
+// RestoreData clears the tombstone set by DeleteData, making the item
+// visible again.
+func (d *Database) RestoreData(userID int, id string) error {
+	var rowsAffected int64
+	err := d.withRetry(context.Background(), func() error {
+		tag, err := d.pool.Exec(context.Background(),
+			`UPDATE items SET deleted_at = NULL WHERE user_id = $1 AND id = $2 AND deleted_at IS NOT NULL`,
+			userID, id,
+		)
+		rowsAffected = tag.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrDataNotFound
+	}
+	return nil
+}
@@ -0,0 +1,965 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// ErrInvalidToken is returned by handlers when the request's token
+// doesn't map to an active session.
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// ErrInvalidRange is returned by handleDownload when a DownloadRequest's
+// Offset/Length fall outside the item's stored data.
+var ErrInvalidRange = errors.New("invalid byte range")
+
+// defaultMaxMessageSize is the declared-payload-length limit used when
+// Server.MaxMessageSize is unset, guarding against a client declaring an
+// unreasonable length.
+const defaultMaxMessageSize = 64 * 1024 * 1024
+
+// ClientHandler owns a single client connection for its lifetime,
+// reading framed requests and writing framed responses.
+type ClientHandler struct {
+	conn   net.Conn
+	server *Server
+}
+
+// NewClientHandler wraps conn for dispatch against server's Database and
+// session store.
+func NewClientHandler(conn net.Conn, server *Server) *ClientHandler {
+	return &ClientHandler{conn: conn, server: server}
+}
+
+// Handle reads requests off the connection until it's closed or a read
+// fails, dispatching each to handleMessage and writing back the result.
+func (h *ClientHandler) Handle() {
+	defer h.conn.Close()
+
+	for {
+		header, payload, err := h.readMessage()
+		if err != nil {
+			if errors.Is(err, protocol.ErrMessageTooLarge) {
+				logger := newRequestLogger(header.MessageID)
+				logger.Printf("rejected oversized message: declared length %d exceeds the %d-byte limit", header.Length, h.maxMessageSize())
+				resp, _ := json.Marshal(protocol.ErrorResponse{Error: protocol.ErrMessageTooLarge.Error()})
+				if err := h.writeMessage(protocol.MsgTypeErrorResponse, header.MessageID, resp); err != nil {
+					logger.Printf("Error writing to connection: %v", err)
+					return
+				}
+				continue
+			}
+			if isClientDisconnectError(err) {
+				return
+			}
+			log.Printf("Error reading from connection: %v", err)
+			return
+		}
+
+		logger := newRequestLogger(header.MessageID)
+		if h.server.LogTraffic {
+			logger.Printf("Received message type: %s, length: %d", protocol.MsgType(header.Type), header.Length)
+		}
+
+		if header.Type == protocol.MsgTypeSyncStreamRequest {
+			if err := h.handleSyncStream(header, payload); err != nil {
+				logger.Printf("Error writing to connection: %v", err)
+				return
+			}
+			continue
+		}
+
+		respType, respPayload := h.handleMessage(header, payload, logger)
+		if err := h.writeMessage(respType, header.MessageID, respPayload); err != nil {
+			logger.Printf("Error writing to connection: %v", err)
+			return
+		}
+	}
+}
+
+// requestLogger prefixes every log line it emits with the correlation ID
+// (the wire-level MessageID) of the request being handled, so a client's
+// bug report naming one request ID can be grepped straight out of the
+// server log even when other connections are logging concurrently.
+type requestLogger struct {
+	messageID uint32
+}
+
+// newRequestLogger builds a requestLogger for the request identified by
+// messageID.
+func newRequestLogger(messageID uint32) requestLogger {
+	return requestLogger{messageID: messageID}
+}
+
+// Printf logs format/args the same as log.Printf, prefixed with the
+// request's correlation ID.
+func (l requestLogger) Printf(format string, args ...interface{}) {
+	log.Printf("[req %d] %s", l.messageID, fmt.Sprintf(format, args...))
+}
+
+// isClientDisconnectError reports whether err is the ordinary shape a
+// client connection takes when it goes away: a clean close (io.EOF), a
+// close mid-message (io.ErrUnexpectedEOF), a read on a connection that's
+// already closed (net.ErrClosed), or a read deadline expiring on an idle
+// connection (a net.Error with Timeout() true). None of these indicate a
+// problem worth an error-level log entry.
+func isClientDisconnectError(err error) bool {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func (h *ClientHandler) readMessage() (protocol.MessageHeader, []byte, error) {
+	if h.server != nil && h.server.ReadTimeout > 0 {
+		if err := h.conn.SetReadDeadline(time.Now().Add(h.server.ReadTimeout)); err != nil {
+			return protocol.MessageHeader{}, nil, err
+		}
+	}
+
+	header, payload, err := protocol.ReadMessage(h.conn, h.maxMessageSize())
+	if errors.Is(err, protocol.ErrMessageTooLarge) {
+		// Drain the declared payload off the connection without
+		// allocating a buffer for it, so the connection stays usable for
+		// the client's next (hopefully well-behaved) request.
+		if _, drainErr := io.CopyN(io.Discard, h.conn, int64(header.Length)); drainErr != nil {
+			return header, nil, drainErr
+		}
+		return header, nil, protocol.ErrMessageTooLarge
+	}
+	return header, payload, err
+}
+
+// maxMessageSize is the effective declared-payload-length limit for this
+// connection: the server's configured MaxMessageSize, or
+// defaultMaxMessageSize if unset.
+func (h *ClientHandler) maxMessageSize() uint32 {
+	if h.server != nil && h.server.MaxMessageSize > 0 {
+		return h.server.MaxMessageSize
+	}
+	return defaultMaxMessageSize
+}
+
+func (h *ClientHandler) writeMessage(msgType uint8, id uint32, payload []byte) error {
+	return protocol.WriteMessage(h.conn, msgType, id, payload)
+}
+
+func (h *ClientHandler) handleMessage(header protocol.MessageHeader, payload []byte, logger requestLogger) (respType uint8, respPayload []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Printf("recovered from panic handling message type %s: %v", protocol.MsgType(header.Type), r)
+			resp, _ := json.Marshal(protocol.ErrorResponse{Error: "internal server error"})
+			respType, respPayload = protocol.MsgTypeErrorResponse, resp
+		}
+	}()
+
+	switch header.Type {
+	case protocol.MsgTypeRegisterRequest:
+		return h.handleRegister(payload)
+	case protocol.MsgTypeAuthRequest:
+		return h.handleAuth(payload)
+	case protocol.MsgTypeStoreDataRequest:
+		return h.handleStoreData(payload)
+	case protocol.MsgTypeGetDataRequest:
+		return h.handleGetData(payload)
+	case protocol.MsgTypeSyncRequest:
+		return h.handleSync(payload)
+	case protocol.MsgTypeUpdateDataRequest:
+		return h.handleUpdateData(payload)
+	case protocol.MsgTypeDeleteDataRequest:
+		return h.handleDeleteData(payload)
+	case protocol.MsgTypeDownloadRequest:
+		return h.handleDownload(payload)
+	case protocol.MsgTypeStatsRequest:
+		return h.handleStats(payload)
+	case protocol.MsgTypeRestoreDataRequest:
+		return h.handleRestoreData(payload)
+	case protocol.MsgTypeRecoveryInfoRequest:
+		return h.handleRecoveryInfo(payload)
+	case protocol.MsgTypeRecoverRequest:
+		return h.handleRecover(payload)
+	case protocol.MsgTypeRenameUserRequest:
+		return h.handleRenameUser(payload)
+	case protocol.MsgTypeListModifiedRequest:
+		return h.handleListModified(payload)
+	case protocol.MsgTypeRekeyRequest:
+		return h.handleRekey(payload)
+	case protocol.MsgTypeUpdateMetadataRequest:
+		return h.handleUpdateMetadata(payload)
+	case protocol.MsgTypeCreateShareRequest:
+		return h.handleCreateShare(payload)
+	case protocol.MsgTypeRedeemShareRequest:
+		return h.handleRedeemShare(payload)
+	case protocol.MsgTypePingRequest:
+		return h.handlePing(payload)
+	case protocol.MsgTypeAutocompleteRequest:
+		return h.handleAutocomplete(payload)
+	case protocol.MsgTypeBatchDeleteRequest:
+		return h.handleBatchDelete(payload)
+	case protocol.MsgTypeAttachFileRequest:
+		return h.handleAttachFile(payload)
+	case protocol.MsgTypeListAttachmentsRequest:
+		return h.handleListAttachments(payload)
+	case protocol.MsgTypeDownloadAttachmentRequest:
+		return h.handleDownloadAttachment(payload)
+	case protocol.MsgTypeDeleteAttachmentRequest:
+		return h.handleDeleteAttachment(payload)
+	case protocol.MsgTypeManifestRequest:
+		return h.handleManifest(payload)
+	default:
+		logger.Printf("unknown message type: %s", protocol.MsgType(header.Type))
+		return h.errorResponse(fmt.Errorf("unknown message type: %s", protocol.MsgType(header.Type)))
+	}
+}
+
+func (h *ClientHandler) errorResponse(err error) (uint8, []byte) {
+	payload, _ := json.Marshal(protocol.ErrorResponse{Error: err.Error()})
+	return protocol.MsgTypeErrorResponse, payload
+}
+
+func (h *ClientHandler) authenticate(token string) (int, error) {
+	userID, ok := h.server.sessionUser(token)
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	return userID, nil
+}
+
+// protocolVersionRejected reports whether clientVersion is below minVersion,
+// with a clear error message for the client, if minVersion is set at all.
+func protocolVersionRejected(clientVersion, minVersion int) (string, bool) {
+	if minVersion <= 0 || clientVersion >= minVersion {
+		return "", false
+	}
+	return fmt.Sprintf("client protocol version %d is below the server's required minimum of %d; please upgrade", clientVersion, minVersion), true
+}
+
+func (h *ClientHandler) handleRegister(payload []byte) (uint8, []byte) {
+	var req protocol.RegisterRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	if msg, rejected := protocolVersionRejected(req.ClientVersion, h.server.MinProtocolVersion); rejected {
+		resp, _ := json.Marshal(protocol.RegisterResponse{Success: false, Error: msg})
+		return protocol.MsgTypeRegisterResponse, resp
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), h.server.BcryptCost)
+	if err != nil {
+		return h.errorResponse(err)
+	}
+
+	var recoveryKeyHash string
+	if req.RecoveryKey != "" {
+		rh, err := bcrypt.GenerateFromPassword([]byte(req.RecoveryKey), h.server.BcryptCost)
+		if err != nil {
+			return h.errorResponse(err)
+		}
+		recoveryKeyHash = string(rh)
+	}
+
+	if _, err := h.server.db.CreateUser(req.Username, string(hash), req.WrappedDEK, req.KDFSalt, recoveryKeyHash, req.WrappedDEKRecovery); err != nil {
+		resp, _ := json.Marshal(protocol.RegisterResponse{Success: false, Error: err.Error()})
+		return protocol.MsgTypeRegisterResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.RegisterResponse{Success: true})
+	return protocol.MsgTypeRegisterResponse, resp
+}
+
+func (h *ClientHandler) handleAuth(payload []byte) (uint8, []byte) {
+	var req protocol.AuthRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	if msg, rejected := protocolVersionRejected(req.ClientVersion, h.server.MinProtocolVersion); rejected {
+		resp, _ := json.Marshal(protocol.AuthResponse{Success: false, Error: msg})
+		return protocol.MsgTypeAuthResponse, resp
+	}
+
+	userID, hash, passwordChangedAt, wrappedDEK, kdfSalt, err := h.server.db.GetUserByUsername(req.Username)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.AuthResponse{Success: false, Error: "invalid username or password"})
+		return protocol.MsgTypeAuthResponse, resp
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)); err != nil {
+		resp, _ := json.Marshal(protocol.AuthResponse{Success: false, Error: "invalid username or password"})
+		return protocol.MsgTypeAuthResponse, resp
+	}
+
+	token := h.server.createSession(userID)
+	ageDays, rotationDue := passwordRotationStatus(passwordChangedAt, h.server.PasswordMaxAge, time.Now())
+	resp, _ := json.Marshal(protocol.AuthResponse{
+		Success:     true,
+		Token:       token,
+		PasswordAge: ageDays,
+		RotationDue: rotationDue,
+		WrappedDEK:  wrappedDEK,
+		KDFSalt:     kdfSalt,
+	})
+	return protocol.MsgTypeAuthResponse, resp
+}
+
+func (h *ClientHandler) handleRecoveryInfo(payload []byte) (uint8, []byte) {
+	var req protocol.RecoveryInfoRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+
+	hash, wrapped, kdfSalt, err := h.server.db.GetRecoveryInfo(req.Username)
+	if err != nil || hash == "" {
+		resp, _ := json.Marshal(protocol.RecoveryInfoResponse{Available: false})
+		return protocol.MsgTypeRecoveryInfoResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.RecoveryInfoResponse{Available: true, WrappedDEKRecovery: wrapped, KDFSalt: kdfSalt})
+	return protocol.MsgTypeRecoveryInfoResponse, resp
+}
+
+func (h *ClientHandler) handleRecover(payload []byte) (uint8, []byte) {
+	var req protocol.RecoverRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+
+	recoveryKeyHash, _, _, err := h.server.db.GetRecoveryInfo(req.Username)
+	if err != nil || recoveryKeyHash == "" {
+		resp, _ := json.Marshal(protocol.RecoverResponse{Error: "recovery not available for this account"})
+		return protocol.MsgTypeRecoverResponse, resp
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(recoveryKeyHash), []byte(req.RecoveryKey)); err != nil {
+		resp, _ := json.Marshal(protocol.RecoverResponse{Error: "invalid recovery key"})
+		return protocol.MsgTypeRecoverResponse, resp
+	}
+
+	userID, _, _, _, _, err := h.server.db.GetUserByUsername(req.Username)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.RecoverResponse{Error: err.Error()})
+		return protocol.MsgTypeRecoverResponse, resp
+	}
+
+	newHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), h.server.BcryptCost)
+	if err != nil {
+		return h.errorResponse(err)
+	}
+
+	if err := h.server.db.UpdateWrappedDEKAndPassword(userID, req.NewWrappedDEK, string(newHash)); err != nil {
+		resp, _ := json.Marshal(protocol.RecoverResponse{Error: err.Error()})
+		return protocol.MsgTypeRecoverResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.RecoverResponse{})
+	return protocol.MsgTypeRecoverResponse, resp
+}
+
+func (h *ClientHandler) handleRenameUser(payload []byte) (uint8, []byte) {
+	var req protocol.RenameUserRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.RenameUserResponse{Error: err.Error()})
+		return protocol.MsgTypeRenameUserResponse, resp
+	}
+
+	_, hash, err := h.server.db.GetUserByID(userID)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.RenameUserResponse{Error: err.Error()})
+		return protocol.MsgTypeRenameUserResponse, resp
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)); err != nil {
+		resp, _ := json.Marshal(protocol.RenameUserResponse{Error: "invalid password"})
+		return protocol.MsgTypeRenameUserResponse, resp
+	}
+
+	if err := h.server.db.RenameUser(userID, req.NewUsername); err != nil {
+		resp, _ := json.Marshal(protocol.RenameUserResponse{Error: err.Error()})
+		return protocol.MsgTypeRenameUserResponse, resp
+	}
+	if len(req.NewWrappedDEK) > 0 {
+		if err := h.server.db.UpdateWrappedDEK(userID, req.NewWrappedDEK); err != nil {
+			resp, _ := json.Marshal(protocol.RenameUserResponse{Error: err.Error()})
+			return protocol.MsgTypeRenameUserResponse, resp
+		}
+	}
+
+	resp, _ := json.Marshal(protocol.RenameUserResponse{})
+	return protocol.MsgTypeRenameUserResponse, resp
+}
+
+// passwordRotationStatus reports how many days old a password is and
+// whether it has exceeded maxAge. maxAge <= 0 disables the check.
+func passwordRotationStatus(changedAt time.Time, maxAge time.Duration, now time.Time) (ageDays int, rotationDue bool) {
+	age := now.Sub(changedAt)
+	ageDays = int(age.Hours() / 24)
+	rotationDue = maxAge > 0 && age > maxAge
+	return ageDays, rotationDue
+}
+
+func (h *ClientHandler) handleStoreData(payload []byte) (uint8, []byte) {
+	var req protocol.StoreDataRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.StoreDataResponse{Error: err.Error()})
+		return protocol.MsgTypeStoreDataResponse, resp
+	}
+
+	item, err := h.server.db.StoreDataAndReturn(userID, req.Item)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.StoreDataResponse{Error: err.Error()})
+		return protocol.MsgTypeStoreDataResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.StoreDataResponse{ID: item.ID, Item: item})
+	return protocol.MsgTypeStoreDataResponse, resp
+}
+
+func (h *ClientHandler) handleGetData(payload []byte) (uint8, []byte) {
+	var req protocol.GetDataRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.GetDataResponse{Error: err.Error()})
+		return protocol.MsgTypeGetDataResponse, resp
+	}
+
+	item, err := h.server.db.GetDataByID(userID, req.ID)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.GetDataResponse{Error: err.Error()})
+		return protocol.MsgTypeGetDataResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.GetDataResponse{Item: item})
+	return protocol.MsgTypeGetDataResponse, resp
+}
+
+func (h *ClientHandler) handleSync(payload []byte) (uint8, []byte) {
+	var req protocol.SyncRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.SyncResponse{Error: err.Error()})
+		return protocol.MsgTypeSyncResponse, resp
+	}
+
+	since := req.Since
+	if since.IsZero() {
+		since = time.Time{}
+	}
+	items, err := h.server.db.GetData(userID, since, req.MetadataOnly)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.SyncResponse{Error: err.Error()})
+		return protocol.MsgTypeSyncResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.SyncResponse{Items: items})
+	return protocol.MsgTypeSyncResponse, resp
+}
+
+// handleSyncStream answers a SyncStreamRequest with a MsgTypeSyncBegin
+// (carrying the item count), one MsgTypeSyncItem per item, then a
+// MsgTypeSyncEnd, writing directly to the connection rather than
+// returning a single (type, payload) pair like the rest of
+// handleMessage's dispatch, since this is the one request type that
+// answers with more than one message. An error here (JSON, auth, or
+// the database) is reported in the SyncBegin message, since nothing has
+// been sent to the client yet at that point; a write failure partway
+// through the item stream is returned to the caller, which closes the
+// connection the same as any other write failure.
+func (h *ClientHandler) handleSyncStream(header protocol.MessageHeader, payload []byte) error {
+	var req protocol.SyncStreamRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.writeSyncStreamBeginError(header.MessageID, err.Error())
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		return h.writeSyncStreamBeginError(header.MessageID, err.Error())
+	}
+
+	items, err := h.server.db.GetData(userID, req.Since, req.MetadataOnly)
+	if err != nil {
+		return h.writeSyncStreamBeginError(header.MessageID, err.Error())
+	}
+
+	beginResp, _ := json.Marshal(protocol.SyncBeginResponse{Count: len(items)})
+	if err := h.writeMessage(protocol.MsgTypeSyncBegin, header.MessageID, beginResp); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		itemResp, _ := json.Marshal(protocol.SyncItemResponse{Item: item})
+		if err := h.writeMessage(protocol.MsgTypeSyncItem, header.MessageID, itemResp); err != nil {
+			return err
+		}
+	}
+
+	endResp, _ := json.Marshal(protocol.SyncEndResponse{})
+	return h.writeMessage(protocol.MsgTypeSyncEnd, header.MessageID, endResp)
+}
+
+func (h *ClientHandler) writeSyncStreamBeginError(id uint32, message string) error {
+	resp, _ := json.Marshal(protocol.SyncBeginResponse{Error: message})
+	return h.writeMessage(protocol.MsgTypeSyncBegin, id, resp)
+}
+
+func (h *ClientHandler) handleUpdateData(payload []byte) (uint8, []byte) {
+	var req protocol.UpdateDataRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.UpdateDataResponse{Error: err.Error()})
+		return protocol.MsgTypeUpdateDataResponse, resp
+	}
+
+	if err := h.server.db.UpdateData(userID, req.ID, req.Item, h.server.AllowTypeChange); err != nil {
+		resp, _ := json.Marshal(protocol.UpdateDataResponse{Error: err.Error()})
+		return protocol.MsgTypeUpdateDataResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.UpdateDataResponse{})
+	return protocol.MsgTypeUpdateDataResponse, resp
+}
+
+func (h *ClientHandler) handleDeleteData(payload []byte) (uint8, []byte) {
+	var req protocol.DeleteDataRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.DeleteDataResponse{Error: err.Error()})
+		return protocol.MsgTypeDeleteDataResponse, resp
+	}
+
+	if err := h.server.db.DeleteData(userID, req.ID); err != nil {
+		resp, _ := json.Marshal(protocol.DeleteDataResponse{Error: err.Error()})
+		return protocol.MsgTypeDeleteDataResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.DeleteDataResponse{})
+	return protocol.MsgTypeDeleteDataResponse, resp
+}
+
+// handleBatchDelete deletes every requested ID independently, continuing
+// past per-item failures so one bad ID (already deleted, wrong user)
+// doesn't abort deletion of the rest of the batch. Only a request-level
+// problem (bad JSON, auth failure) short-circuits before any deletes.
+func (h *ClientHandler) handleBatchDelete(payload []byte) (uint8, []byte) {
+	var req protocol.BatchDeleteRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.BatchDeleteResponse{Error: err.Error()})
+		return protocol.MsgTypeBatchDeleteResponse, resp
+	}
+
+	results := make([]protocol.BatchDeleteResult, len(req.IDs))
+	for i, id := range req.IDs {
+		result := protocol.BatchDeleteResult{ID: id}
+		if err := h.server.db.DeleteData(userID, id); err != nil {
+			result.Error = err.Error()
+		}
+		results[i] = result
+	}
+
+	resp, _ := json.Marshal(protocol.BatchDeleteResponse{Results: results})
+	return protocol.MsgTypeBatchDeleteResponse, resp
+}
+
+func (h *ClientHandler) handleDownload(payload []byte) (uint8, []byte) {
+	var req protocol.DownloadRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.DownloadResponse{Error: err.Error()})
+		return protocol.MsgTypeDownloadResponse, resp
+	}
+
+	item, err := h.server.db.GetDataByID(userID, req.ID)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.DownloadResponse{Error: err.Error()})
+		return protocol.MsgTypeDownloadResponse, resp
+	}
+
+	data, err := sliceRange(item.Data, req.Offset, req.Length)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.DownloadResponse{Error: err.Error()})
+		return protocol.MsgTypeDownloadResponse, resp
+	}
+
+	checksum := sha256.Sum256(item.Data)
+	resp, _ := json.Marshal(protocol.DownloadResponse{
+		Data:           data,
+		TotalSize:      int64(len(item.Data)),
+		ChecksumSHA256: hex.EncodeToString(checksum[:]),
+	})
+	return protocol.MsgTypeDownloadResponse, resp
+}
+
+// sliceRange returns the [offset, offset+length) slice of data for a
+// chunked or resumable download. offset == 0 && length == 0 is the
+// ordinary whole-file case and returns data unchanged; length == 0 with
+// a positive offset returns everything from offset to the end, which is
+// how a client resumes a partial download. It's a plain function (not a
+// method) so the range arithmetic can be tested without a database.
+func sliceRange(data []byte, offset, length int64) ([]byte, error) {
+	if offset == 0 && length == 0 {
+		return data, nil
+	}
+	size := int64(len(data))
+	if offset < 0 || offset > size {
+		return nil, ErrInvalidRange
+	}
+	end := size
+	if length > 0 {
+		end = offset + length
+		if end > size {
+			return nil, ErrInvalidRange
+		}
+	}
+	return data[offset:end], nil
+}
+
+func (h *ClientHandler) handleRestoreData(payload []byte) (uint8, []byte) {
+	var req protocol.RestoreDataRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.RestoreDataResponse{Error: err.Error()})
+		return protocol.MsgTypeRestoreDataResponse, resp
+	}
+
+	if err := h.server.db.RestoreData(userID, req.ID); err != nil {
+		resp, _ := json.Marshal(protocol.RestoreDataResponse{Error: err.Error()})
+		return protocol.MsgTypeRestoreDataResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.RestoreDataResponse{})
+	return protocol.MsgTypeRestoreDataResponse, resp
+}
+
+func (h *ClientHandler) handleListModified(payload []byte) (uint8, []byte) {
+	var req protocol.ListModifiedRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.ListModifiedResponse{Error: err.Error()})
+		return protocol.MsgTypeListModifiedResponse, resp
+	}
+
+	items, err := h.server.db.GetDataModifiedBetween(userID, req.Since, req.Until)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.ListModifiedResponse{Error: err.Error()})
+		return protocol.MsgTypeListModifiedResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.ListModifiedResponse{Items: items})
+	return protocol.MsgTypeListModifiedResponse, resp
+}
+
+// maxAutocompleteLimit bounds how many items handleAutocomplete will
+// return regardless of what the client asks for, so a malicious or
+// buggy Limit can't turn an interactive-typing query into a full table
+// scan's worth of response payload.
+const maxAutocompleteLimit = 50
+
+func (h *ClientHandler) handleAutocomplete(payload []byte) (uint8, []byte) {
+	var req protocol.AutocompleteRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.AutocompleteResponse{Error: err.Error()})
+		return protocol.MsgTypeAutocompleteResponse, resp
+	}
+
+	limit := req.Limit
+	if limit <= 0 || limit > maxAutocompleteLimit {
+		limit = maxAutocompleteLimit
+	}
+
+	items, err := h.server.db.SearchByNamePrefix(userID, req.Prefix, limit)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.AutocompleteResponse{Error: err.Error()})
+		return protocol.MsgTypeAutocompleteResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.AutocompleteResponse{Items: items})
+	return protocol.MsgTypeAutocompleteResponse, resp
+}
+
+func (h *ClientHandler) handleRekey(payload []byte) (uint8, []byte) {
+	var req protocol.RekeyRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.RekeyResponse{Error: err.Error()})
+		return protocol.MsgTypeRekeyResponse, resp
+	}
+
+	if err := h.server.db.UpdateWrappedDEK(userID, req.NewWrappedDEK); err != nil {
+		resp, _ := json.Marshal(protocol.RekeyResponse{Error: err.Error()})
+		return protocol.MsgTypeRekeyResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.RekeyResponse{})
+	return protocol.MsgTypeRekeyResponse, resp
+}
+
+func (h *ClientHandler) handleUpdateMetadata(payload []byte) (uint8, []byte) {
+	var req protocol.UpdateMetadataRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.UpdateMetadataResponse{Error: err.Error()})
+		return protocol.MsgTypeUpdateMetadataResponse, resp
+	}
+
+	if err := h.server.db.UpdateMetadata(userID, req.ID, req.Metadata); err != nil {
+		resp, _ := json.Marshal(protocol.UpdateMetadataResponse{Error: err.Error()})
+		return protocol.MsgTypeUpdateMetadataResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.UpdateMetadataResponse{})
+	return protocol.MsgTypeUpdateMetadataResponse, resp
+}
+
+func (h *ClientHandler) handleCreateShare(payload []byte) (uint8, []byte) {
+	var req protocol.CreateShareRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.CreateShareResponse{Error: err.Error()})
+		return protocol.MsgTypeCreateShareResponse, resp
+	}
+
+	// GetDataByID scopes the lookup to userID, so this also proves the
+	// caller owns the item being shared.
+	if _, err := h.server.db.GetDataByID(userID, req.ItemID); err != nil {
+		resp, _ := json.Marshal(protocol.CreateShareResponse{Error: err.Error()})
+		return protocol.MsgTypeCreateShareResponse, resp
+	}
+
+	maxAccesses := req.MaxAccesses
+	if maxAccesses <= 0 {
+		maxAccesses = 1
+	}
+
+	shareID, err := h.server.db.CreateShare(userID, req.ItemID, req.Type, req.Name, req.EncryptedData, req.ExpiresAt, maxAccesses)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.CreateShareResponse{Error: err.Error()})
+		return protocol.MsgTypeCreateShareResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.CreateShareResponse{ShareID: shareID})
+	return protocol.MsgTypeCreateShareResponse, resp
+}
+
+func (h *ClientHandler) handleRedeemShare(payload []byte) (uint8, []byte) {
+	var req protocol.RedeemShareRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+
+	itemType, name, data, err := h.server.db.RedeemShare(req.ShareID)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.RedeemShareResponse{Error: err.Error()})
+		return protocol.MsgTypeRedeemShareResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.RedeemShareResponse{Type: itemType, Name: name, Data: data})
+	return protocol.MsgTypeRedeemShareResponse, resp
+}
+
+// handleAttachFile links an already client-encrypted file to req.ItemID.
+// GetDataByID scopes the ownership check to userID, the same as
+// handleCreateShare does for the item it snapshots.
+func (h *ClientHandler) handleAttachFile(payload []byte) (uint8, []byte) {
+	var req protocol.AttachFileRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.AttachFileResponse{Error: err.Error()})
+		return protocol.MsgTypeAttachFileResponse, resp
+	}
+
+	if _, err := h.server.db.GetDataByID(userID, req.ItemID); err != nil {
+		resp, _ := json.Marshal(protocol.AttachFileResponse{Error: err.Error()})
+		return protocol.MsgTypeAttachFileResponse, resp
+	}
+
+	id, err := h.server.db.AttachFile(userID, req.ItemID, req.Filename, req.Data)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.AttachFileResponse{Error: err.Error()})
+		return protocol.MsgTypeAttachFileResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.AttachFileResponse{ID: id})
+	return protocol.MsgTypeAttachFileResponse, resp
+}
+
+func (h *ClientHandler) handleListAttachments(payload []byte) (uint8, []byte) {
+	var req protocol.ListAttachmentsRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.ListAttachmentsResponse{Error: err.Error()})
+		return protocol.MsgTypeListAttachmentsResponse, resp
+	}
+
+	attachments, err := h.server.db.ListAttachments(userID, req.ItemID)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.ListAttachmentsResponse{Error: err.Error()})
+		return protocol.MsgTypeListAttachmentsResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.ListAttachmentsResponse{Attachments: attachments})
+	return protocol.MsgTypeListAttachmentsResponse, resp
+}
+
+func (h *ClientHandler) handleDownloadAttachment(payload []byte) (uint8, []byte) {
+	var req protocol.DownloadAttachmentRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.DownloadAttachmentResponse{Error: err.Error()})
+		return protocol.MsgTypeDownloadAttachmentResponse, resp
+	}
+
+	attachment, data, err := h.server.db.GetAttachment(userID, req.ID)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.DownloadAttachmentResponse{Error: err.Error()})
+		return protocol.MsgTypeDownloadAttachmentResponse, resp
+	}
+
+	checksum := sha256.Sum256(data)
+	resp, _ := json.Marshal(protocol.DownloadAttachmentResponse{
+		Filename:       attachment.Filename,
+		Data:           data,
+		ChecksumSHA256: hex.EncodeToString(checksum[:]),
+	})
+	return protocol.MsgTypeDownloadAttachmentResponse, resp
+}
+
+func (h *ClientHandler) handleDeleteAttachment(payload []byte) (uint8, []byte) {
+	var req protocol.DeleteAttachmentRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.DeleteAttachmentResponse{Error: err.Error()})
+		return protocol.MsgTypeDeleteAttachmentResponse, resp
+	}
+
+	if err := h.server.db.DeleteAttachment(userID, req.ID); err != nil {
+		resp, _ := json.Marshal(protocol.DeleteAttachmentResponse{Error: err.Error()})
+		return protocol.MsgTypeDeleteAttachmentResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.DeleteAttachmentResponse{})
+	return protocol.MsgTypeDeleteAttachmentResponse, resp
+}
+
+// handleManifest answers with a lightweight summary of every item the
+// caller owns, for the client to diff against its local state.
+func (h *ClientHandler) handleManifest(payload []byte) (uint8, []byte) {
+	var req protocol.ManifestRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.ManifestResponse{Error: err.Error()})
+		return protocol.MsgTypeManifestResponse, resp
+	}
+
+	entries, err := h.server.db.GetManifest(userID)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.ManifestResponse{Error: err.Error()})
+		return protocol.MsgTypeManifestResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.ManifestResponse{Entries: entries})
+	return protocol.MsgTypeManifestResponse, resp
+}
+
+// handlePing answers a PingRequest immediately; it's unauthenticated, so
+// a client can use it to measure round-trip latency, check protocol
+// compatibility, and check database health before attempting to log in.
+// DBHealthy is reported as false rather than panicking if h.server or its
+// database isn't set, since Ping is meant to stay safe to call in any
+// state.
+func (h *ClientHandler) handlePing(payload []byte) (uint8, []byte) {
+	dbHealthy := h.server != nil && h.server.db != nil && h.server.db.Healthy()
+	resp, _ := json.Marshal(protocol.PingResponse{
+		ServerVersion: protocol.Version,
+		DBHealthy:     dbHealthy,
+	})
+	return protocol.MsgTypePingResponse, resp
+}
+
+func (h *ClientHandler) handleStats(payload []byte) (uint8, []byte) {
+	var req protocol.StatsRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return h.errorResponse(err)
+	}
+	userID, err := h.authenticate(req.Token)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.StatsResponse{Error: err.Error()})
+		return protocol.MsgTypeStatsResponse, resp
+	}
+
+	stats, err := h.server.db.GetUserStats(userID)
+	if err != nil {
+		resp, _ := json.Marshal(protocol.StatsResponse{Error: err.Error()})
+		return protocol.MsgTypeStatsResponse, resp
+	}
+
+	resp, _ := json.Marshal(protocol.StatsResponse{Stats: stats})
+	return protocol.MsgTypeStatsResponse, resp
+}
@@ -0,0 +1,748 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestPasswordRotationStatus(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		changedAt   time.Time
+		maxAge      time.Duration
+		wantDue     bool
+		wantAgeDays int
+	}{
+		{"fresh password", now.Add(-1 * 24 * time.Hour), 90 * 24 * time.Hour, false, 1},
+		{"exactly at threshold", now.Add(-90 * 24 * time.Hour), 90 * 24 * time.Hour, false, 90},
+		{"past threshold", now.Add(-91 * 24 * time.Hour), 90 * 24 * time.Hour, true, 91},
+		{"check disabled", now.Add(-1000 * 24 * time.Hour), 0, false, 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ageDays, due := passwordRotationStatus(tt.changedAt, tt.maxAge, now)
+			if due != tt.wantDue {
+				t.Errorf("rotationDue = %v, want %v", due, tt.wantDue)
+			}
+			if ageDays != tt.wantAgeDays {
+				t.Errorf("ageDays = %d, want %d", ageDays, tt.wantAgeDays)
+			}
+		})
+	}
+}
+
+// TestHandleRegisterHashesAtConfiguredCost verifies that registering a
+// new user hashes its password with the server's configured BcryptCost,
+// not bcrypt.DefaultCost, and that the cost is recoverable from the
+// stored hash alone (bcrypt embeds it), matching how CompareHashAndPassword
+// verifies it later regardless of the server's current setting.
+func TestHandleRegisterHashesAtConfiguredCost(t *testing.T) {
+	db := testDatabase(t)
+
+	srv := NewServer("", db)
+	srv.BcryptCost = bcrypt.MinCost
+	h := &ClientHandler{server: srv}
+
+	req := protocol.RegisterRequest{
+		Username:   "cost-test-user",
+		Password:   "hunter2",
+		WrappedDEK: []byte("wrapped-dek"),
+		KDFSalt:    []byte("salt"),
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal register request: %v", err)
+	}
+
+	respType, respPayload := h.handleRegister(payload)
+	if respType != protocol.MsgTypeRegisterResponse {
+		t.Fatalf("unexpected response type: %d", respType)
+	}
+	var resp protocol.RegisterResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		t.Fatalf("unmarshal register response: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("register failed: %s", resp.Error)
+	}
+
+	_, hash, _, _, _, err := db.GetUserByUsername(req.Username)
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		t.Fatalf("read cost from stored hash: %v", err)
+	}
+	if cost != bcrypt.MinCost {
+		t.Fatalf("expected the stored hash to carry cost %d, got %d", bcrypt.MinCost, cost)
+	}
+}
+
+// TestHandleRegisterRejectsBelowMinimumProtocolVersion verifies that a
+// server with MinProtocolVersion set refuses to register a client
+// reporting an older version, with an error explaining why.
+func TestHandleRegisterRejectsBelowMinimumProtocolVersion(t *testing.T) {
+	db := testDatabase(t)
+
+	srv := NewServer("", db)
+	srv.MinProtocolVersion = 2
+	h := &ClientHandler{server: srv}
+
+	req := protocol.RegisterRequest{
+		Username:      "old-client-user",
+		Password:      "hunter2",
+		WrappedDEK:    []byte("wrapped-dek"),
+		KDFSalt:       []byte("salt"),
+		ClientVersion: 1,
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal register request: %v", err)
+	}
+
+	respType, respPayload := h.handleRegister(payload)
+	if respType != protocol.MsgTypeRegisterResponse {
+		t.Fatalf("unexpected response type: %d", respType)
+	}
+	var resp protocol.RegisterResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		t.Fatalf("unmarshal register response: %v", err)
+	}
+	if resp.Success {
+		t.Fatal("expected registration to be rejected for a below-minimum client version")
+	}
+	if resp.Error == "" {
+		t.Fatal("expected a non-empty error explaining the rejection")
+	}
+
+	if _, _, _, _, _, err := db.GetUserByUsername(req.Username); err == nil {
+		t.Fatal("expected no user to be created for a rejected registration")
+	}
+}
+
+// TestHandleRegisterAcceptsAtMinimumProtocolVersion verifies that a
+// client reporting exactly MinProtocolVersion is allowed to register.
+func TestHandleRegisterAcceptsAtMinimumProtocolVersion(t *testing.T) {
+	db := testDatabase(t)
+
+	srv := NewServer("", db)
+	srv.MinProtocolVersion = 2
+	h := &ClientHandler{server: srv}
+
+	req := protocol.RegisterRequest{
+		Username:      "current-client-user",
+		Password:      "hunter2",
+		WrappedDEK:    []byte("wrapped-dek"),
+		KDFSalt:       []byte("salt"),
+		ClientVersion: 2,
+	}
+	payload, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal register request: %v", err)
+	}
+
+	respType, respPayload := h.handleRegister(payload)
+	if respType != protocol.MsgTypeRegisterResponse {
+		t.Fatalf("unexpected response type: %d", respType)
+	}
+	var resp protocol.RegisterResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		t.Fatalf("unmarshal register response: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected registration to succeed at the minimum version: %s", resp.Error)
+	}
+}
+
+// TestHandleAuthRejectsBelowMinimumProtocolVersion verifies that a
+// server with MinProtocolVersion set refuses to authenticate a client
+// reporting an older version, without even checking its credentials.
+func TestHandleAuthRejectsBelowMinimumProtocolVersion(t *testing.T) {
+	db := testDatabase(t)
+
+	srv := NewServer("", db)
+	h := &ClientHandler{server: srv}
+
+	registerReq := protocol.RegisterRequest{
+		Username:   "auth-version-user",
+		Password:   "hunter2",
+		WrappedDEK: []byte("wrapped-dek"),
+		KDFSalt:    []byte("salt"),
+	}
+	payload, _ := json.Marshal(registerReq)
+	if _, respPayload := h.handleRegister(payload); true {
+		var resp protocol.RegisterResponse
+		if err := json.Unmarshal(respPayload, &resp); err != nil || !resp.Success {
+			t.Fatalf("failed to set up test user: %v %s", err, resp.Error)
+		}
+	}
+
+	srv.MinProtocolVersion = 2
+	authReq := protocol.AuthRequest{
+		Username:      registerReq.Username,
+		Password:      registerReq.Password,
+		ClientVersion: 1,
+	}
+	authPayload, err := json.Marshal(authReq)
+	if err != nil {
+		t.Fatalf("marshal auth request: %v", err)
+	}
+
+	respType, respPayload := h.handleAuth(authPayload)
+	if respType != protocol.MsgTypeAuthResponse {
+		t.Fatalf("unexpected response type: %d", respType)
+	}
+	var resp protocol.AuthResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		t.Fatalf("unmarshal auth response: %v", err)
+	}
+	if resp.Success {
+		t.Fatal("expected authentication to be rejected for a below-minimum client version")
+	}
+	if resp.Token != "" {
+		t.Fatal("expected no session token to be issued for a rejected authentication")
+	}
+}
+
+// TestHandleAuthAcceptsAtMinimumProtocolVersion verifies that a client
+// reporting exactly MinProtocolVersion authenticates normally.
+func TestHandleAuthAcceptsAtMinimumProtocolVersion(t *testing.T) {
+	db := testDatabase(t)
+
+	srv := NewServer("", db)
+	h := &ClientHandler{server: srv}
+
+	registerReq := protocol.RegisterRequest{
+		Username:   "auth-version-ok-user",
+		Password:   "hunter2",
+		WrappedDEK: []byte("wrapped-dek"),
+		KDFSalt:    []byte("salt"),
+	}
+	payload, _ := json.Marshal(registerReq)
+	if _, respPayload := h.handleRegister(payload); true {
+		var resp protocol.RegisterResponse
+		if err := json.Unmarshal(respPayload, &resp); err != nil || !resp.Success {
+			t.Fatalf("failed to set up test user: %v %s", err, resp.Error)
+		}
+	}
+
+	srv.MinProtocolVersion = 2
+	authReq := protocol.AuthRequest{
+		Username:      registerReq.Username,
+		Password:      registerReq.Password,
+		ClientVersion: 2,
+	}
+	authPayload, err := json.Marshal(authReq)
+	if err != nil {
+		t.Fatalf("marshal auth request: %v", err)
+	}
+
+	respType, respPayload := h.handleAuth(authPayload)
+	if respType != protocol.MsgTypeAuthResponse {
+		t.Fatalf("unexpected response type: %d", respType)
+	}
+	var resp protocol.AuthResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		t.Fatalf("unmarshal auth response: %v", err)
+	}
+	if !resp.Success {
+		t.Fatalf("expected authentication to succeed at the minimum version: %s", resp.Error)
+	}
+	if resp.Token == "" {
+		t.Fatal("expected a session token to be issued")
+	}
+}
+
+// TestHandleReturnsCleanlyOnEOFWithoutLoggingError verifies that a
+// client disconnecting cleanly mid-read makes Handle return without
+// logging it as an error.
+func TestHandleReturnsCleanlyOnEOFWithoutLoggingError(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+
+	var logBuf bytes.Buffer
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(os.Stderr)
+
+	h := NewClientHandler(serverConn, &Server{sessions: make(map[string]int)})
+
+	done := make(chan struct{})
+	go func() {
+		h.Handle()
+		close(done)
+	}()
+
+	clientConn.Close()
+	<-done
+
+	if strings.Contains(logBuf.String(), "Error reading from connection") {
+		t.Fatalf("expected no error log for a clean disconnect, got: %s", logBuf.String())
+	}
+}
+
+// TestHandleLogsCorrelateWithMessageID verifies that every log line
+// emitted while handling a request is tagged with that request's
+// MessageID, so a user's bug report naming one request ID can be traced
+// through the server log.
+func TestHandleLogsCorrelateWithMessageID(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	var logBuf bytes.Buffer
+	log.SetOutput(&logBuf)
+	defer log.SetOutput(os.Stderr)
+
+	h := NewClientHandler(serverConn, &Server{sessions: make(map[string]int)})
+	go h.Handle()
+
+	const wantMessageID = 4242
+	header := make([]byte, protocol.HeaderSize)
+	header[0] = 250 // not a defined MsgType, so the handler logs and error-responds
+	binary.BigEndian.PutUint32(header[1:5], wantMessageID)
+	binary.BigEndian.PutUint32(header[5:9], 0)
+	if _, err := clientConn.Write(header); err != nil {
+		t.Fatalf("write request: %v", err)
+	}
+
+	respHeader := make([]byte, protocol.HeaderSize)
+	if _, err := io.ReadFull(clientConn, respHeader); err != nil {
+		t.Fatalf("read response header: %v", err)
+	}
+	length := binary.BigEndian.Uint32(respHeader[5:9])
+	if length > 0 {
+		io.CopyN(io.Discard, clientConn, int64(length))
+	}
+
+	want := fmt.Sprintf("[req %d]", wantMessageID)
+	if !strings.Contains(logBuf.String(), want) {
+		t.Fatalf("expected log output to contain %q, got: %s", want, logBuf.String())
+	}
+}
+
+func TestSliceRangeWholeFile(t *testing.T) {
+	data := []byte("0123456789")
+	got, err := sliceRange(data, 0, 0)
+	if err != nil {
+		t.Fatalf("sliceRange: %v", err)
+	}
+	if string(got) != "0123456789" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestSliceRangeMidFile(t *testing.T) {
+	data := []byte("0123456789")
+	got, err := sliceRange(data, 3, 4)
+	if err != nil {
+		t.Fatalf("sliceRange: %v", err)
+	}
+	if string(got) != "3456" {
+		t.Fatalf("got %q, want %q", got, "3456")
+	}
+}
+
+func TestSliceRangeFromOffsetToEnd(t *testing.T) {
+	data := []byte("0123456789")
+	got, err := sliceRange(data, 7, 0)
+	if err != nil {
+		t.Fatalf("sliceRange: %v", err)
+	}
+	if string(got) != "789" {
+		t.Fatalf("got %q, want %q", got, "789")
+	}
+}
+
+func TestSliceRangeOutOfBounds(t *testing.T) {
+	data := []byte("0123456789")
+	cases := []struct {
+		name   string
+		offset int64
+		length int64
+	}{
+		{"negative offset", -1, 1},
+		{"offset past end", 11, 0},
+		{"length runs past end", 8, 5},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := sliceRange(data, tt.offset, tt.length); !errors.Is(err, ErrInvalidRange) {
+				t.Fatalf("expected ErrInvalidRange, got %v", err)
+			}
+		})
+	}
+}
+
+// TestHandleDownloadServesMidFileRange verifies that a DownloadRequest
+// with Offset/Length returns only that slice of the stored data, plus
+// the item's full TotalSize for the client to track progress against.
+func TestHandleDownloadServesMidFileRange(t *testing.T) {
+	db := testDatabase(t)
+	userID := createTestUser(t, db, "download-range-user")
+
+	id, err := db.StoreData(userID, protocol.NewDataItem{
+		Type: protocol.DataTypeBinary, Name: "file.bin", Data: []byte("0123456789"), Metadata: map[string]string{},
+	})
+	if err != nil {
+		t.Fatalf("store data: %v", err)
+	}
+
+	srv := NewServer("", db)
+	token := srv.createSession(userID)
+	h := &ClientHandler{server: srv}
+
+	payload, err := json.Marshal(protocol.DownloadRequest{Token: token, ID: id, Offset: 3, Length: 4})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	respType, respPayload := h.handleDownload(payload)
+	if respType != protocol.MsgTypeDownloadResponse {
+		t.Fatalf("unexpected response type: %d", respType)
+	}
+	var resp protocol.DownloadResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp.Error != "" {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	if string(resp.Data) != "3456" {
+		t.Fatalf("got %q, want %q", resp.Data, "3456")
+	}
+	if resp.TotalSize != 10 {
+		t.Fatalf("TotalSize = %d, want 10", resp.TotalSize)
+	}
+	wantSum := sha256.Sum256([]byte("0123456789"))
+	if resp.ChecksumSHA256 != hex.EncodeToString(wantSum[:]) {
+		t.Fatalf("ChecksumSHA256 = %q, want checksum of the full stored data", resp.ChecksumSHA256)
+	}
+}
+
+// TestHandleDownloadRejectsOutOfBoundsRange verifies that a range
+// extending past the stored data's end comes back as an error response
+// rather than a truncated or panicking read.
+func TestHandleDownloadRejectsOutOfBoundsRange(t *testing.T) {
+	db := testDatabase(t)
+	userID := createTestUser(t, db, "download-range-oob-user")
+
+	id, err := db.StoreData(userID, protocol.NewDataItem{
+		Type: protocol.DataTypeBinary, Name: "file.bin", Data: []byte("0123456789"), Metadata: map[string]string{},
+	})
+	if err != nil {
+		t.Fatalf("store data: %v", err)
+	}
+
+	srv := NewServer("", db)
+	token := srv.createSession(userID)
+	h := &ClientHandler{server: srv}
+
+	payload, err := json.Marshal(protocol.DownloadRequest{Token: token, ID: id, Offset: 8, Length: 5})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	_, respPayload := h.handleDownload(payload)
+	var resp protocol.DownloadResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp.Error == "" {
+		t.Fatal("expected an error for an out-of-bounds range")
+	}
+}
+
+// TestHandlePingRespondsWithoutAuth verifies that a PingRequest is
+// answered directly, with no token required, and reports this build's
+// protocol version. With no server (and so no database) attached,
+// DBHealthy degrades to false instead of panicking.
+func TestHandlePingRespondsWithoutAuth(t *testing.T) {
+	h := &ClientHandler{}
+	respType, respPayload := h.handlePing(nil)
+	if respType != protocol.MsgTypePingResponse {
+		t.Fatalf("unexpected response type: %d", respType)
+	}
+	var resp protocol.PingResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp.ServerVersion != protocol.Version {
+		t.Fatalf("expected ServerVersion %d, got %d", protocol.Version, resp.ServerVersion)
+	}
+	if resp.DBHealthy {
+		t.Fatal("expected DBHealthy to be false with no server attached")
+	}
+}
+
+// TestHandleMessageRecoversFromHandlerPanic verifies that a panic inside
+// a dispatched handler (here, a nil Server causing authenticate to
+// dereference a nil pointer) is recovered, logged, and turned into a
+// generic error response, rather than crashing the connection's
+// goroutine.
+func TestHandleMessageRecoversFromHandlerPanic(t *testing.T) {
+	h := &ClientHandler{}
+	payload, err := json.Marshal(protocol.StatsRequest{Token: "whatever"})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+
+	respType, respPayload := h.handleMessage(protocol.MessageHeader{Type: protocol.MsgTypeStatsRequest, MessageID: 1}, payload, newRequestLogger(1))
+
+	if respType != protocol.MsgTypeErrorResponse {
+		t.Fatalf("expected MsgTypeErrorResponse, got %s", protocol.MsgType(respType))
+	}
+	var resp protocol.ErrorResponse
+	if err := json.Unmarshal(respPayload, &resp); err != nil {
+		t.Fatalf("unmarshal error response: %v", err)
+	}
+	if resp.Error == "" {
+		t.Fatal("expected a non-empty error message")
+	}
+}
+
+// TestHandleRejectsOversizedDeclaredLength verifies that a header
+// declaring a payload larger than the server's MaxMessageSize is drained
+// off the connection and answered with a "message too large" error,
+// without the server allocating a buffer for it, and that the
+// connection stays open for the client's next request.
+func TestHandleRejectsOversizedDeclaredLength(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	srv := &Server{sessions: make(map[string]int), MaxMessageSize: 16}
+	h := NewClientHandler(serverConn, srv)
+	go h.Handle()
+
+	const wantMessageID = 99
+	header := make([]byte, protocol.HeaderSize)
+	header[0] = protocol.MsgTypeSyncRequest
+	binary.BigEndian.PutUint32(header[1:5], wantMessageID)
+	binary.BigEndian.PutUint32(header[5:9], 1024)
+	if _, err := clientConn.Write(header); err != nil {
+		t.Fatalf("write oversized header: %v", err)
+	}
+	oversizedPayload := make([]byte, 1024)
+	if _, err := clientConn.Write(oversizedPayload); err != nil {
+		t.Fatalf("write oversized payload: %v", err)
+	}
+
+	respHeader := make([]byte, protocol.HeaderSize)
+	if _, err := io.ReadFull(clientConn, respHeader); err != nil {
+		t.Fatalf("read response header: %v", err)
+	}
+	if respHeader[0] != protocol.MsgTypeErrorResponse {
+		t.Fatalf("unexpected response type: %d", respHeader[0])
+	}
+	length := binary.BigEndian.Uint32(respHeader[5:9])
+	respPayload := make([]byte, length)
+	if _, err := io.ReadFull(clientConn, respPayload); err != nil {
+		t.Fatalf("read response payload: %v", err)
+	}
+	var errResp protocol.ErrorResponse
+	if err := json.Unmarshal(respPayload, &errResp); err != nil {
+		t.Fatalf("unmarshal error response: %v", err)
+	}
+	if errResp.Error != protocol.ErrMessageTooLarge.Error() {
+		t.Fatalf("got error %q, want %q", errResp.Error, protocol.ErrMessageTooLarge.Error())
+	}
+}
+
+// TestHandleAcceptsMessageWithinLimitAfterOversizedRejection verifies
+// that, after rejecting one oversized message, the connection is still
+// usable: a normal-sized message that follows is handled as usual.
+func TestHandleAcceptsMessageWithinLimitAfterOversizedRejection(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	srv := &Server{sessions: make(map[string]int), MaxMessageSize: 128}
+	h := NewClientHandler(serverConn, srv)
+	go h.Handle()
+
+	oversizedHeader := make([]byte, protocol.HeaderSize)
+	oversizedHeader[0] = protocol.MsgTypeSyncRequest
+	binary.BigEndian.PutUint32(oversizedHeader[1:5], 1)
+	binary.BigEndian.PutUint32(oversizedHeader[5:9], 1024)
+	if _, err := clientConn.Write(oversizedHeader); err != nil {
+		t.Fatalf("write oversized header: %v", err)
+	}
+	if _, err := clientConn.Write(make([]byte, 1024)); err != nil {
+		t.Fatalf("write oversized payload: %v", err)
+	}
+	if err := drainOneResponse(clientConn); err != nil {
+		t.Fatalf("drain oversized response: %v", err)
+	}
+
+	payload, err := json.Marshal(protocol.SyncRequest{Token: "not-a-real-token"})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	normalHeader := make([]byte, protocol.HeaderSize)
+	normalHeader[0] = protocol.MsgTypeSyncRequest
+	binary.BigEndian.PutUint32(normalHeader[1:5], 2)
+	binary.BigEndian.PutUint32(normalHeader[5:9], uint32(len(payload)))
+	if _, err := clientConn.Write(normalHeader); err != nil {
+		t.Fatalf("write normal header: %v", err)
+	}
+	if _, err := clientConn.Write(payload); err != nil {
+		t.Fatalf("write normal payload: %v", err)
+	}
+
+	respHeader := make([]byte, protocol.HeaderSize)
+	if _, err := io.ReadFull(clientConn, respHeader); err != nil {
+		t.Fatalf("read response header: %v", err)
+	}
+	if respHeader[0] != protocol.MsgTypeSyncResponse {
+		t.Fatalf("unexpected response type: %d", respHeader[0])
+	}
+}
+
+// TestHandleOmitsTrafficLogByDefault verifies that Handle doesn't log
+// per-message type/length lines unless Server.LogTraffic is set, since
+// that logging can reveal usage patterns and should be opt-in.
+func TestHandleOmitsTrafficLogByDefault(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	var logBuf bytes.Buffer
+	origOutput := log.Writer()
+	origFlags := log.Flags()
+	log.SetOutput(&logBuf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(origOutput)
+		log.SetFlags(origFlags)
+	}()
+
+	srv := &Server{sessions: make(map[string]int)}
+	h := NewClientHandler(serverConn, srv)
+	go h.Handle()
+
+	payload, err := json.Marshal(protocol.PingRequest{})
+	if err != nil {
+		t.Fatalf("marshal ping: %v", err)
+	}
+	header := make([]byte, protocol.HeaderSize)
+	header[0] = protocol.MsgTypePingRequest
+	binary.BigEndian.PutUint32(header[1:5], 1)
+	binary.BigEndian.PutUint32(header[5:9], uint32(len(payload)))
+	if _, err := clientConn.Write(header); err != nil {
+		t.Fatalf("write header: %v", err)
+	}
+	if _, err := clientConn.Write(payload); err != nil {
+		t.Fatalf("write payload: %v", err)
+	}
+	if err := drainOneResponse(clientConn); err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+
+	if strings.Contains(logBuf.String(), "Received message type") {
+		t.Fatalf("expected no traffic log line by default, got: %s", logBuf.String())
+	}
+}
+
+// TestHandleLogsTrafficWhenEnabled verifies that Handle logs each
+// message's type and length once Server.LogTraffic is set.
+func TestHandleLogsTrafficWhenEnabled(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	var logBuf bytes.Buffer
+	origOutput := log.Writer()
+	origFlags := log.Flags()
+	log.SetOutput(&logBuf)
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(origOutput)
+		log.SetFlags(origFlags)
+	}()
+
+	srv := &Server{sessions: make(map[string]int), LogTraffic: true}
+	h := NewClientHandler(serverConn, srv)
+	go h.Handle()
+
+	payload, err := json.Marshal(protocol.PingRequest{})
+	if err != nil {
+		t.Fatalf("marshal ping: %v", err)
+	}
+	header := make([]byte, protocol.HeaderSize)
+	header[0] = protocol.MsgTypePingRequest
+	binary.BigEndian.PutUint32(header[1:5], 1)
+	binary.BigEndian.PutUint32(header[5:9], uint32(len(payload)))
+	if _, err := clientConn.Write(header); err != nil {
+		t.Fatalf("write header: %v", err)
+	}
+	if _, err := clientConn.Write(payload); err != nil {
+		t.Fatalf("write payload: %v", err)
+	}
+	if err := drainOneResponse(clientConn); err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+
+	if !strings.Contains(logBuf.String(), "Received message type") {
+		t.Fatalf("expected a traffic log line with LogTraffic enabled, got: %s", logBuf.String())
+	}
+}
+
+// TestHandleClosesIdleConnectionAfterReadTimeout verifies that a client
+// that connects but never sends a request is disconnected once
+// Server.ReadTimeout elapses, rather than tying up the handler forever.
+func TestHandleClosesIdleConnectionAfterReadTimeout(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	srv := &Server{sessions: make(map[string]int), ReadTimeout: 20 * time.Millisecond}
+	h := NewClientHandler(serverConn, srv)
+	done := make(chan struct{})
+	go func() {
+		h.Handle()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Handle to return after the read timeout elapsed")
+	}
+
+	buf := make([]byte, 1)
+	if _, err := clientConn.Read(buf); err == nil {
+		t.Fatal("expected the connection to be closed after the read timeout")
+	}
+}
+
+// drainOneResponse reads and discards a single framed response.
+func drainOneResponse(conn net.Conn) error {
+	header := make([]byte, protocol.HeaderSize)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	length := binary.BigEndian.Uint32(header[5:9])
+	if length > 0 {
+		_, err := io.CopyN(io.Discard, conn, int64(length))
+		return err
+	}
+	return nil
+}
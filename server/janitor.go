@@ -0,0 +1,42 @@
+package server
+
+import "time"
+
+// expireSessions removes every session whose expiry (set by
+// createSession when SessionTTL is positive) is at or before now,
+// returning how many were removed. Sessions created while SessionTTL
+// was zero have no expiry entry and are left alone. It's a plain
+// method, independent of any running goroutine, so it can be tested
+// deterministically.
+func (s *Server) expireSessions(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for token, expiresAt := range s.sessionExpiry {
+		if !expiresAt.After(now) {
+			delete(s.sessions, token)
+			delete(s.sessionExpiry, token)
+			removed++
+		}
+	}
+	return removed
+}
+
+// runSessionJanitor calls expireSessions every interval until stop is
+// closed. It's launched as a background goroutine by Start when
+// SessionJanitorInterval is positive, and stopped by Stop closing the
+// same channel.
+func (s *Server) runSessionJanitor(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.expireSessions(time.Now())
+		case <-stop:
+			return
+		}
+	}
+}
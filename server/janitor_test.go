@@ -0,0 +1,95 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+// TestExpireSessionsRemovesOnlyPastExpiry verifies that expireSessions
+// removes a session whose expiry has passed while leaving a fresh one
+// (and one with no expiry at all) untouched.
+func TestExpireSessionsRemovesOnlyPastExpiry(t *testing.T) {
+	s := NewServer("", nil)
+	now := time.Now()
+
+	s.sessions["expired"] = 1
+	s.sessionExpiry["expired"] = now.Add(-time.Minute)
+
+	s.sessions["fresh"] = 2
+	s.sessionExpiry["fresh"] = now.Add(time.Hour)
+
+	s.sessions["no-ttl"] = 3 // created while SessionTTL was zero: no expiry entry
+
+	removed := s.expireSessions(now)
+	if removed != 1 {
+		t.Fatalf("expireSessions removed %d, want 1", removed)
+	}
+
+	if _, ok := s.sessionUser("expired"); ok {
+		t.Fatal("expected the expired session to be removed")
+	}
+	if userID, ok := s.sessionUser("fresh"); !ok || userID != 2 {
+		t.Fatalf("expected the fresh session to be retained, got userID=%d ok=%v", userID, ok)
+	}
+	if userID, ok := s.sessionUser("no-ttl"); !ok || userID != 3 {
+		t.Fatalf("expected the no-TTL session to be retained, got userID=%d ok=%v", userID, ok)
+	}
+}
+
+// TestRunSessionJanitorSweepsOnATicker verifies that runSessionJanitor
+// removes an already-expired session once its ticker fires, and stops
+// cleanly when its stop channel is closed.
+func TestRunSessionJanitorSweepsOnATicker(t *testing.T) {
+	s := NewServer("", nil)
+	s.sessions["expired"] = 1
+	s.sessionExpiry["expired"] = time.Now().Add(-time.Minute)
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		s.runSessionJanitor(10*time.Millisecond, stop)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, ok := s.sessionUser("expired"); !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the janitor to remove the expired session")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected runSessionJanitor to return after stop was closed")
+	}
+}
+
+// TestSessionTTLPopulatesExpiry verifies that createSession only records
+// an expiry when SessionTTL is positive.
+func TestSessionTTLPopulatesExpiry(t *testing.T) {
+	s := NewServer("", nil)
+	s.SessionTTL = time.Hour
+
+	token := s.createSession(7)
+	s.mu.Lock()
+	_, hasExpiry := s.sessionExpiry[token]
+	s.mu.Unlock()
+	if !hasExpiry {
+		t.Fatal("expected createSession to record an expiry when SessionTTL is set")
+	}
+
+	s2 := NewServer("", nil)
+	token2 := s2.createSession(7)
+	s2.mu.Lock()
+	_, hasExpiry2 := s2.sessionExpiry[token2]
+	s2.mu.Unlock()
+	if hasExpiry2 {
+		t.Fatal("expected no expiry entry when SessionTTL is zero")
+	}
+}
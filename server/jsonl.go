@@ -0,0 +1,58 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// maxJSONLLineSize bounds how large a single JSONL message may be.
+const maxJSONLLineSize = 10 * 1024 * 1024
+
+// jsonlMessage is the on-the-wire shape of a single JSONL frame: one
+// JSON object per line, reusing the same message type and payload the
+// binary framing carries.
+type jsonlMessage struct {
+	Type    uint8           `json:"type"`
+	ID      uint32          `json:"id"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// HandleJSONL is the newline-delimited-JSON counterpart to Handle: same
+// handleMessage dispatch, different framing, for tooling that would
+// rather speak JSON lines than the binary header format.
+func (h *ClientHandler) HandleJSONL() {
+	defer h.conn.Close()
+
+	scanner := bufio.NewScanner(h.conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLineSize)
+
+	for scanner.Scan() {
+		var msg jsonlMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			log.Printf("Error decoding JSONL message: %v", err)
+			return
+		}
+
+		header := protocol.MessageHeader{Type: msg.Type, MessageID: msg.ID, Length: uint32(len(msg.Payload))}
+		logger := newRequestLogger(msg.ID)
+		respType, respPayload := h.handleMessage(header, msg.Payload, logger)
+
+		out, err := json.Marshal(jsonlMessage{Type: respType, ID: msg.ID, Payload: respPayload})
+		if err != nil {
+			log.Printf("Error encoding JSONL response: %v", err)
+			return
+		}
+		if _, err := h.conn.Write(append(out, '\n')); err != nil {
+			log.Printf("Error writing JSONL response: %v", err)
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		log.Printf("Error reading JSONL connection: %v", err)
+	}
+}
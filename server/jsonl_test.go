@@ -0,0 +1,52 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+func TestHandleJSONLDispatchesToHandlers(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer clientConn.Close()
+
+	srv := NewServer(":0", nil)
+	handler := NewClientHandler(serverConn, srv)
+	go handler.HandleJSONL()
+
+	req := jsonlMessage{Type: protocol.MsgTypeStatsRequest, ID: 1}
+	req.Payload, _ = json.Marshal(protocol.StatsRequest{Token: "not-a-real-token"})
+
+	line, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	if _, err := clientConn.Write(append(line, '\n')); err != nil {
+		t.Fatalf("write request: %v", err)
+	}
+
+	reader := bufio.NewReader(clientConn)
+	respLine, err := reader.ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+
+	var resp jsonlMessage
+	if err := json.Unmarshal(respLine, &resp); err != nil {
+		t.Fatalf("unmarshal response: %v", err)
+	}
+	if resp.Type != protocol.MsgTypeStatsResponse {
+		t.Fatalf("expected stats response type %d, got %d", protocol.MsgTypeStatsResponse, resp.Type)
+	}
+
+	var statsResp protocol.StatsResponse
+	if err := json.Unmarshal(resp.Payload, &statsResp); err != nil {
+		t.Fatalf("unmarshal stats response: %v", err)
+	}
+	if statsResp.Error == "" {
+		t.Fatal("expected an error for an unauthenticated request")
+	}
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// migrationsPath is the source URL golang-migrate reads .sql files from.
+const migrationsPath = "file://server/migrations"
+
+// pgDuplicateDatabase is the Postgres error code for CREATE DATABASE
+// racing (or simply repeating) against a database that already exists.
+const pgDuplicateDatabase = "42P04"
+
+// CreateDatabaseIfMissing connects to the "postgres" maintenance
+// database on the same server as connString and issues CREATE DATABASE
+// for connString's target database if it doesn't already exist. It's
+// meant to run before RunMigrations on first-time setup, since
+// NewDatabase only pings an existing database rather than creating one.
+// A concurrent CREATE DATABASE for the same name is treated as success.
+func CreateDatabaseIfMissing(connString string) error {
+	config, err := pgx.ParseConfig(connString)
+	if err != nil {
+		return fmt.Errorf("parse database config: %w", err)
+	}
+	dbName := config.Database
+	if dbName == "" {
+		return fmt.Errorf("connection string has no database name")
+	}
+	config.Database = "postgres"
+
+	ctx := context.Background()
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return fmt.Errorf("connect to maintenance database: %w", err)
+	}
+	defer conn.Close(ctx)
+
+	_, err = conn.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s", pgx.Identifier{dbName}.Sanitize()))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgDuplicateDatabase {
+			return nil
+		}
+		return fmt.Errorf("create database %s: %w", dbName, err)
+	}
+	return nil
+}
+
+// RunMigrations applies every pending migration to the database at
+// connString.
+func RunMigrations(connString string) error {
+	m, err := migrate.New(migrationsPath, connString)
+	if err != nil {
+		return fmt.Errorf("open migrator: %w", err)
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		var dirty migrate.ErrDirty
+		if errors.As(err, &dirty) {
+			log.Printf("Migrations are dirty at version %d (a previous migration failed partway through). "+
+				"Inspect the database, fix or roll back the offending migration by hand, then run "+
+				"`server migrate -force %d` (or the correct version) before starting the server again.", dirty.Version, dirty.Version)
+		}
+		return fmt.Errorf("run migrations: %w", err)
+	}
+	return nil
+}
+
+// ForceMigrationVersion clears the database's dirty flag and sets its
+// recorded migration version to version, without applying or reverting
+// any migration. It's an operator escape hatch for after a migration
+// has failed partway through and been fixed or rolled back by hand; see
+// RunMigrations's logged guidance for when to use it.
+func ForceMigrationVersion(connString string, version int) error {
+	m, err := migrate.New(migrationsPath, connString)
+	if err != nil {
+		return fmt.Errorf("open migrator: %w", err)
+	}
+	defer m.Close()
+
+	if err := m.Force(version); err != nil {
+		return fmt.Errorf("force migration version %d: %w", version, err)
+	}
+	return nil
+}
+
+// MigrationStatus reports the database's current migration version and
+// whether it's marked dirty (a prior migration failed partway through).
+// version is 0 and ok is false if no migration has ever been applied.
+func MigrationStatus(connString string) (version uint, dirty bool, ok bool, err error) {
+	m, err := migrate.New(migrationsPath, connString)
+	if err != nil {
+		return 0, false, false, fmt.Errorf("open migrator: %w", err)
+	}
+	defer m.Close()
+
+	version, dirty, err = m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, false, nil
+	}
+	if err != nil {
+		return 0, false, false, fmt.Errorf("get migration version: %w", err)
+	}
+	return version, dirty, true, nil
+}
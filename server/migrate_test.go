@@ -0,0 +1,192 @@
+package server
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TestCreateDatabaseIfMissingCreatesAndIsIdempotent needs a real Postgres
+// instance (to CREATE/DROP a scratch database) and is meant to run in
+// CI, not as part of a quick unit run.
+func TestCreateDatabaseIfMissingCreatesAndIsIdempotent(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set, skipping database test")
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("parse TEST_DATABASE_URL: %v", err)
+	}
+	scratchName := u.Path[1:] + "_create_if_missing_test"
+	scratchURL := *u
+	scratchURL.Path = "/" + scratchName
+
+	maintenanceURL := *u
+	maintenanceURL.Path = "/postgres"
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, maintenanceURL.String())
+	if err != nil {
+		t.Fatalf("connect to maintenance database: %v", err)
+	}
+	defer conn.Close(ctx)
+	t.Cleanup(func() {
+		_, _ = conn.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{scratchName}.Sanitize())
+	})
+	_, _ = conn.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{scratchName}.Sanitize())
+
+	if err := CreateDatabaseIfMissing(scratchURL.String()); err != nil {
+		t.Fatalf("CreateDatabaseIfMissing (first call): %v", err)
+	}
+
+	var exists bool
+	if err := conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", scratchName).Scan(&exists); err != nil {
+		t.Fatalf("check database exists: %v", err)
+	}
+	if !exists {
+		t.Fatal("expected the scratch database to exist after CreateDatabaseIfMissing")
+	}
+
+	if err := CreateDatabaseIfMissing(scratchURL.String()); err != nil {
+		t.Fatalf("CreateDatabaseIfMissing (second call, already exists): %v", err)
+	}
+}
+
+// TestMigrationStatusReportsFreshThenMigratedDatabase needs a real
+// Postgres instance and is meant to run in CI, not as part of a quick
+// unit run.
+func TestMigrationStatusReportsFreshThenMigratedDatabase(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set, skipping database test")
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("parse TEST_DATABASE_URL: %v", err)
+	}
+	scratchName := u.Path[1:] + "_migration_status_test"
+	scratchURL := *u
+	scratchURL.Path = "/" + scratchName
+
+	maintenanceURL := *u
+	maintenanceURL.Path = "/postgres"
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, maintenanceURL.String())
+	if err != nil {
+		t.Fatalf("connect to maintenance database: %v", err)
+	}
+	defer conn.Close(ctx)
+	t.Cleanup(func() {
+		_, _ = conn.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{scratchName}.Sanitize())
+	})
+	_, _ = conn.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{scratchName}.Sanitize())
+
+	if err := CreateDatabaseIfMissing(scratchURL.String()); err != nil {
+		t.Fatalf("CreateDatabaseIfMissing: %v", err)
+	}
+
+	if _, _, ok, err := MigrationStatus(scratchURL.String()); err != nil {
+		t.Fatalf("MigrationStatus (fresh database): %v", err)
+	} else if ok {
+		t.Fatal("expected ok=false for a fresh database with no migrations applied")
+	}
+
+	if err := RunMigrations(scratchURL.String()); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+
+	version, dirty, ok, err := MigrationStatus(scratchURL.String())
+	if err != nil {
+		t.Fatalf("MigrationStatus (migrated database): %v", err)
+	}
+	if !ok {
+		t.Fatal("expected ok=true after RunMigrations")
+	}
+	if dirty {
+		t.Fatal("expected dirty=false after a clean RunMigrations")
+	}
+	if version == 0 {
+		t.Fatal("expected a non-zero migration version after RunMigrations")
+	}
+}
+
+// TestForceMigrationVersionClearsDirtyAndAllowsFurtherMigration needs a
+// real Postgres instance and is meant to run in CI, not as part of a
+// quick unit run.
+func TestForceMigrationVersionClearsDirtyAndAllowsFurtherMigration(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set, skipping database test")
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("parse TEST_DATABASE_URL: %v", err)
+	}
+	scratchName := u.Path[1:] + "_force_migration_test"
+	scratchURL := *u
+	scratchURL.Path = "/" + scratchName
+
+	maintenanceURL := *u
+	maintenanceURL.Path = "/postgres"
+
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, maintenanceURL.String())
+	if err != nil {
+		t.Fatalf("connect to maintenance database: %v", err)
+	}
+	defer conn.Close(ctx)
+	t.Cleanup(func() {
+		_, _ = conn.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{scratchName}.Sanitize())
+	})
+	_, _ = conn.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{scratchName}.Sanitize())
+
+	if err := CreateDatabaseIfMissing(scratchURL.String()); err != nil {
+		t.Fatalf("CreateDatabaseIfMissing: %v", err)
+	}
+	if err := RunMigrations(scratchURL.String()); err != nil {
+		t.Fatalf("RunMigrations: %v", err)
+	}
+
+	scratchConn, err := pgx.Connect(ctx, scratchURL.String())
+	if err != nil {
+		t.Fatalf("connect to scratch database: %v", err)
+	}
+	defer scratchConn.Close(ctx)
+	if _, err := scratchConn.Exec(ctx, "UPDATE schema_migrations SET dirty = true"); err != nil {
+		t.Fatalf("mark schema_migrations dirty: %v", err)
+	}
+
+	if _, dirty, ok, err := MigrationStatus(scratchURL.String()); err != nil {
+		t.Fatalf("MigrationStatus (dirty database): %v", err)
+	} else if !ok || !dirty {
+		t.Fatalf("expected ok=true, dirty=true after manually marking dirty, got ok=%v dirty=%v", ok, dirty)
+	}
+
+	version, _, ok, err := MigrationStatus(scratchURL.String())
+	if err != nil || !ok {
+		t.Fatalf("MigrationStatus before force: err=%v ok=%v", err, ok)
+	}
+	if err := ForceMigrationVersion(scratchURL.String(), int(version)); err != nil {
+		t.Fatalf("ForceMigrationVersion: %v", err)
+	}
+
+	if _, dirty, ok, err := MigrationStatus(scratchURL.String()); err != nil {
+		t.Fatalf("MigrationStatus (after force): %v", err)
+	} else if !ok || dirty {
+		t.Fatalf("expected dirty=false after ForceMigrationVersion, got ok=%v dirty=%v", ok, dirty)
+	}
+
+	// A subsequent RunMigrations should now proceed without error (there
+	// may be nothing further to apply, which is also success).
+	if err := RunMigrations(scratchURL.String()); err != nil {
+		t.Fatalf("RunMigrations after force: %v", err)
+	}
+}
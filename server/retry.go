@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// retryablePgCodes are Postgres error codes worth retrying: connection
+// loss and serialization conflicts that a fresh attempt might avoid.
+// Constraint violations and the like are deliberately excluded — retrying
+// those would just fail again.
+var retryablePgCodes = map[string]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"08000": true, // connection_exception
+	"08003": true, // connection_does_not_exist
+	"08006": true, // connection_failure
+}
+
+// isRetryablePgError reports whether err is a Postgres error worth
+// retrying.
+func isRetryablePgError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return retryablePgCodes[pgErr.Code]
+	}
+	return false
+}
+
+// maxRetries and retryBaseDelay bound withRetry's exponential backoff.
+const maxRetries = 3
+const retryBaseDelay = 50 * time.Millisecond
+
+// withRetry calls fn, retrying with exponential backoff when fn returns
+// a retryable Postgres error (connection loss, serialization failure).
+// Any other error, including constraint violations, is returned
+// immediately without retrying. The whole attempt is additionally gated
+// by the circuit breaker: once it's open, withRetry fails immediately
+// with ErrServiceUnavailable instead of running fn (and its retries) at
+// all, and the outcome of whichever attempt it does make is fed back
+// into the breaker.
+func (d *Database) withRetry(ctx context.Context, fn func() error) error {
+	if !d.breaker.allow() {
+		return ErrServiceUnavailable
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = fn()
+		if err == nil || !isRetryablePgError(err) || attempt == maxRetries {
+			d.breaker.recordResult(err)
+			return err
+		}
+
+		delay := retryBaseDelay * time.Duration(1<<attempt)
+		select {
+		case <-ctx.Done():
+			d.breaker.recordResult(ctx.Err())
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestWithRetrySucceedsAfterTransientFailures(t *testing.T) {
+	db := &Database{breaker: newCircuitBreaker()}
+	attempts := 0
+	err := db.withRetry(context.Background(), func() error {
+		attempts++
+		if attempts < 3 {
+			return &pgconn.PgError{Code: "40001"} // serialization_failure
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected eventual success, got %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestWithRetryDoesNotRetryNonRetryableError(t *testing.T) {
+	db := &Database{breaker: newCircuitBreaker()}
+	attempts := 0
+	wantErr := &pgconn.PgError{Code: pgUniqueViolation}
+	err := db.withRetry(context.Background(), func() error {
+		attempts++
+		return wantErr
+	})
+	if !errors.Is(err, error(wantErr)) && err != wantErr {
+		t.Fatalf("expected the non-retryable error to be returned unchanged, got %v", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected exactly 1 attempt for a non-retryable error, got %d", attempts)
+	}
+}
+
+func TestWithRetryGivesUpAfterMaxRetries(t *testing.T) {
+	db := &Database{breaker: newCircuitBreaker()}
+	attempts := 0
+	retryable := &pgconn.PgError{Code: "40001"}
+	err := db.withRetry(context.Background(), func() error {
+		attempts++
+		return retryable
+	})
+	if err != retryable {
+		t.Fatalf("expected the last retryable error to be returned, got %v", err)
+	}
+	if attempts != maxRetries+1 {
+		t.Fatalf("expected %d attempts, got %d", maxRetries+1, attempts)
+	}
+}
@@ -0,0 +1,227 @@
+// Package server implements the password manager's TCP server: session
+// tracking, the per-connection protocol handler, and the Postgres-backed
+// Database.
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// defaultPasswordMaxAge is how old a master password can get before the
+// client is told to nag the user about rotating it.
+const defaultPasswordMaxAge = 90 * 24 * time.Hour
+
+// Server accepts client connections and dispatches each to its own
+// ClientHandler.
+type Server struct {
+	addr     string
+	db       *Database
+	listener net.Listener
+
+	// PasswordMaxAge is the threshold past which AuthResponse reports
+	// RotationDue. Zero disables the check.
+	PasswordMaxAge time.Duration
+
+	// Protocol selects the wire framing: "binary" (default) for the
+	// fixed header format, or "jsonl" for newline-delimited JSON.
+	Protocol string
+
+	// Socket, if set, makes Start listen on a Unix domain socket at this
+	// path instead of addr's TCP address. Useful when the client and
+	// server are co-located on the same host: it skips the TCP stack and
+	// access can be restricted with ordinary filesystem permissions.
+	Socket string
+
+	// BcryptCost is the cost factor used to hash new master passwords and
+	// recovery keys. Higher values are slower to compute, trading
+	// registration/rotation latency for resistance to offline cracking on
+	// beefier hardware. The cost is embedded in each stored bcrypt hash,
+	// so verification always uses whatever cost a hash was created with,
+	// even after this setting changes. Zero means bcrypt.DefaultCost.
+	BcryptCost int
+
+	// MaxMessageSize caps how large a declared request payload the
+	// framing layer will allocate for, independent of any per-item
+	// limits a handler enforces afterward. A connection that declares a
+	// larger length has its payload drained and discarded, and gets back
+	// a "message too large" error instead of the server allocating a
+	// buffer for it. Zero means defaultMaxMessageSize.
+	MaxMessageSize uint32
+
+	// KeepAlivePeriod enables TCP keepalive on each accepted *net.TCPConn
+	// and sets the interval between probes, so a client that vanished
+	// without sending a FIN (a dead laptop, a pulled network cable) is
+	// eventually detected and its handler goroutine and session cleaned
+	// up instead of blocking on a read forever. Zero disables keepalive.
+	// It has no effect on Unix domain socket connections.
+	KeepAlivePeriod time.Duration
+
+	// AllowTypeChange permits UpdateData to change an item's data type.
+	// Off by default: changing a login into a binary item mid-edit is
+	// almost always a client bug, not an intentional edit, and it leaves
+	// the item's metadata (built for its old type) in a confusing state.
+	AllowTypeChange bool
+
+	// MinProtocolVersion, if set, rejects Register/Auth from a client
+	// reporting an older protocol.Version, with a clear error explaining
+	// why, so operators can drop support for old, insecure clients.
+	// Zero (the default) accepts any client, including ones old enough
+	// not to report a version at all.
+	MinProtocolVersion int
+
+	// LogTraffic enables per-message "Received message type: X, length:
+	// Y" logging in Handle. Off by default: combined with item-name logs
+	// elsewhere, per-message traffic logging can reveal usage patterns
+	// (when a user is active, roughly how much data they're moving)
+	// beyond what's needed for ordinary operation.
+	LogTraffic bool
+
+	// ReadTimeout bounds how long a connection may go without sending a
+	// complete request before Handle gives up and closes it, reclaiming
+	// the handler goroutine tied up by a client that connected but never
+	// sends data. Zero disables the timeout. It's set fresh before every
+	// message, so an active connection idling between requests isn't cut
+	// off by the timeout of an earlier, already-answered request.
+	ReadTimeout time.Duration
+
+	// SessionTTL is how long a session token stays valid after
+	// createSession mints it. Zero (the default) means sessions never
+	// expire on their own; combined with SessionJanitorInterval, expired
+	// tokens are periodically swept out of memory instead of
+	// accumulating for the life of the process.
+	SessionTTL time.Duration
+
+	// SessionJanitorInterval, if positive, makes Start launch a
+	// background goroutine that calls expireSessions on this interval
+	// until Stop is called. Zero disables the janitor; SessionTTL alone
+	// still causes sessionUser to reject an expired token, it just won't
+	// be freed from memory until the janitor (or a restart) runs.
+	SessionJanitorInterval time.Duration
+
+	mu            sync.Mutex
+	sessions      map[string]int       // token -> user ID
+	sessionExpiry map[string]time.Time // token -> expiry, only set when SessionTTL > 0
+	janitorStop   chan struct{}
+}
+
+// NewServer creates a Server that will listen on addr and serve data
+// from db.
+func NewServer(addr string, db *Database) *Server {
+	return &Server{
+		addr:           addr,
+		db:             db,
+		sessions:       make(map[string]int),
+		sessionExpiry:  make(map[string]time.Time),
+		PasswordMaxAge: defaultPasswordMaxAge,
+		Protocol:       "binary",
+		BcryptCost:     bcrypt.DefaultCost,
+	}
+}
+
+// Start listens on the server's address (or, if Socket is set, its Unix
+// domain socket) and serves connections until the listener is closed or
+// Accept returns a fatal error.
+func (s *Server) Start() error {
+	network, addr := "tcp", s.addr
+	if s.Socket != "" {
+		network, addr = "unix", s.Socket
+		// A prior server that didn't shut down cleanly (e.g. killed)
+		// leaves its socket file behind, which would otherwise make
+		// Listen fail with "address already in use".
+		if err := os.Remove(addr); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	s.listener = ln
+	log.Printf("Server listening on %s (%s)", addr, network)
+
+	if s.SessionJanitorInterval > 0 {
+		s.janitorStop = make(chan struct{})
+		go s.runSessionJanitor(s.SessionJanitorInterval, s.janitorStop)
+	}
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+		enableKeepAlive(conn, s.KeepAlivePeriod)
+		handler := NewClientHandler(conn, s)
+		if s.Protocol == "jsonl" {
+			go handler.HandleJSONL()
+		} else {
+			go handler.Handle()
+		}
+	}
+}
+
+// enableKeepAlive turns on TCP keepalive on conn with the given probe
+// period, if period is positive and conn is a *net.TCPConn (a Unix
+// domain socket connection has no such option, so it's left alone).
+func enableKeepAlive(conn net.Conn, period time.Duration) {
+	if period <= 0 {
+		return
+	}
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		return
+	}
+	_ = tcpConn.SetKeepAlive(true)
+	_ = tcpConn.SetKeepAlivePeriod(period)
+}
+
+// Stop closes the listener, which unblocks Start, and removes the Unix
+// socket file if the server was listening on one.
+func (s *Server) Stop() error {
+	if s.janitorStop != nil {
+		close(s.janitorStop)
+		s.janitorStop = nil
+	}
+	if s.listener == nil {
+		return nil
+	}
+	err := s.listener.Close()
+	if s.Socket != "" {
+		if rmErr := os.Remove(s.Socket); rmErr != nil && !os.IsNotExist(rmErr) && err == nil {
+			err = rmErr
+		}
+	}
+	return err
+}
+
+// createSession generates a new opaque token for userID and remembers
+// it in memory.
+func (s *Server) createSession(userID int) string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	token := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	s.sessions[token] = userID
+	if s.SessionTTL > 0 {
+		s.sessionExpiry[token] = time.Now().Add(s.SessionTTL)
+	}
+	s.mu.Unlock()
+	return token
+}
+
+// sessionUser resolves a token to the user ID that owns it.
+func (s *Server) sessionUser(token string) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userID, ok := s.sessions[token]
+	return userID, ok
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/SemAnton-2007/go-password-manager/protocol"
+)
+
+// TestServerUnixSocketRoundTrip exercises a full request/response over a
+// Unix domain socket instead of TCP: an unrecognized message type needs
+// no database access to answer, so it's enough to prove the socket is
+// actually serving the wire protocol, and that Stop removes the socket
+// file behind it.
+func TestServerUnixSocketRoundTrip(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "pwmanager.sock")
+
+	srv := NewServer("", nil)
+	srv.Socket = socketPath
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Start() }()
+
+	var conn net.Conn
+	var err error
+	for i := 0; i < 100; i++ {
+		conn, err = net.Dial("unix", socketPath)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("dial unix socket: %v", err)
+	}
+	defer conn.Close()
+
+	header := make([]byte, protocol.HeaderSize)
+	header[0] = 0xEE // unrecognized message type
+	binary.BigEndian.PutUint32(header[1:5], 1)
+	binary.BigEndian.PutUint32(header[5:9], 2)
+	if _, err := conn.Write(header); err != nil {
+		t.Fatalf("write header: %v", err)
+	}
+	if _, err := conn.Write([]byte("{}")); err != nil {
+		t.Fatalf("write payload: %v", err)
+	}
+
+	respHeader := make([]byte, protocol.HeaderSize)
+	if _, err := io.ReadFull(conn, respHeader); err != nil {
+		t.Fatalf("read response header: %v", err)
+	}
+	if respHeader[0] != protocol.MsgTypeErrorResponse {
+		t.Fatalf("expected an error response for an unknown message type, got type %d", respHeader[0])
+	}
+	length := binary.BigEndian.Uint32(respHeader[5:9])
+	respPayload := make([]byte, length)
+	if _, err := io.ReadFull(conn, respPayload); err != nil {
+		t.Fatalf("read response payload: %v", err)
+	}
+
+	if err := srv.Stop(); err != nil {
+		t.Fatalf("stop server: %v", err)
+	}
+	<-errCh
+
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Fatalf("expected Stop to remove the socket file, stat error: %v", err)
+	}
+}
+
+// TestEnableKeepAliveSetsOptionOnTCPConn checks that enableKeepAlive
+// accepts a real *net.TCPConn and returns no error setting the socket
+// option; there's no portable way to read SO_KEEPALIVE back from the
+// kernel to assert it "took".
+func TestEnableKeepAliveSetsOptionOnTCPConn(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	acceptedCh := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			acceptedCh <- conn
+		}
+	}()
+
+	clientConn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer clientConn.Close()
+
+	accepted := <-acceptedCh
+	defer accepted.Close()
+
+	if _, ok := accepted.(*net.TCPConn); !ok {
+		t.Fatalf("expected accepted conn to be a *net.TCPConn, got %T", accepted)
+	}
+	enableKeepAlive(accepted, 30*time.Second)
+}
+
+// TestEnableKeepAliveIgnoresNonTCPConn checks that enableKeepAlive is a
+// no-op (and doesn't panic) on a connection type that has no keepalive
+// option, such as a Unix domain socket.
+func TestEnableKeepAliveIgnoresNonTCPConn(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	enableKeepAlive(serverConn, 30*time.Second)
+}
+
+// TestEnableKeepAliveDisabledByZeroPeriod checks that a zero period
+// leaves the connection untouched, matching Server.KeepAlivePeriod's
+// "zero disables keepalive" doc comment.
+func TestEnableKeepAliveDisabledByZeroPeriod(t *testing.T) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	enableKeepAlive(serverConn, 0)
+}